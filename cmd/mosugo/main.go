@@ -2,9 +2,12 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
 	"image/color"
 	"log"
+	"sync/atomic"
 	"time"
 
 	"fyne.io/fyne/v2"
@@ -17,6 +20,7 @@ import (
 
 	"github.com/F4tal1t/Mosugo/assets"
 	mosuCanvas "github.com/F4tal1t/Mosugo/internal/canvas"
+	"github.com/F4tal1t/Mosugo/internal/storage"
 	"github.com/F4tal1t/Mosugo/internal/theme"
 	"github.com/F4tal1t/Mosugo/internal/tools"
 	"github.com/F4tal1t/Mosugo/internal/ui"
@@ -27,8 +31,18 @@ const (
 )
 
 var (
-	BorderColor = color.RGBA{0, 31, 45, 255}
-	toolButtons []*widget.Button
+	dirFlag  = flag.String("dir", "", "directory to keep the journal in (overrides $"+storage.StorageDirEnv+" and settings.json)")
+	dateFlag = flag.String("date", "", "day to open, as YYYY-MM-DD (default today)")
+)
+
+var (
+	BorderColor   = color.RGBA{0, 31, 45, 255}
+	toolButtons   []*widget.Button
+	strokeOptions *fyne.Container
+
+	// savesHeld pauses auto-save while the user decides what to do about
+	// the open day changing on disk
+	savesHeld atomic.Bool
 )
 
 func loadEmbeddedResource(path string) (fyne.Resource, error) {
@@ -49,12 +63,8 @@ func createToolButton(iconPath string, tool tools.ToolType, mosugoCanvas *mosuCa
 	var btn *widget.Button
 	btn = widget.NewButtonWithIcon("", icon, func() {
 		mosugoCanvas.SetTool(tool)
-		for _, b := range toolButtons {
-			b.Importance = widget.LowImportance
-			b.Refresh()
-		}
-		btn.Importance = widget.HighImportance
-		btn.Refresh()
+		highlightButton(toolButtons, btn)
+		showStrokeOptions(tool)
 		fmt.Println("Selected Tool:", tool)
 	})
 	btn.Importance = widget.LowImportance
@@ -62,8 +72,169 @@ func createToolButton(iconPath string, tool tools.ToolType, mosugoCanvas *mosuCa
 	return btn
 }
 
-func setupCanvas(today time.Time) *mosuCanvas.MosugoCanvas {
+// highlightButton marks selected as the active button of its group.
+func highlightButton(group []*widget.Button, selected *widget.Button) {
+	for _, b := range group {
+		b.Importance = widget.LowImportance
+		b.Refresh()
+	}
+	selected.Importance = widget.HighImportance
+	selected.Refresh()
+}
+
+// setupStrokeOptions builds the Draw tool's ink palette and pen width presets.
+// They set the pen for new strokes and are only shown while drawing.
+func setupStrokeOptions(mosugoCanvas *mosuCanvas.MosugoCanvas) *fyne.Container {
+	var colorButtons, widthButtons []*widget.Button
+	swatches := []fyne.CanvasObject{}
+	for i, col := range theme.StrokePalette {
+		colorIdx := i
+		btn := widget.NewButton("", nil)
+		btn.Importance = widget.LowImportance
+		btn.OnTapped = func() {
+			mosugoCanvas.StrokeColorIdx = colorIdx
+			highlightButton(colorButtons, btn)
+		}
+		if colorIdx == mosugoCanvas.StrokeColorIdx {
+			btn.Importance = widget.HighImportance
+		}
+		colorButtons = append(colorButtons, btn)
+
+		dot := container.NewPadded(container.NewPadded(canvas.NewCircle(col)))
+		swatches = append(swatches, container.NewStack(btn, dot))
+	}
+
+	presets := []fyne.CanvasObject{}
+	for _, width := range theme.StrokeWidths {
+		penWidth := width
+		btn := widget.NewButton("", nil)
+		btn.Importance = widget.LowImportance
+		btn.OnTapped = func() {
+			mosugoCanvas.StrokeWidth = penWidth
+			highlightButton(widthButtons, btn)
+		}
+		if penWidth == mosugoCanvas.StrokeWidth {
+			btn.Importance = widget.HighImportance
+		}
+		widthButtons = append(widthButtons, btn)
+
+		bar := canvas.NewRectangle(theme.InkGrey)
+		bar.SetMinSize(fyne.NewSize(18, penWidth))
+		presets = append(presets, container.NewStack(btn, container.NewCenter(bar)))
+	}
+
+	options := container.NewVBox(
+		container.NewGridWrap(fyne.NewSize(35, 35), swatches...),
+		container.NewGridWrap(fyne.NewSize(35, 35), presets...),
+	)
+	options.Hide()
+	return options
+}
+
+// showStrokeOptions shows the palette and width presets when tool is the Draw tool.
+func showStrokeOptions(tool tools.ToolType) {
+	if strokeOptions == nil {
+		return
+	}
+	if tool == tools.ToolDraw {
+		strokeOptions.Show()
+	} else {
+		strokeOptions.Hide()
+	}
+}
+
+// openStore opens the journal directory picked by the -dir flag, the
+// environment or the settings file.
+func openStore() *storage.FileStore {
+	root, err := storage.ResolveStoragePath(*dirFlag)
+	if err != nil {
+		log.Fatalln("Could not find the storage directory:", err)
+	}
+	store, err := storage.NewFileStore(root)
+	if err != nil {
+		log.Fatalln("Could not open the storage directory:", err)
+	}
+	fmt.Println("Keeping workspaces in", store.Root())
+	return store
+}
+
+// applySettings sets up the canvas with the options from settings.json. A
+// broken settings file keeps the defaults, since the journal opened anyway.
+func applySettings(mosugoCanvas *mosuCanvas.MosugoCanvas) {
+	settings, err := storage.LoadSettings()
+	if err != nil {
+		log.Println("Ignoring settings:", err)
+		return
+	}
+	if settings.UndoLimit != 0 {
+		mosugoCanvas.SetUndoLimit(settings.UndoLimit)
+	}
+}
+
+// openDate returns the day picked by the -date flag, or today.
+func openDate() time.Time {
+	if *dateFlag == "" {
+		return time.Now()
+	}
+	date, err := time.Parse("2006-01-02", *dateFlag)
+	if err != nil {
+		log.Fatalln("Invalid -date, expected YYYY-MM-DD:", err)
+	}
+	return date
+}
+
+// lockStore makes this the one instance writing to the store. When another
+// instance already is, date is handed to it to open and forwarded is true,
+// so this one can exit; if it does not answer, the store is opened
+// read-only instead.
+func lockStore(store *storage.FileStore, date time.Time) (lock *storage.DirLock, forwarded bool) {
+	lock, err := store.Lock()
+	if err == nil {
+		return lock, false
+	}
+	if !errors.Is(err, storage.ErrLocked) {
+		log.Println("Could not lock the storage directory, continuing without:", err)
+		return nil, false
+	}
+
+	if err := store.ForwardOpen(date); err != nil {
+		log.Println("Mosugo is already running but did not answer, opening read-only:", err)
+		store.SetReadOnly(true)
+		return nil, false
+	}
+	fmt.Println("Mosugo is already running; asked it to open", date.Format("2006-01-02"))
+	return nil, true
+}
+
+// listenForOpen opens the days that later instances forward, and brings the
+// window to the front.
+func listenForOpen(lock *storage.DirLock, w fyne.Window, mosugoCanvas *mosuCanvas.MosugoCanvas, metaBorder *ui.MetaballBorder) {
+	err := lock.Listen(func(date time.Time) {
+		fyne.Do(func() {
+			if err := mosugoCanvas.SaveCurrentWorkspace(); err != nil {
+				log.Println("Failed to save workspace:", err)
+			}
+			if err := mosugoCanvas.LoadWorkspace(date); err != nil {
+				log.Println("Failed to load workspace for", date.Format("2006-01-02"), ":", err)
+				return
+			}
+			metaBorder.SetCurrentDate(date)
+			w.RequestFocus()
+			fmt.Println("Opened workspace for another instance:", date.Format("2006-01-02"))
+		})
+	})
+	if err != nil {
+		log.Println("Not accepting days from other instances:", err)
+	}
+}
+
+func setupCanvas(w fyne.Window, store storage.Store, today time.Time) *mosuCanvas.MosugoCanvas {
 	mosugoCanvas := mosuCanvas.NewMosugoCanvas()
+	mosugoCanvas.SetStore(store)
+	applySettings(mosugoCanvas)
+	mosugoCanvas.SetOnRecovered(func(date time.Time, recovery storage.Recovery) {
+		ui.ShowRecoveryWarning(w, date, recovery)
+	})
 
 	var autoSaveTimer *time.Timer
 	mosugoCanvas.SetOnDirty(func() {
@@ -71,12 +242,19 @@ func setupCanvas(today time.Time) *mosuCanvas.MosugoCanvas {
 			autoSaveTimer.Stop()
 		}
 		autoSaveTimer = time.AfterFunc(2*time.Second, func() {
-			err := mosugoCanvas.SaveCurrentWorkspace()
-			if err != nil {
-				log.Println("Auto-save failed:", err)
-			} else {
-				fmt.Println("Auto-saved workspace for", mosugoCanvas.GetCurrentDate().Format("2006-01-02"))
-			}
+			// Saving reads the history and the merge base, which only the UI
+			// goroutine may touch
+			fyne.Do(func() {
+				if savesHeld.Load() {
+					return
+				}
+				err := mosugoCanvas.SaveCurrentWorkspace()
+				if err != nil {
+					log.Println("Auto-save failed:", err)
+				} else {
+					fmt.Println("Auto-saved workspace for", mosugoCanvas.GetCurrentDate().Format("2006-01-02"))
+				}
+			})
 		})
 	})
 
@@ -87,28 +265,100 @@ func setupCanvas(today time.Time) *mosuCanvas.MosugoCanvas {
 	return mosugoCanvas
 }
 
+// setupLiveReload watches the storage directory for the open day being
+// changed by another program, such as a sync tool. A canvas without unsaved
+// changes reloads it; otherwise the user picks which version to keep. A
+// read-only instance always reloads, as its changes cannot be kept anyway.
+func setupLiveReload(w fyne.Window, store *storage.FileStore, mosugoCanvas *mosuCanvas.MosugoCanvas) {
+	_, err := store.Watch(func(date time.Time) {
+		fyne.Do(func() {
+			day := date.Format("2006-01-02")
+			if day != mosugoCanvas.GetCurrentDate().Format("2006-01-02") {
+				return
+			}
+			if !mosugoCanvas.IsDirty() || store.ReadOnly() {
+				if err := mosugoCanvas.ReloadWorkspace(); err != nil {
+					log.Println("Failed to reload workspace:", err)
+				} else {
+					fmt.Println("Reloaded workspace changed on disk:", day)
+				}
+				return
+			}
+			if savesHeld.Swap(true) {
+				return // already asking
+			}
+
+			ui.ShowExternalChangeDialog(w, date, func(choice ui.ExternalChoice) {
+				savesHeld.Store(false)
+				switch choice {
+				case ui.KeepMine:
+					if err := mosugoCanvas.SaveCurrentWorkspace(); err != nil {
+						log.Println("Failed to save workspace:", err)
+					}
+				case ui.LoadTheirs:
+					if err := mosugoCanvas.ReloadWorkspace(); err != nil {
+						log.Println("Failed to reload workspace:", err)
+					}
+				case ui.MergeBoth:
+					theirs, err := store.LoadWorkspace(mosugoCanvas.GetCurrentDate())
+					if err != nil {
+						log.Println("Failed to load workspace to merge:", err)
+						return
+					}
+					conflicts, _ := mosugoCanvas.MergeWorkspace(theirs)
+					if err := mosugoCanvas.SaveCurrentWorkspace(); err != nil {
+						log.Println("Failed to save workspace:", err)
+					}
+					if len(conflicts) > 0 {
+						ui.ShowMergeConflictsDialog(w, conflicts)
+					}
+				}
+			})
+		})
+	})
+	if err != nil {
+		log.Println("Not watching the storage directory for changes:", err)
+	}
+}
+
 func setupToolbar(mosugoCanvas *mosuCanvas.MosugoCanvas) *fyne.Container {
 	selectBtn := createToolButton("select.svg", tools.ToolSelect, mosugoCanvas)
 	cardBtn := createToolButton("card.svg", tools.ToolCard, mosugoCanvas)
 	drawBtn := createToolButton("draw.svg", tools.ToolDraw, mosugoCanvas)
 	eraseBtn := createToolButton("eraser.svg", tools.ToolErase, mosugoCanvas)
+	lassoBtn := createToolButton("lasso.svg", tools.ToolLasso, mosugoCanvas)
 
 	toolbarButtons := container.NewGridWrap(fyne.NewSize(35, 35),
-		selectBtn, cardBtn, drawBtn, eraseBtn,
+		selectBtn, cardBtn, drawBtn, eraseBtn, lassoBtn,
 	)
 
 	leftPadding := canvas.NewRectangle(color.Transparent)
 	leftPadding.SetMinSize(fyne.NewSize(5, 0))
 
-	toolbarAligned := container.NewHBox(leftPadding, toolbarButtons, layout.NewSpacer())
+	strokeOptions = setupStrokeOptions(mosugoCanvas)
+
+	toolbarAligned := container.NewHBox(leftPadding, toolbarButtons, strokeOptions, layout.NewSpacer())
 	return container.NewVBox(layout.NewSpacer(), toolbarAligned, layout.NewSpacer())
 }
 
-func setupBorderAndCalendar(today time.Time, mosugoCanvas *mosuCanvas.MosugoCanvas) *ui.MetaballBorder {
+// setupHistoryPanel creates the history timeline, hidden until toggled, and
+// the layer that keeps it at the right edge of the window.
+func setupHistoryPanel(mosugoCanvas *mosuCanvas.MosugoCanvas) (*ui.HistoryPanel, *fyne.Container) {
+	panel := ui.NewHistoryPanel(mosugoCanvas)
+	panel.Hide()
+
+	rightPadding := canvas.NewRectangle(color.Transparent)
+	rightPadding.SetMinSize(fyne.NewSize(5, 0))
+
+	panelAligned := container.NewHBox(layout.NewSpacer(), panel, rightPadding)
+	return panel, container.NewVBox(layout.NewSpacer(), panelAligned, layout.NewSpacer())
+}
+
+func setupBorderAndCalendar(store storage.Store, today time.Time, mosugoCanvas *mosuCanvas.MosugoCanvas) *ui.MetaballBorder {
 	metaBorder := ui.NewMetaballBorder(BorderColor)
 	metaBorder.SetCurrentDate(today)
 
-	calendarContent := ui.NewCalendarContent(today, func(selectedDate time.Time) {
+	calendarContent := ui.NewCalendarContent(store, today, func(selectedDate time.Time) {
 		if err := mosugoCanvas.SaveCurrentWorkspace(); err != nil {
 			log.Println("Failed to save workspace:", err)
 		}
@@ -142,6 +392,7 @@ func setupKeyboardShortcuts(w fyne.Window, mosugoCanvas *mosuCanvas.MosugoCanvas
 
 	if deskCanvas, ok := w.Canvas().(desktop.Canvas); ok {
 		state := &keyboardShortcutState{
+			window:       w,
 			mosugoCanvas: mosugoCanvas,
 			undoHandler:  undoHandler,
 			redoHandler:  redoHandler,
@@ -160,6 +411,64 @@ func setupKeyboardShortcuts(w fyne.Window, mosugoCanvas *mosuCanvas.MosugoCanvas
 		}
 	})
 
+	ctrlShift := fyne.KeyModifierControl | fyne.KeyModifierShift
+
+	fitAll := &desktop.CustomShortcut{KeyName: fyne.KeyF, Modifier: ctrlShift}
+	w.Canvas().AddShortcut(fitAll, func(shortcut fyne.Shortcut) {
+		if !mosugoCanvas.ZoomToFit() {
+			log.Println("Nothing to frame")
+		}
+	})
+
+	fitSelection := &desktop.CustomShortcut{KeyName: fyne.KeyS, Modifier: ctrlShift}
+	w.Canvas().AddShortcut(fitSelection, func(shortcut fyne.Shortcut) {
+		if !mosugoCanvas.ZoomToSelection() {
+			log.Println("Nothing selected to frame")
+		}
+	})
+
+	cycleColor := &desktop.CustomShortcut{KeyName: fyne.KeyC, Modifier: ctrlShift}
+	w.Canvas().AddShortcut(cycleColor, func(shortcut fyne.Shortcut) {
+		if !mosugoCanvas.CycleSelectionColor() {
+			log.Println("Nothing selected")
+		}
+	})
+
+	duplicate := &desktop.CustomShortcut{KeyName: fyne.KeyD, Modifier: fyne.KeyModifierControl}
+	w.Canvas().AddShortcut(duplicate, func(shortcut fyne.Shortcut) {
+		if !mosugoCanvas.SelectionToolActive() {
+			return
+		}
+		if !mosugoCanvas.DuplicateSelection() {
+			log.Println("Nothing selected")
+		}
+	})
+
+	// Copied cards and strokes go through the system clipboard, so they can
+	// be pasted into another day's workspace
+	w.Canvas().AddShortcut(&fyne.ShortcutCopy{}, func(shortcut fyne.Shortcut) {
+		if !mosugoCanvas.SelectionToolActive() {
+			return
+		}
+		if !mosugoCanvas.CopySelection(fyne.CurrentApp().Clipboard()) {
+			log.Println("Nothing selected")
+		}
+	})
+
+	w.Canvas().AddShortcut(&fyne.ShortcutPaste{}, func(shortcut fyne.Shortcut) {
+		if !mosugoCanvas.SelectionToolActive() {
+			return
+		}
+		if !mosugoCanvas.PasteClipboard(fyne.CurrentApp().Clipboard()) {
+			log.Println("Nothing to paste")
+		}
+	})
+
+	resetView := &desktop.CustomShortcut{KeyName: fyne.KeyR, Modifier: ctrlShift}
+	w.Canvas().AddShortcut(resetView, func(shortcut fyne.Shortcut) {
+		mosugoCanvas.ResetView()
+	})
+
 	ctrlLeft := &desktop.CustomShortcut{KeyName: fyne.KeyLeft, Modifier: fyne.KeyModifierControl}
 	w.Canvas().AddShortcut(ctrlLeft, func(shortcut fyne.Shortcut) {
 		currentDate := mosugoCanvas.GetCurrentDate()
@@ -196,6 +505,7 @@ func setupKeyboardShortcuts(w fyne.Window, mosugoCanvas *mosuCanvas.MosugoCanvas
 }
 
 type keyboardShortcutState struct {
+	window       fyne.Window
 	mosugoCanvas *mosuCanvas.MosugoCanvas
 	undoHandler  func()
 	redoHandler  func()
@@ -207,7 +517,7 @@ type keyboardShortcutState struct {
 }
 
 func (s *keyboardShortcutState) onKeyDown(key *fyne.KeyEvent) {
-	if s.handleModifierDown(key.Name) || s.handleUndoRedoDown(key.Name) || s.handleToolDown(key.Name) {
+	if s.handleModifierDown(key.Name) || s.handleUndoRedoDown(key.Name) || s.handleDeleteDown(key.Name) || s.handleToolDown(key.Name) {
 		return
 	}
 }
@@ -272,12 +582,27 @@ func (s *keyboardShortcutState) handleUndoRedoDown(keyName fyne.KeyName) bool {
 	}
 }
 
+// handleDeleteDown deletes the selection, unless a card is being typed in.
+func (s *keyboardShortcutState) handleDeleteDown(keyName fyne.KeyName) bool {
+	if keyName != fyne.KeyDelete && keyName != fyne.KeyBackspace {
+		return false
+	}
+	if s.window.Canvas().Focused() != nil {
+		return false
+	}
+	if !s.mosugoCanvas.DeleteSelection() {
+		log.Println("Nothing selected")
+	}
+	return true
+}
+
 func (s *keyboardShortcutState) handleToolDown(keyName fyne.KeyName) bool {
 	tool, ok := toolShortcutMap[keyName]
 	if !ok {
 		return false
 	}
 	s.mosugoCanvas.SetTool(tool)
+	showStrokeOptions(tool)
 	fmt.Println("Tool:", tool)
 	return true
 }
@@ -286,19 +611,36 @@ var toolShortcutMap = map[fyne.KeyName]tools.ToolType{
 	fyne.Key1:           tools.ToolCard,
 	fyne.Key2:           tools.ToolDraw,
 	fyne.Key3:           tools.ToolErase,
+	fyne.Key4:           tools.ToolLasso,
 	fyne.Key0:           tools.ToolSelect,
 	fyne.KeyEscape:      tools.ToolCard,
 	fyne.KeyName("KP1"): tools.ToolCard,
 	fyne.KeyName("KP2"): tools.ToolDraw,
 	fyne.KeyName("KP3"): tools.ToolErase,
+	fyne.KeyName("KP4"): tools.ToolLasso,
 	fyne.KeyName("KP0"): tools.ToolSelect,
 }
 
 func main() {
+	flag.Parse()
+	today := openDate()
+	store := openStore()
+	lock, forwarded := lockStore(store, today)
+	if forwarded {
+		return
+	}
+	if lock != nil {
+		defer lock.Unlock()
+	}
+
 	a := app.NewWithID("com.mosugo")
 	a.Settings().SetTheme(theme.NewMosugoTheme())
 
-	w := a.NewWindow("Mosugo")
+	title := "Mosugo"
+	if store.ReadOnly() {
+		title += " (read-only)"
+	}
+	w := a.NewWindow(title)
 	w.Resize(fyne.NewSize(600, 500))
 	w.SetPadded(false)
 
@@ -306,14 +648,29 @@ func main() {
 		w.SetIcon(icon)
 	}
 
-	today := time.Now()
-	mosugoCanvas := setupCanvas(today)
+	mosugoCanvas := setupCanvas(w, store, today)
 	toolbarLayer := setupToolbar(mosugoCanvas)
-	metaBorder := setupBorderAndCalendar(today, mosugoCanvas)
+	metaBorder := setupBorderAndCalendar(store, today, mosugoCanvas)
+
+	historyPanel, historyLayer := setupHistoryPanel(mosugoCanvas)
 
-	finalLayout := container.NewStack(mosugoCanvas, metaBorder, toolbarLayer)
+	finalLayout := container.NewStack(mosugoCanvas, metaBorder, toolbarLayer, historyLayer)
 
 	setupKeyboardShortcuts(w, mosugoCanvas, metaBorder)
+	setupLiveReload(w, store, mosugoCanvas)
+	if lock != nil {
+		listenForOpen(lock, w, mosugoCanvas, metaBorder)
+	}
+
+	toggleHistory := &desktop.CustomShortcut{KeyName: fyne.KeyH, Modifier: fyne.KeyModifierControl}
+	w.Canvas().AddShortcut(toggleHistory, func(shortcut fyne.Shortcut) {
+		if historyPanel.Visible() {
+			historyPanel.Hide()
+		} else {
+			historyPanel.Sync()
+			historyPanel.Show()
+		}
+	})
 
 	w.SetContent(finalLayout)
 	w.ShowAndRun()
@@ -6,7 +6,9 @@ package canvas
 
 import (
 	"image/color"
+	"log"
 	"math"
+	"sort"
 	"time"
 
 	"fyne.io/fyne/v2"
@@ -17,6 +19,7 @@ import (
 
 	"github.com/F4tal1t/Mosugo/internal/cards"
 	"github.com/F4tal1t/Mosugo/internal/storage"
+	"github.com/F4tal1t/Mosugo/internal/strokes"
 	"github.com/F4tal1t/Mosugo/internal/theme"
 	"github.com/F4tal1t/Mosugo/internal/tools"
 )
@@ -54,16 +57,22 @@ func (c *MosugoCanvas) GetScale() float32           { return c.Scale }
 func (c *MosugoCanvas) AddObject(o fyne.CanvasObject) {
 	if card, ok := o.(*cards.MosuWidget); ok {
 		c.wireCardCallbacks(card)
+		c.UpdateCardBounds(card)
 	}
 	c.Content.Add(o)
 }
 func (c *MosugoCanvas) RemoveObject(o fyne.CanvasObject) {
-	if line, ok := o.(*canvas.Line); ok {
-		delete(c.strokesMap, line)
-		delete(c.strokeIDMap, line)
-		delete(c.glowLines, line)
+	if stroke, ok := o.(*strokes.Stroke); ok && c.strokeByID[stroke.ID] == stroke {
+		delete(c.strokeByID, stroke.ID)
 	}
+	c.objectIndex().Remove(o)
+	delete(c.onScreen, o)
+	delete(c.cullPending, o)
 	c.Content.Remove(o)
+	if c.selected[o] {
+		delete(c.selected, o)
+		c.syncSelection()
+	}
 }
 
 func (c *MosugoCanvas) SetCursor(cur desktop.Cursor) {
@@ -71,14 +80,16 @@ func (c *MosugoCanvas) SetCursor(cur desktop.Cursor) {
 	c.Refresh()
 }
 
-func (c *MosugoCanvas) ContentObject() fyne.CanvasObject       { return c.Content }
-func (c *MosugoCanvas) Snap(v float32) float32                 { return snap(v) }
-func (c *MosugoCanvas) SnapUp(v float32) float32               { return snapUp(v) }
-func (c *MosugoCanvas) ContentContainer() *fyne.Container      { return c.Content }
-func (c *MosugoCanvas) GhostRect() *canvas.Rectangle           { return c.ghostRect }
-func (c *MosugoCanvas) GetSelectedCard() *cards.MosuWidget     { return c.selectedCard }
-func (c *MosugoCanvas) SetSelectedCard(card *cards.MosuWidget) { c.selectedCard = card }
+func (c *MosugoCanvas) ContentObject() fyne.CanvasObject  { return c.Content }
+func (c *MosugoCanvas) Snap(v float32) float32            { return snap(v) }
+func (c *MosugoCanvas) SnapUp(v float32) float32          { return snapUp(v) }
+func (c *MosugoCanvas) ContentContainer() *fyne.Container { return c.Content }
+func (c *MosugoCanvas) GhostRect() *canvas.Rectangle      { return c.ghostRect }
 func (c *MosugoCanvas) SetTool(t tools.ToolType) {
+	// A gesture cut short by the switch keeps what it did as one step
+	for c.InTransaction() {
+		c.CommitTransaction()
+	}
 	c.CurrentTool = t
 	switch t {
 	case tools.ToolSelect:
@@ -89,9 +100,13 @@ func (c *MosugoCanvas) SetTool(t tools.ToolType) {
 		c.ActiveTool = &tools.DrawTool{}
 	case tools.ToolErase:
 		c.ActiveTool = &tools.EraseTool{}
+	case tools.ToolLasso:
+		c.ActiveTool = &tools.LassoTool{}
 	default:
 		c.ActiveTool = &tools.SelectTool{}
 	}
+	// Resize handles and the transform box depend on the tool
+	c.syncSelection()
 	c.Refresh()
 }
 
@@ -113,33 +128,58 @@ type MosugoCanvas struct {
 	CurrentTool tools.ToolType
 	ActiveTool  tools.Tool
 
-	ghostRect    *canvas.Rectangle
-	selectedCard *cards.MosuWidget
+	ghostRect *canvas.Rectangle
+
+	// lasso and transformBox are drawn above the content by the Lasso tool;
+	// transformBase holds the selection geometry while a transform is dragged
+	lasso         *strokes.Stroke
+	transformBox  *transformBox
+	transformBase map[fyne.CanvasObject]shapeSnapshot
+
+	// selected holds the selected cards and strokes
+	selected map[fyne.CanvasObject]bool
 
 	isPanning bool
 	panStart  fyne.Position
+	modifiers fyne.KeyModifier // held when the last mouse button went down
+	pointer   fyne.Position    // last mouse position over the canvas, screen space
+	pointerIn bool
 
-	currentStroke []*canvas.Line
-	strokes       [][]*canvas.Line
-
-	strokesMap   map[*canvas.Line]StrokeCoords
-	strokeIDMap  map[*canvas.Line]int
-	glowLines    map[*canvas.Line]bool
+	strokeByID   map[int]*strokes.Stroke
 	nextStrokeID int
 
-	StrokeWidth float32
-	StrokeColor color.Color
+	// index is the world-space spatial index over cards and strokes
+	index *spatialIndex
+	// onScreen holds the objects shown by the last layout pass, cullPending
+	// the ones (re)indexed since then that the next pass still has to check
+	onScreen    map[fyne.CanvasObject]bool
+	cullPending map[fyne.CanvasObject]bool
+
+	// StrokeWidth and StrokeColorIdx are the pen used for new strokes
+	StrokeWidth    float32
+	StrokeColorIdx int
 
-	lastScale float32
+	lastScale   float32
+	viewAnimSeq int // bumped to cancel a running camera tween
 
 	// Persistence fields
+	store           storage.Store // where workspaces are saved; in memory until SetStore
 	currentDate     time.Time
+	saved           storage.WorkspaceState // the open day as last loaded or saved; what merges start from
 	isDirty         bool
 	onDirty         func() // Callback when canvas becomes dirty
+	onRecovered     func(date time.Time, recovery storage.Recovery)
 	uiReady         bool
 	suppressHistory bool
-	undoStack       []historyCommand
-	redoStack       []historyCommand
+	undoStack       []historyStep
+	redoStack       []historyStep
+	undoLimit       int              // most steps kept in undoStack; < 1 keeps all
+	textRun         *textRun         // text edit the latest step can still absorb
+	txnCmds         []historyCommand // commands recorded by open transactions
+	txnMarks        []int            // len(txnCmds) at each open BeginTransaction
+	now             func() time.Time
+	traveling       bool   // TravelTo is stepping through history
+	onHistory       func() // Callback when the undo or redo stack changes
 }
 
 // NewMosugoCanvas creates and initializes a new MosugoCanvas with default settings.
@@ -151,13 +191,16 @@ func NewMosugoCanvas() *MosugoCanvas {
 		CurrentTool:  tools.ToolSelect,
 		ActiveTool:   &tools.SelectTool{},
 		StrokeWidth:  2.5,
-		StrokeColor:  theme.InkGrey,
-		strokesMap:   make(map[*canvas.Line]StrokeCoords),
-		strokeIDMap:  make(map[*canvas.Line]int),
-		glowLines:    make(map[*canvas.Line]bool),
+		strokeByID:   make(map[int]*strokes.Stroke),
 		nextStrokeID: 1,
+		index:        newSpatialIndex(),
+		onScreen:     make(map[fyne.CanvasObject]bool),
+		cullPending:  make(map[fyne.CanvasObject]bool),
+		store:        storage.NewMemoryStore(),
 		currentDate:  time.Now(),
 		isDirty:      false,
+		undoLimit:    DefaultUndoLimit,
+		now:          time.Now,
 	}
 	c.ExtendBaseWidget(c)
 
@@ -170,6 +213,12 @@ func NewMosugoCanvas() *MosugoCanvas {
 	c.ghostRect.Hide()
 
 	c.Content.Add(c.ghostRect)
+
+	c.lasso = strokes.NewStroke(0, theme.SelectionBlue, 0, lassoWidth)
+	c.lasso.Halo = color.Transparent
+	c.lasso.Hide()
+	c.transformBox = newTransformBox()
+
 	c.resetHistory()
 
 	return c
@@ -225,7 +274,8 @@ func (r *mosugoRenderer) Layout(size fyne.Size) {
 		r.canvas.Content.Resize(size)
 		r.canvas.Content.Move(fyne.NewPos(0, 0))
 
-		for _, obj := range r.canvas.Content.Objects {
+		// Only objects near the viewport are laid out; the rest stay hidden
+		for _, obj := range r.canvas.cullContent(size) {
 			if mosuW, ok := obj.(*cards.MosuWidget); ok {
 				// Use WorldToScreen for robust positioning (Float based)
 				screenPos := r.canvas.WorldToScreen(mosuW.WorldPos)
@@ -239,106 +289,87 @@ func (r *mosugoRenderer) Layout(size fyne.Size) {
 
 				mosuW.Move(screenPos)
 				mosuW.Resize(screenSize)
-			} else if rect, ok := obj.(*canvas.Rectangle); ok && rect == r.canvas.ghostRect {
-				// Ghost rect logic handled by Tool
-			} else if line, ok := obj.(*canvas.Line); ok {
-				if coords, ok := r.canvas.GetStrokeCoords(line); ok {
-					p1 := r.canvas.WorldToScreen(coords.P1)
-					p2 := r.canvas.WorldToScreen(coords.P2)
-					line.Position1 = p1
-					line.Position2 = p2
-
-					if r.canvas.glowLines[line] {
-						line.StrokeWidth = r.canvas.StrokeWidth * 2 * r.canvas.Scale
-					} else {
-						line.StrokeWidth = r.canvas.StrokeWidth * r.canvas.Scale
-					}
-				}
+			} else if stroke, ok := obj.(*strokes.Stroke); ok {
+				r.layoutStroke(stroke, size)
 			}
 		}
 	}
+	r.layoutOverlay(size)
+}
+
+// layoutStroke places a stroke over the part of its bounds that lies near the
+// viewport, so zooming into a long stroke never rasterizes more than the window.
+func (r *mosugoRenderer) layoutStroke(stroke *strokes.Stroke, size fyne.Size) {
+	c := r.canvas
+	view := c.visibleWorldRect(size)
+
+	// one screen pixel of slack for the antialiased edge
+	pad := 1 / c.Scale
+	lo, hi := stroke.Bounds()
+	lo = fyne.NewPos(fyne.Max(lo.X-pad, view.Min.X), fyne.Max(lo.Y-pad, view.Min.Y))
+	hi = fyne.NewPos(fyne.Min(hi.X+pad, view.Max.X), fyne.Min(hi.Y+pad, view.Max.Y))
+
+	stroke.Move(c.WorldToScreen(lo))
+	stroke.SetView(lo, c.Scale, fyne.NewSize(
+		fyne.Max(hi.X-lo.X, 0)*c.Scale,
+		fyne.Max(hi.Y-lo.Y, 0)*c.Scale,
+	))
 }
 
 // ------------------------------------------------------------------
 // Stroke Management Helpers
 // ------------------------------------------------------------------
 
-// StrokeCoords holds the world-space start and end positions of a stroke line.
-type StrokeCoords struct {
-	P1, P2 fyne.Position
-}
-
-// AddStroke adds a new stroke line to the canvas with the specified coordinates and stroke ID.
+// AddStroke appends a segment to the stroke with the given ID, creating the
+// stroke if needed. Invalid IDs get a freshly generated stroke.
 func (c *MosugoCanvas) AddStroke(p1, p2 fyne.Position, strokeID int) {
-	c.addStrokeSegmentWithWidth(p1, p2, strokeID, c.StrokeWidth)
+	c.addStrokeSegment(p1, p2, strokeID, c.StrokeColorIdx, c.StrokeWidth)
 }
 
-func (c *MosugoCanvas) addStrokeSegmentWithWidth(p1, p2 fyne.Position, strokeID int, width float32) {
+// addStrokeSegment is AddStroke with an explicit style. The style only
+// applies when the segment starts a new stroke.
+func (c *MosugoCanvas) addStrokeSegment(p1, p2 fyne.Position, strokeID, colorIdx int, width float32) {
 	// Defensive check: ensure stroke ID is valid
 	if !c.ValidateStrokeID(strokeID) {
 		// This shouldn't happen, but generate a valid ID if it does
 		strokeID = c.GenerateStrokeID()
 	}
 
-	glowLine := canvas.NewLine(theme.GridBg)
-	glowLine.StrokeWidth = width * 1.5
-	glowLine.Position1 = c.WorldToScreen(p1)
-	glowLine.Position2 = c.WorldToScreen(p2)
-	c.Content.Add(glowLine)
-	c.RegisterStroke(glowLine, p1, p2, strokeID)
-	c.glowLines[glowLine] = true
-
-	line := canvas.NewLine(c.StrokeColor)
-	line.StrokeWidth = width
-	line.Position1 = c.WorldToScreen(p1)
-	line.Position2 = c.WorldToScreen(p2)
-	c.Content.Add(line)
-	c.RegisterStroke(line, p1, p2, strokeID)
-}
-
-// RegisterStroke associates stroke metadata with a line object for tracking.
-func (c *MosugoCanvas) RegisterStroke(line *canvas.Line, p1, p2 fyne.Position, strokeID int) {
-	if c.strokesMap == nil {
-		c.strokesMap = make(map[*canvas.Line]StrokeCoords)
+	stroke := c.strokeByID[strokeID]
+	if stroke == nil {
+		stroke = c.newStroke(strokeID, colorIdx, width)
 	}
-	if c.strokeIDMap == nil {
-		c.strokeIDMap = make(map[*canvas.Line]int)
+	// A segment that doesn't continue the polyline is joined to it
+	if n := len(stroke.Points); n == 0 || stroke.Points[n-1] != p1 {
+		stroke.Append(p1)
 	}
-	c.strokesMap[line] = StrokeCoords{P1: p1, P2: p2}
-	c.strokeIDMap[line] = strokeID
+	stroke.Append(p2)
+	c.UpdateStrokeBounds(stroke)
 }
 
-// GetStrokeCoords retrieves the world coordinates for a given stroke line.
-func (c *MosugoCanvas) GetStrokeCoords(line *canvas.Line) (StrokeCoords, bool) {
-	if c.strokesMap == nil {
-		return StrokeCoords{}, false
+func (c *MosugoCanvas) newStroke(strokeID, colorIdx int, width float32) *strokes.Stroke {
+	if c.strokeByID == nil {
+		c.strokeByID = make(map[int]*strokes.Stroke)
 	}
-	coords, ok := c.strokesMap[line]
-	return coords, ok
+	stroke := strokes.NewStroke(strokeID, theme.StrokeColor(colorIdx), colorIdx, width)
+	c.strokeByID[strokeID] = stroke
+	c.Content.Add(stroke)
+	return stroke
 }
 
-// GetStrokePoints retrieves the start and end points of a stroke line in world coordinates.
-func (c *MosugoCanvas) GetStrokePoints(line *canvas.Line) (fyne.Position, fyne.Position, bool) {
-	if c.strokesMap == nil {
-		return fyne.Position{}, fyne.Position{}, false
-	}
-	coords, ok := c.strokesMap[line]
-	return coords.P1, coords.P2, ok
-}
-
-func (c *MosugoCanvas) GetStrokeID(line *canvas.Line) (int, bool) {
-	if c.strokeIDMap == nil {
-		return 0, false
-	}
-	id, ok := c.strokeIDMap[line]
-	return id, ok
+// StrokeByID returns the stroke with the given ID, or nil.
+func (c *MosugoCanvas) StrokeByID(strokeID int) *strokes.Stroke {
+	return c.strokeByID[strokeID]
 }
 
-func (c *MosugoCanvas) IsGlowLine(line *canvas.Line) bool {
-	if c.glowLines == nil {
-		return false
+// sortedStrokes returns every stroke ordered by ID, i.e. in drawing order.
+func (c *MosugoCanvas) sortedStrokes() []*strokes.Stroke {
+	all := make([]*strokes.Stroke, 0, len(c.strokeByID))
+	for _, stroke := range c.strokeByID {
+		all = append(all, stroke)
 	}
-	return c.glowLines[line]
+	sort.Slice(all, func(i, j int) bool { return all[i].ID < all[j].ID })
+	return all
 }
 
 // GenerateStrokeID creates a unique ID for a new stroke.
@@ -430,7 +461,8 @@ func (r *mosugoRenderer) MinSize() fyne.Size {
 
 // Objects returns the list of drawable objects for the canvas.
 func (r *mosugoRenderer) Objects() []fyne.CanvasObject {
-	return []fyne.CanvasObject{r.canvas.Grid, r.canvas.Content}
+	objects := []fyne.CanvasObject{r.canvas.Grid, r.canvas.Content, r.canvas.lasso}
+	return append(objects, r.canvas.transformBox.objects()...)
 }
 
 // Refresh triggers a redraw of the canvas.
@@ -455,6 +487,8 @@ func (c *MosugoCanvas) Cursor() desktop.Cursor {
 		return desktop.TextCursor
 	case tools.ToolErase:
 		return desktop.HResizeCursor
+	case tools.ToolLasso:
+		return desktop.CrosshairCursor
 	}
 	return desktop.DefaultCursor
 }
@@ -466,17 +500,34 @@ func (c *MosugoCanvas) Tapped(e *fyne.PointEvent) {
 	}
 }
 
-// MouseDown handles mouse button down events (for middle-click pan).
+// MouseDown handles mouse button down events. The right and middle buttons
+// pan in every tool, now that dragging across empty space draws a marquee.
 func (c *MosugoCanvas) MouseDown(e *desktop.MouseEvent) {
-	if e.Button == desktop.MouseButtonSecondary {
+	c.modifiers = e.Modifier
+	if e.Button == desktop.MouseButtonSecondary || e.Button == desktop.MouseButtonTertiary {
 		c.isPanning = true
 		c.panStart = e.Position
 	}
 }
 
+// MouseIn starts tracking the pointer, which is where pastes land.
+func (c *MosugoCanvas) MouseIn(e *desktop.MouseEvent) {
+	c.pointer, c.pointerIn = e.Position, true
+}
+
+// MouseMoved tracks the pointer.
+func (c *MosugoCanvas) MouseMoved(e *desktop.MouseEvent) {
+	c.pointer, c.pointerIn = e.Position, true
+}
+
+// MouseOut stops tracking the pointer.
+func (c *MosugoCanvas) MouseOut() {
+	c.pointerIn = false
+}
+
 // MouseUp handles mouse button release events.
 func (c *MosugoCanvas) MouseUp(e *desktop.MouseEvent) {
-	if e.Button == desktop.MouseButtonSecondary {
+	if e.Button == desktop.MouseButtonSecondary || e.Button == desktop.MouseButtonTertiary {
 		c.isPanning = false
 	}
 }
@@ -485,6 +536,7 @@ func (c *MosugoCanvas) MouseUp(e *desktop.MouseEvent) {
 func (c *MosugoCanvas) Dragged(e *fyne.DragEvent) {
 	// Panning takes precedence if right-mouse is held
 	if c.isPanning {
+		c.stopViewAnimation()
 		delta := e.Position.Subtract(c.panStart)
 		c.Offset.X += delta.X
 		c.Offset.Y += delta.Y
@@ -529,6 +581,17 @@ func (c *MosugoCanvas) SetOnDirty(callback func()) {
 	c.onDirty = callback
 }
 
+// SetOnRecovered sets the callback called when a day is loaded whose file
+// was damaged, with how it was recovered, so the user can be warned.
+func (c *MosugoCanvas) SetOnRecovered(callback func(date time.Time, recovery storage.Recovery)) {
+	c.onRecovered = callback
+}
+
+// SetStore sets where the canvas saves and loads workspaces.
+func (c *MosugoCanvas) SetStore(store storage.Store) {
+	c.store = store
+}
+
 // GetCurrentDate returns the date of the currently loaded workspace
 func (c *MosugoCanvas) GetCurrentDate() time.Time {
 	return c.currentDate
@@ -541,6 +604,19 @@ func (c *MosugoCanvas) SetCurrentDate(date time.Time) {
 
 // SaveCurrentWorkspace saves the current canvas state to storage
 func (c *MosugoCanvas) SaveCurrentWorkspace() error {
+	state := c.workspaceState()
+	if err := c.store.SaveWorkspace(c.currentDate, state); err != nil {
+		return err
+	}
+	c.saved = state
+	c.isDirty = false
+
+	// The history goes next to the workspace, so undo survives day switches and restarts
+	return c.store.SaveHistory(c.currentDate, c.historyState())
+}
+
+// workspaceState collects the canvas state as it is saved
+func (c *MosugoCanvas) workspaceState() storage.WorkspaceState {
 	state := storage.WorkspaceState{
 		Scale:   c.Scale,
 		OffsetX: c.Offset.X,
@@ -567,51 +643,24 @@ func (c *MosugoCanvas) SaveCurrentWorkspace() error {
 		}
 	}
 
-	// Collect strokes
-	for line, coords := range c.strokesMap {
-		// Skip glow lines (only save the actual strokes)
-		if c.glowLines[line] {
-			continue
-		}
-
-		// Validate stroke ID before saving
-		strokeID, ok := c.GetStrokeID(line)
-		if !ok || !c.ValidateStrokeID(strokeID) {
-			// Skip strokes without valid IDs (shouldn't happen, but defensive)
-			continue
-		}
-
-		strokeData := storage.StrokeData{
-			P1X:      coords.P1.X,
-			P1Y:      coords.P1.Y,
-			P2X:      coords.P2.X,
-			P2Y:      coords.P2.Y,
-			ColorIdx: 0, // Default color index for now
-			Width:    c.StrokeWidth,
-			StrokeID: strokeID,
-		}
-		state.Strokes = append(state.Strokes, strokeData)
+	// Collect strokes, one segment per polyline edge
+	for _, stroke := range c.sortedStrokes() {
+		state.Strokes = append(state.Strokes, strokeSegmentData(stroke)...)
 	}
 
-	// Save to file
-	if err := storage.SaveWorkspace(c.currentDate, state); err != nil {
-		return err
-	}
-
-	c.isDirty = false
-	return nil
+	return state
 }
 
 // LoadWorkspace loads a workspace from storage and replaces the current canvas state
 func (c *MosugoCanvas) LoadWorkspace(date time.Time) error {
 	// Load workspace state
-	state, err := storage.LoadWorkspace(date)
+	state, err := c.store.LoadWorkspace(date)
 	if err != nil {
 		return err
 	}
 
 	c.ClearCanvas()
-	c.Scale = state.Scale
+	c.Scale = clampScale(state.Scale)
 	c.Offset = fyne.NewPos(state.OffsetX, state.OffsetY)
 	c.currentDate = date
 
@@ -622,14 +671,9 @@ func (c *MosugoCanvas) LoadWorkspace(date time.Time) error {
 		}
 	}
 
+	// Reserve the saved IDs first so migrated strokes never collide with them
 	maxStrokeID := 0
 	for _, strokeData := range state.Strokes {
-		c.addStrokeSegmentWithWidth(
-			fyne.NewPos(strokeData.P1X, strokeData.P1Y),
-			fyne.NewPos(strokeData.P2X, strokeData.P2Y),
-			strokeData.StrokeID,
-			strokeData.Width,
-		)
 		if strokeData.StrokeID > maxStrokeID {
 			maxStrokeID = strokeData.StrokeID
 		}
@@ -637,13 +681,37 @@ func (c *MosugoCanvas) LoadWorkspace(date time.Time) error {
 	if maxStrokeID >= c.nextStrokeID {
 		c.nextStrokeID = maxStrokeID + 1
 	}
+	c.addStrokeSegments(state.Strokes)
 
+	c.saved = state
 	c.isDirty = false
 	c.resetHistory()
+	if state.Recovery != nil {
+		// The saved history belongs to the damaged file; saving soon puts the
+		// recovered workspace in its place
+		c.notifyDirty()
+	} else if history, err := c.store.LoadHistory(date); err != nil {
+		log.Println("Failed to load history:", err)
+	} else {
+		c.restoreHistory(history)
+	}
 	c.Refresh()
+
+	if state.Recovery != nil {
+		c.reportRecovery(date, *state.Recovery)
+	}
 	return nil
 }
 
+// reportRecovery passes on how the damaged workspace of date was recovered
+func (c *MosugoCanvas) reportRecovery(date time.Time, recovery storage.Recovery) {
+	if c.onRecovered != nil {
+		c.onRecovered(date, recovery)
+	} else {
+		log.Println("Workspace file damaged, recovered what was left:", recovery.Err)
+	}
+}
+
 // ClearCanvas removes all cards and strokes from the canvas.
 func (c *MosugoCanvas) ClearCanvas() {
 	objectsToRemove := []fyne.CanvasObject{}
@@ -657,13 +725,12 @@ func (c *MosugoCanvas) ClearCanvas() {
 		c.Content.Remove(obj)
 	}
 
-	// Clear stroke maps
-	c.strokesMap = make(map[*canvas.Line]StrokeCoords)
-	c.strokeIDMap = make(map[*canvas.Line]int)
-	c.glowLines = make(map[*canvas.Line]bool)
-	c.strokes = [][]*canvas.Line{}
-	c.currentStroke = []*canvas.Line{}
+	// Clear stroke tracking
+	c.strokeByID = make(map[int]*strokes.Stroke)
+	c.index = newSpatialIndex()
+	c.onScreen = make(map[fyne.CanvasObject]bool)
+	c.cullPending = make(map[fyne.CanvasObject]bool)
 	c.nextStrokeID = 1
 
-	c.selectedCard = nil
+	c.selected = nil
 }
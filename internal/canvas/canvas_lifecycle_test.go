@@ -2,15 +2,20 @@ package canvas
 
 import (
 	"testing"
+	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/canvas"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/F4tal1t/Mosugo/internal/storage"
+	"github.com/F4tal1t/Mosugo/internal/strokes"
+	"github.com/F4tal1t/Mosugo/internal/testutil"
 )
 
-// TestAddStrokeCreatesGlowAndRegularPair tests that AddStroke creates both lines
-func TestAddStrokeCreatesGlowAndRegularPair(t *testing.T) {
+// TestAddStrokeCreatesSingleStroke tests that AddStroke creates one stroke widget
+func TestAddStrokeCreatesSingleStroke(t *testing.T) {
 	c := NewMosugoCanvas()
 
 	initialObjects := len(c.Content.Objects)
@@ -21,37 +26,16 @@ func TestAddStrokeCreatesGlowAndRegularPair(t *testing.T) {
 
 	c.AddStroke(p1, p2, strokeID)
 
-	// Should add 2 lines: glow and regular
-	assert.Equal(t, initialObjects+2, len(c.Content.Objects), "Should add 2 lines (glow + regular)")
-
-	// Find the two new lines
-	var glowLine, regularLine *canvas.Line
-	for i := initialObjects; i < len(c.Content.Objects); i++ {
-		if line, ok := c.Content.Objects[i].(*canvas.Line); ok {
-			if c.IsGlowLine(line) {
-				glowLine = line
-			} else {
-				regularLine = line
-			}
-		}
-	}
-
-	require.NotNil(t, glowLine, "Should find glow line")
-	require.NotNil(t, regularLine, "Should find regular line")
+	// Should add a single object drawing both ink and halo
+	assert.Equal(t, initialObjects+1, len(c.Content.Objects), "Should add one stroke widget")
 
-	// Verify glow line has wider stroke
-	assert.Greater(t, glowLine.StrokeWidth, regularLine.StrokeWidth, "Glow line should be wider")
-	assert.Equal(t, c.StrokeWidth*1.5, glowLine.StrokeWidth)
-	assert.Equal(t, c.StrokeWidth, regularLine.StrokeWidth)
-
-	// Both should have same stroke ID
-	glowID, ok := c.GetStrokeID(glowLine)
-	require.True(t, ok)
-	assert.Equal(t, strokeID, glowID)
-
-	regularID, ok := c.GetStrokeID(regularLine)
-	require.True(t, ok)
-	assert.Equal(t, strokeID, regularID)
+	stroke, ok := c.Content.Objects[initialObjects].(*strokes.Stroke)
+	require.True(t, ok, "Should add a stroke widget")
+	assert.Equal(t, strokeID, stroke.ID)
+	assert.Equal(t, []fyne.Position{p1, p2}, stroke.Points)
+	assert.Equal(t, c.StrokeWidth, stroke.Width)
+	assert.Greater(t, stroke.HaloWidth(), stroke.Width, "Halo should be wider than the ink")
+	assert.Same(t, stroke, c.StrokeByID(strokeID))
 }
 
 // TestAddStrokeWithInvalidIDGeneratesNew tests defensive ID generation
@@ -65,58 +49,29 @@ func TestAddStrokeWithInvalidIDGeneratesNew(t *testing.T) {
 	c.AddStroke(p1, p2, 0)
 
 	// Should have generated a valid ID instead
-	foundValidID := false
-	for line := range c.strokeIDMap {
-		id, ok := c.GetStrokeID(line)
-		if ok && c.ValidateStrokeID(id) {
-			foundValidID = true
-			break
-		}
+	require.Len(t, c.strokeByID, 1)
+	for id, stroke := range c.strokeByID {
+		assert.True(t, c.ValidateStrokeID(id), "Should have generated valid ID for invalid input")
+		assert.Equal(t, id, stroke.ID)
 	}
-
-	assert.True(t, foundValidID, "Should have generated valid ID for invalid input")
 }
 
-// TestRemoveObjectCleansUpMaps tests that RemoveObject cleans stroke tracking
-func TestRemoveObjectCleansUpMaps(t *testing.T) {
+// TestRemoveObjectCleansUpStroke tests that RemoveObject cleans stroke tracking
+func TestRemoveObjectCleansUpStroke(t *testing.T) {
 	c := NewMosugoCanvas()
 
-	line := canvas.NewLine(nil)
-	p1 := fyne.NewPos(10, 20)
-	p2 := fyne.NewPos(100, 200)
-	strokeID := 1
-
-	// Register and add line
-	c.RegisterStroke(line, p1, p2, strokeID)
-	c.glowLines[line] = true
-	c.Content.Add(line)
-
-	// Verify it's tracked
-	_, ok := c.GetStrokeID(line)
-	require.True(t, ok)
-	assert.True(t, c.IsGlowLine(line))
+	strokeID := c.GenerateStrokeID()
+	c.AddStroke(fyne.NewPos(10, 20), fyne.NewPos(100, 200), strokeID)
+	stroke := c.StrokeByID(strokeID)
+	require.NotNil(t, stroke)
 
 	// Remove it
-	c.RemoveObject(line)
+	c.RemoveObject(stroke)
 
 	// Verify cleanup
-	_, ok = c.GetStrokeID(line)
-	assert.False(t, ok, "Stroke ID should be removed from map")
-
-	_, _, ok = c.GetStrokePoints(line)
-	assert.False(t, ok, "Stroke coords should be removed from map")
-
-	assert.False(t, c.IsGlowLine(line), "Glow line marker should be removed")
-
-	// Verify not in container
-	found := false
-	for _, obj := range c.Content.Objects {
-		if obj == line {
-			found = true
-			break
-		}
-	}
-	assert.False(t, found, "Line should be removed from container")
+	assert.Nil(t, c.StrokeByID(strokeID), "Stroke should be removed from the ID map")
+	assert.Zero(t, c.objectIndex().Len(), "Stroke should be removed from the spatial index")
+	assert.NotContains(t, c.Content.Objects, stroke, "Stroke should be removed from container")
 }
 
 // TestClearCanvasCompleteReset tests comprehensive canvas reset
@@ -133,9 +88,7 @@ func TestClearCanvasCompleteReset(t *testing.T) {
 
 	// Verify strokes were added
 	require.Greater(t, len(c.Content.Objects), 1, "Should have objects")
-	require.Greater(t, len(c.strokesMap), 0, "Should have stroke coords")
-	require.Greater(t, len(c.strokeIDMap), 0, "Should have stroke IDs")
-	require.Greater(t, len(c.glowLines), 0, "Should have glow lines")
+	require.Len(t, c.strokeByID, 5, "Should track every stroke")
 
 	// Store values before clear
 	offsetBefore := c.Offset
@@ -145,17 +98,14 @@ func TestClearCanvasCompleteReset(t *testing.T) {
 	c.ClearCanvas()
 
 	// Verify maps are cleared
-	assert.Empty(t, c.strokesMap, "Stroke coords map should be empty")
-	assert.Empty(t, c.strokeIDMap, "Stroke ID map should be empty")
-	assert.Empty(t, c.glowLines, "Glow lines map should be empty")
-	assert.Empty(t, c.strokes, "Strokes array should be empty")
-	assert.Empty(t, c.currentStroke, "Current stroke should be empty")
+	assert.Empty(t, c.strokeByID, "Stroke map should be empty")
+	assert.Zero(t, c.objectIndex().Len(), "Spatial index should be empty")
 
 	// Verify nextStrokeID is reset
 	assert.Equal(t, 1, c.nextStrokeID, "Next stroke ID should reset to 1")
 
 	// Verify selected card is cleared
-	assert.Nil(t, c.selectedCard, "Selected card should be nil")
+	assert.Empty(t, c.Selection(), "Selection should be empty")
 
 	// Verify offset and scale are unchanged
 	assert.Equal(t, offsetBefore, c.Offset, "Offset should be preserved")
@@ -225,15 +175,14 @@ func TestStrokeIDMigrationOnLoad(t *testing.T) {
 
 	// All strokes should now have valid IDs
 	validCount := 0
-	for line := range c.strokeIDMap {
-		id, ok := c.GetStrokeID(line)
-		if ok && c.ValidateStrokeID(id) {
+	for id := range c.strokeByID {
+		if c.ValidateStrokeID(id) {
 			validCount++
 		}
 	}
 
-	// Should have 4 lines (2 glow + 2 regular), all with valid IDs
-	assert.Equal(t, 4, validCount, "All strokes should have valid IDs")
+	// Each invalid segment becomes its own stroke
+	assert.Equal(t, 2, validCount, "All strokes should have valid IDs")
 }
 
 // TestAddMultipleStrokesSameID tests grouping strokes with same ID
@@ -256,17 +205,56 @@ func TestAddMultipleStrokesSameID(t *testing.T) {
 		c.AddStroke(s.p1, s.p2, sharedStrokeID)
 	}
 
-	// Count lines with this stroke ID
-	count := 0
-	for line := range c.strokeIDMap {
-		id, ok := c.GetStrokeID(line)
-		if ok && id == sharedStrokeID {
-			count++
+	// All segments extend the same polyline
+	require.Len(t, c.strokeByID, 1)
+	stroke := c.StrokeByID(sharedStrokeID)
+	require.NotNil(t, stroke)
+	assert.Equal(t, []fyne.Position{
+		fyne.NewPos(0, 0), fyne.NewPos(10, 10), fyne.NewPos(20, 15), fyne.NewPos(30, 10),
+	}, stroke.Points)
+	assert.Len(t, stroke.Segments(), 3)
+}
+
+// TestAddStrokeSegmentsChainsUnorderedSegments tests loading segments saved out of order
+func TestAddStrokeSegmentsChainsUnorderedSegments(t *testing.T) {
+	c := NewMosugoCanvas()
+
+	// Older files saved segments in map order
+	c.addStrokeSegments([]storage.StrokeData{
+		{P1X: 20, P1Y: 15, P2X: 30, P2Y: 10, Width: 4, StrokeID: 7},
+		{P1X: 0, P1Y: 0, P2X: 10, P2Y: 10, Width: 4, StrokeID: 7},
+		{P1X: 10, P1Y: 10, P2X: 20, P2Y: 15, Width: 4, StrokeID: 7},
+		// A piece that doesn't connect to the rest of stroke 7
+		{P1X: 500, P1Y: 500, P2X: 510, P2Y: 500, Width: 4, StrokeID: 7},
+	})
+
+	stroke := c.StrokeByID(7)
+	require.NotNil(t, stroke)
+	assert.Equal(t, []fyne.Position{
+		fyne.NewPos(0, 0), fyne.NewPos(10, 10), fyne.NewPos(20, 15), fyne.NewPos(30, 10),
+	}, stroke.Points)
+	testutil.Float32Equal(t, 4, stroke.Width)
+
+	require.Len(t, c.strokeByID, 2, "Disconnected piece becomes its own stroke")
+	for id, other := range c.strokeByID {
+		if id != 7 {
+			assert.Equal(t, []fyne.Position{fyne.NewPos(500, 500), fyne.NewPos(510, 500)}, other.Points)
 		}
 	}
+}
 
-	// Should have 6 lines: 3 segments × 2 (glow + regular)
-	assert.Equal(t, 6, count, "Should have 6 lines for 3 segments (glow + regular each)")
+// TestChainSegmentsClosedLoop tests that a loop without a free end is still chained
+func TestChainSegmentsClosedLoop(t *testing.T) {
+	runs := chainSegments([]storage.StrokeData{
+		{P1X: 10, P1Y: 0, P2X: 10, P2Y: 10},
+		{P1X: 0, P1Y: 0, P2X: 10, P2Y: 0},
+		{P1X: 10, P1Y: 10, P2X: 0, P2Y: 0},
+	})
+
+	require.Len(t, runs, 1)
+	assert.Equal(t, []fyne.Position{
+		fyne.NewPos(10, 0), fyne.NewPos(10, 10), fyne.NewPos(0, 0), fyne.NewPos(10, 0),
+	}, runs[0])
 }
 
 // TestContentContainerGetter tests ContentContainer method
@@ -314,3 +302,36 @@ func TestRemoveNonLineObject(t *testing.T) {
 
 	assert.NotContains(t, c.Content.Objects, rect)
 }
+
+// TestSaveLoadKeepsStrokeStyle tests that stroke colors and widths survive a save and reload
+func TestSaveLoadKeepsStrokeStyle(t *testing.T) {
+	store := storage.NewMemoryStore()
+	date := time.Date(2099, 2, 1, 0, 0, 0, 0, time.UTC)
+	c := NewMosugoCanvas()
+	c.SetStore(store)
+	c.SetCurrentDate(date)
+
+	c.StrokeColorIdx, c.StrokeWidth = 1, 5
+	thick := c.GenerateStrokeID()
+	c.AddStroke(fyne.NewPos(0, 0), fyne.NewPos(50, 0), thick)
+	c.StrokeColorIdx, c.StrokeWidth = 5, 1.5
+	thin := c.GenerateStrokeID()
+	c.AddStroke(fyne.NewPos(0, 40), fyne.NewPos(50, 40), thin)
+	require.NoError(t, c.SaveCurrentWorkspace())
+
+	// Rebuild from what was saved, as LoadWorkspace does
+	state, err := store.LoadWorkspace(date)
+	require.NoError(t, err)
+	loaded := NewMosugoCanvas()
+	loaded.addStrokeSegments(state.Strokes)
+
+	for id, want := range map[int]struct {
+		colorIdx int
+		width    float32
+	}{thick: {1, 5}, thin: {5, 1.5}} {
+		stroke := loaded.StrokeByID(id)
+		require.NotNil(t, stroke, "Stroke %d", id)
+		assert.Equal(t, want.colorIdx, stroke.ColorIndex)
+		assert.Equal(t, want.width, stroke.Width)
+	}
+}
@@ -3,10 +3,11 @@ package canvas
 import (
 	"testing"
 
-	"github.com/F4tal1t/Mosugo//internal/testutil"
+	"github.com/F4tal1t/Mosugo/internal/storage"
+	"github.com/F4tal1t/Mosugo/internal/testutil"
+	"github.com/F4tal1t/Mosugo/internal/theme"
 
 	"fyne.io/fyne/v2"
-	"fyne.io/fyne/v2/canvas"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -162,100 +163,41 @@ func TestValidateStrokeID(t *testing.T) {
 	}
 }
 
-// TestRegisterStrokeAndRetrieve tests stroke registration and retrieval
-func TestRegisterStrokeAndRetrieve(t *testing.T) {
+// TestStrokeByIDNotFound tests lookup of an unknown stroke
+func TestStrokeByIDNotFound(t *testing.T) {
 	c := NewMosugoCanvas()
 
-	line := canvas.NewLine(nil)
-	p1 := fyne.NewPos(10, 20)
-	p2 := fyne.NewPos(100, 200)
-	strokeID := 42
-
-	// Register stroke
-	c.RegisterStroke(line, p1, p2, strokeID)
-
-	// Retrieve stroke ID
-	retrievedID, ok := c.GetStrokeID(line)
-	require.True(t, ok, "Should find registered stroke")
-	assert.Equal(t, strokeID, retrievedID)
-
-	// Retrieve stroke points
-	rp1, rp2, ok := c.GetStrokePoints(line)
-	require.True(t, ok, "Should find registered stroke points")
-	testutil.PositionEqual(t, p1, rp1)
-	testutil.PositionEqual(t, p2, rp2)
-}
-
-// TestGetStrokeIDNotFound tests retrieval of unregistered stroke
-func TestGetStrokeIDNotFound(t *testing.T) {
-	c := NewMosugoCanvas()
-
-	line := canvas.NewLine(nil)
-	id, ok := c.GetStrokeID(line)
-
-	assert.False(t, ok, "Should not find unregistered stroke")
-	assert.Equal(t, 0, id, "Should return zero for not found")
+	assert.Nil(t, c.StrokeByID(42), "Should not find unknown stroke")
+	assert.Empty(t, c.CollectStrokeDataByID(42))
 }
 
-// TestGetStrokePointsNotFound tests retrieval of unregistered stroke points
-func TestGetStrokePointsNotFound(t *testing.T) {
+// TestCollectStrokeDataRoundTrip tests that collected segments rebuild the same stroke
+func TestCollectStrokeDataRoundTrip(t *testing.T) {
 	c := NewMosugoCanvas()
 
-	line := canvas.NewLine(nil)
-	p1, p2, ok := c.GetStrokePoints(line)
-
-	assert.False(t, ok, "Should not find unregistered stroke")
-	testutil.PositionEqual(t, fyne.Position{}, p1)
-	testutil.PositionEqual(t, fyne.Position{}, p2)
-}
-
-// TestRegisterMultipleStrokes tests registering multiple strokes
-func TestRegisterMultipleStrokes(t *testing.T) {
-	c := NewMosugoCanvas()
-
-	strokes := []struct {
-		line     *canvas.Line
-		p1       fyne.Position
-		p2       fyne.Position
-		strokeID int
-	}{
-		{canvas.NewLine(nil), fyne.NewPos(0, 0), fyne.NewPos(10, 10), 1},
-		{canvas.NewLine(nil), fyne.NewPos(20, 20), fyne.NewPos(30, 30), 2},
-		{canvas.NewLine(nil), fyne.NewPos(40, 40), fyne.NewPos(50, 50), 3},
+	strokeID := c.GenerateStrokeID()
+	points := []fyne.Position{
+		fyne.NewPos(0, 0), fyne.NewPos(10, 10), fyne.NewPos(20, 5), fyne.NewPos(40, 30),
 	}
-
-	// Register all strokes
-	for _, s := range strokes {
-		c.RegisterStroke(s.line, s.p1, s.p2, s.strokeID)
+	for i := 1; i < len(points); i++ {
+		c.AddStroke(points[i-1], points[i], strokeID)
 	}
 
-	// Verify all can be retrieved
-	for i, s := range strokes {
-		t.Run("Stroke_"+string(rune(i+'0')), func(t *testing.T) {
-			id, ok := c.GetStrokeID(s.line)
-			require.True(t, ok)
-			assert.Equal(t, s.strokeID, id)
-
-			p1, p2, ok := c.GetStrokePoints(s.line)
-			require.True(t, ok)
-			testutil.PositionEqual(t, s.p1, p1)
-			testutil.PositionEqual(t, s.p2, p2)
-		})
+	segments := c.CollectStrokeDataByID(strokeID)
+	require.Len(t, segments, 3, "One segment per polyline edge")
+	for i, segment := range segments {
+		assert.Equal(t, strokeID, segment.StrokeID)
+		testutil.PositionEqual(t, points[i], fyne.NewPos(segment.P1X, segment.P1Y))
+		testutil.PositionEqual(t, points[i+1], fyne.NewPos(segment.P2X, segment.P2Y))
 	}
-}
-
-// TestIsGlowLine tests glow line detection
-func TestIsGlowLine(t *testing.T) {
-	c := NewMosugoCanvas()
-
-	glowLine := canvas.NewLine(nil)
-	regularLine := canvas.NewLine(nil)
 
-	// Mark one as glow
-	c.glowLines[glowLine] = true
+	c.removeStrokeByID(strokeID)
+	require.Nil(t, c.StrokeByID(strokeID))
 
-	assert.True(t, c.IsGlowLine(glowLine), "Should detect glow line")
-	assert.False(t, c.IsGlowLine(regularLine), "Should not detect regular line as glow")
+	c.addStrokeSegments(segments)
+	stroke := c.StrokeByID(strokeID)
+	require.NotNil(t, stroke)
+	assert.Equal(t, points, stroke.Points)
 }
 
 // TestPerpendicularDistance tests the internal distance calculation
@@ -327,3 +269,52 @@ func TestDistanceFunction(t *testing.T) {
 		})
 	}
 }
+
+// TestStrokesKeepTheirOwnStyle tests that each stroke records the pen it was drawn with
+func TestStrokesKeepTheirOwnStyle(t *testing.T) {
+	c := NewMosugoCanvas()
+
+	c.StrokeColorIdx, c.StrokeWidth = 2, 5
+	red := c.GenerateStrokeID()
+	c.AddStroke(fyne.NewPos(0, 0), fyne.NewPos(10, 0), red)
+
+	c.StrokeColorIdx, c.StrokeWidth = 3, 1.5
+	blue := c.GenerateStrokeID()
+	c.AddStroke(fyne.NewPos(0, 50), fyne.NewPos(10, 50), blue)
+	// Changing the pen mid-stroke doesn't restyle it
+	c.AddStroke(fyne.NewPos(10, 0), fyne.NewPos(20, 0), red)
+
+	stroke := c.StrokeByID(red)
+	require.NotNil(t, stroke)
+	assert.Equal(t, 2, stroke.ColorIndex)
+	assert.Equal(t, theme.StrokePalette[2], stroke.Color)
+	assert.Equal(t, float32(5), stroke.Width)
+
+	for _, segment := range c.CollectStrokeDataByID(blue) {
+		assert.Equal(t, 3, segment.ColorIdx)
+		assert.Equal(t, float32(1.5), segment.Width)
+	}
+}
+
+// TestAddStrokeSegmentsRestoresStyle tests loading saved colors and widths
+func TestAddStrokeSegmentsRestoresStyle(t *testing.T) {
+	c := NewMosugoCanvas()
+	c.addStrokeSegments([]storage.StrokeData{
+		{P1X: 0, P1Y: 0, P2X: 10, P2Y: 10, ColorIdx: 4, Width: 5, StrokeID: 1},
+		{P1X: 0, P1Y: 0, P2X: 10, P2Y: 10, ColorIdx: 99, Width: 0, StrokeID: 2},
+	})
+
+	styled := c.StrokeByID(1)
+	require.NotNil(t, styled)
+	assert.Equal(t, 4, styled.ColorIndex)
+	assert.Equal(t, theme.StrokePalette[4], styled.Color)
+	assert.Equal(t, float32(5), styled.Width)
+
+	// Unknown colors fall back to the default ink but keep their index,
+	// missing widths use the current pen
+	unknown := c.StrokeByID(2)
+	require.NotNil(t, unknown)
+	assert.Equal(t, 99, unknown.ColorIndex)
+	assert.Equal(t, theme.StrokePalette[0], unknown.Color)
+	assert.Equal(t, c.StrokeWidth, unknown.Width)
+}
@@ -3,10 +3,13 @@ package canvas
 import (
 	"testing"
 
+	"github.com/F4tal1t/Mosugo/internal/cards"
 	"github.com/F4tal1t/Mosugo/internal/testutil"
+	"github.com/F4tal1t/Mosugo/internal/theme"
 
 	"fyne.io/fyne/v2"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // TestScreenToWorldBasic tests basic coordinate transformations at 1:1 scale with zero offset
@@ -276,13 +279,181 @@ func TestNewMosugoCanvasDefaults(t *testing.T) {
 	testutil.Float32Equal(t, 1.0, c.Scale)
 	testutil.PositionEqual(t, fyne.NewPos(0, 0), c.Offset)
 	assert.NotNil(t, c.Content)
-	assert.NotNil(t, c.strokesMap)
-	assert.NotNil(t, c.strokeIDMap)
-	assert.NotNil(t, c.glowLines)
+	assert.NotNil(t, c.strokeByID)
 	assert.Equal(t, 1, c.nextStrokeID)
 	assert.False(t, c.isDirty)
 }
 
+// TestZoomAtKeepsCursorAnchored tests that zooming keeps the world point under the cursor fixed
+func TestZoomAtKeepsCursorAnchored(t *testing.T) {
+	for _, scale := range []float32{0.5, 2.0, 4.0} {
+		c := NewMosugoCanvas()
+		c.Offset = fyne.NewPos(40, -25)
+		cursor := fyne.NewPos(320, 180)
+		anchor := c.ScreenToWorld(cursor)
+
+		c.ZoomAt(cursor, scale)
+
+		testutil.Float32Equal(t, scale, c.Scale)
+		testutil.PositionEqual(t, anchor, c.ScreenToWorld(cursor))
+	}
+}
+
+// TestZoomAtClampsScale tests that zoom stays within MinScale and MaxScale
+func TestZoomAtClampsScale(t *testing.T) {
+	c := NewMosugoCanvas()
+
+	c.ZoomAt(fyne.NewPos(100, 100), 100)
+	testutil.Float32Equal(t, MaxScale, c.Scale)
+
+	c.ZoomAt(fyne.NewPos(100, 100), 0.001)
+	testutil.Float32Equal(t, MinScale, c.Scale)
+}
+
+// TestScrolledZoomsAndMarksDirty tests wheel zoom direction and viewport persistence
+func TestScrolledZoomsAndMarksDirty(t *testing.T) {
+	c := NewMosugoCanvas()
+	dirtyCalls := 0
+	c.SetOnDirty(func() { dirtyCalls++ })
+
+	cursor := fyne.NewPos(200, 150)
+	anchor := c.ScreenToWorld(cursor)
+
+	c.Scrolled(&fyne.ScrollEvent{
+		PointEvent: fyne.PointEvent{Position: cursor},
+		Scrolled:   fyne.NewDelta(0, 10),
+	})
+
+	assert.Greater(t, c.Scale, float32(1.0), "Scrolling up should zoom in")
+	testutil.PositionEqual(t, anchor, c.ScreenToWorld(cursor))
+	assert.True(t, c.isDirty, "Zoom should mark the viewport dirty")
+	assert.Equal(t, 1, dirtyCalls)
+
+	c.Scrolled(&fyne.ScrollEvent{
+		PointEvent: fyne.PointEvent{Position: cursor},
+		Scrolled:   fyne.NewDelta(0, -20),
+	})
+	assert.Less(t, c.Scale, float32(1.0), "Scrolling down should zoom out")
+
+	// Horizontal-only scroll does not zoom
+	scale := c.Scale
+	c.Scrolled(&fyne.ScrollEvent{
+		PointEvent: fyne.PointEvent{Position: cursor},
+		Scrolled:   fyne.NewDelta(15, 0),
+	})
+	assert.Equal(t, scale, c.Scale)
+}
+
+// TestContentBoundsCoversCardsAndStrokes tests world bounds used by camera commands
+func TestContentBoundsCoversCardsAndStrokes(t *testing.T) {
+	c := NewMosugoCanvas()
+
+	_, ok := c.contentBounds()
+	assert.False(t, ok, "Empty canvas has no bounds")
+	assert.False(t, c.ZoomToFit(), "Nothing to frame on an empty canvas")
+
+	card := cards.NewMosuWidget("card-1", theme.CardBg, 0)
+	card.WorldPos = fyne.NewPos(60, 90)
+	card.WorldSize = fyne.NewSize(120, 60)
+	c.AddObject(card)
+	c.AddStroke(fyne.NewPos(-40, 300), fyne.NewPos(10, 250), c.GenerateStrokeID())
+
+	bounds, ok := c.contentBounds()
+	require.True(t, ok)
+	// Strokes count with their halo
+	halo := c.StrokeWidth
+	testutil.PositionEqual(t, fyne.NewPos(-40-halo, 90), bounds.Min)
+	testutil.PositionEqual(t, fyne.NewPos(180, 300+halo), bounds.Max)
+}
+
+// TestFitViewCentersBounds tests that fitted content is centered and fully visible
+func TestFitViewCentersBounds(t *testing.T) {
+	viewport := fyne.NewSize(800, 600)
+	bounds := worldRect{Min: fyne.NewPos(100, 100), Max: fyne.NewPos(1100, 400)}
+
+	offset, scale := fitView(bounds, viewport, 50)
+
+	// Width is the limiting side: (800 - 2*50) / 1000
+	testutil.Float32Equal(t, 0.7, scale)
+
+	c := NewMosugoCanvas()
+	c.Offset = offset
+	c.Scale = scale
+	testutil.PositionEqual(t, fyne.NewPos(400, 300), c.WorldToScreen(bounds.center()))
+
+	topLeft := c.WorldToScreen(bounds.Min)
+	bottomRight := c.WorldToScreen(bounds.Max)
+	assert.GreaterOrEqual(t, topLeft.X, float32(50)-testutil.PositionTolerance)
+	assert.LessOrEqual(t, bottomRight.X, float32(750)+testutil.PositionTolerance)
+	assert.GreaterOrEqual(t, topLeft.Y, float32(50))
+	assert.LessOrEqual(t, bottomRight.Y, float32(550))
+}
+
+// TestFitViewLimitsZoomIn tests that tiny content isn't blown up past maxFitScale
+func TestFitViewLimitsZoomIn(t *testing.T) {
+	point := worldRect{Min: fyne.NewPos(10, 10), Max: fyne.NewPos(10, 10)}
+	_, scale := fitView(point, fyne.NewSize(800, 600), 50)
+	testutil.Float32Equal(t, maxFitScale, scale)
+
+	huge := worldRect{Min: fyne.NewPos(0, 0), Max: fyne.NewPos(1e7, 1e7)}
+	_, scale = fitView(huge, fyne.NewSize(800, 600), 50)
+	testutil.Float32Equal(t, MinScale, scale)
+}
+
+// TestResetViewAndZoomToSelection tests camera commands without a running UI
+func TestResetViewAndZoomToSelection(t *testing.T) {
+	c := NewMosugoCanvas()
+	c.Offset = fyne.NewPos(-500, 320)
+	c.Scale = 3.0
+
+	assert.False(t, c.ZoomToSelection(), "Nothing selected")
+
+	c.ResetView()
+	testutil.Float32Equal(t, 1.0, c.Scale)
+	testutil.PositionEqual(t, fyne.NewPos(0, 0), c.Offset)
+	assert.True(t, c.isDirty, "View change should be persisted")
+}
+
+// TestCullContentHidesOffscreenObjects tests viewport culling while panning
+func TestCullContentHidesOffscreenObjects(t *testing.T) {
+	c := NewMosugoCanvas()
+	viewport := fyne.NewSize(800, 600)
+
+	c.AddStroke(fyne.NewPos(100, 100), fyne.NewPos(200, 100), c.GenerateStrokeID())
+	c.AddStroke(fyne.NewPos(5000, 100), fyne.NewPos(5100, 100), c.GenerateStrokeID())
+	near := c.StrokeByID(1)
+	far := c.StrokeByID(2)
+
+	visible := c.cullContent(viewport)
+	assert.Equal(t, []fyne.CanvasObject{near}, visible, "Only the on-screen stroke is laid out")
+	assert.True(t, near.Visible())
+	assert.False(t, far.Visible(), "New off-screen objects are hidden")
+
+	// Pan so only the far stroke is in view
+	c.Offset = fyne.NewPos(-4800, 0)
+	c.cullContent(viewport)
+	assert.False(t, near.Visible())
+	assert.True(t, far.Visible())
+
+	// Objects just past the edge stay within the margin
+	c.Offset = fyne.NewPos(-4800-cullMargin/2, 0)
+	c.cullContent(viewport)
+	assert.True(t, far.Visible())
+
+	// A card moved out of view is hidden on the next pass
+	card := cards.NewMosuWidget("card-1", theme.CardBg, 0)
+	card.WorldPos = fyne.NewPos(5000, 300)
+	card.WorldSize = fyne.NewSize(90, 60)
+	c.AddObject(card)
+	c.Offset = fyne.NewPos(-4800, 0)
+	assert.Contains(t, c.cullContent(viewport), card)
+
+	card.WorldPos = fyne.NewPos(-3000, 300)
+	c.UpdateCardBounds(card)
+	assert.NotContains(t, c.cullContent(viewport), card)
+	assert.False(t, card.Visible())
+}
+
 // Helper function to format float for test names
 func formatFloat(f float32) string {
 	if f == float32(int(f)) {
@@ -0,0 +1,131 @@
+package canvas
+
+import (
+	"log"
+	"time"
+
+	"fyne.io/fyne/v2"
+
+	"github.com/F4tal1t/Mosugo/internal/cards"
+	"github.com/F4tal1t/Mosugo/internal/storage"
+	"github.com/F4tal1t/Mosugo/internal/strokes"
+)
+
+// CopySelection puts the selected cards and strokes on the clipboard as a
+// storage.Clip. It returns false when nothing is selected.
+func (c *MosugoCanvas) CopySelection(clipboard fyne.Clipboard) bool {
+	cardData, strokeData := c.selectionData()
+	if len(cardData) == 0 && len(strokeData) == 0 {
+		return false
+	}
+	text, err := storage.EncodeClip(cardData, strokeData)
+	if err != nil {
+		log.Println("Copy failed:", err)
+		return false
+	}
+	clipboard.SetContent(text)
+	return true
+}
+
+// PasteClipboard adds the cards and strokes of a clip on the clipboard as one
+// step, with fresh IDs, their top-left corner on the grid cell under the
+// pointer (or at the middle of the view when the pointer is elsewhere), and
+// selects them. It returns false when the clipboard holds no clip.
+func (c *MosugoCanvas) PasteClipboard(clipboard fyne.Clipboard) bool {
+	clip, err := storage.DecodeClip(clipboard.Content())
+	if err != nil || (len(clip.Cards) == 0 && len(clip.Strokes) == 0) {
+		return false
+	}
+
+	target := c.pasteTarget()
+	origin := clipOrigin(clip)
+	offset := fyne.NewPos(snap(target.X)-snap(origin.X), snap(target.Y)-snap(origin.Y))
+	c.insertCopies(clip.Cards, clip.Strokes, offset)
+	return true
+}
+
+// pasteTarget is the world position pastes land at.
+func (c *MosugoCanvas) pasteTarget() fyne.Position {
+	if c.pointerIn {
+		return c.ScreenToWorld(c.pointer)
+	}
+	size := c.Size()
+	return c.ScreenToWorld(fyne.NewPos(size.Width/2, size.Height/2))
+}
+
+// clipOrigin returns the top-left corner of everything in a clip.
+func clipOrigin(clip storage.Clip) fyne.Position {
+	var bounds worldRect
+	found := false
+	add := func(r worldRect) {
+		if !found {
+			bounds, found = r, true
+		} else {
+			bounds = bounds.union(r)
+		}
+	}
+	for _, card := range clip.Cards {
+		add(rectFromPosSize(fyne.NewPos(card.PosX, card.PosY), fyne.NewSize(card.Width, card.Height)))
+	}
+	for _, segment := range clip.Strokes {
+		add(rectFromPoints(fyne.NewPos(segment.P1X, segment.P1Y), fyne.NewPos(segment.P2X, segment.P2Y)))
+	}
+	return bounds.Min
+}
+
+// selectionData serializes the selected cards and strokes.
+func (c *MosugoCanvas) selectionData() ([]storage.MosuData, []storage.StrokeData) {
+	cardData := []storage.MosuData{}
+	strokeData := []storage.StrokeData{}
+	for _, obj := range c.Selection() {
+		switch o := obj.(type) {
+		case *cards.MosuWidget:
+			cardData = append(cardData, c.CollectCardData(o))
+		case *strokes.Stroke:
+			strokeData = append(strokeData, c.CollectStrokeDataByID(o.ID)...)
+		}
+	}
+	return cardData, strokeData
+}
+
+// insertCopies adds copies of cards and strokes moved by offset as one step,
+// and selects them. Copies get fresh IDs, so they never clash with the
+// objects they were copied from, wherever those are.
+func (c *MosugoCanvas) insertCopies(cardData []storage.MosuData, strokeData []storage.StrokeData, offset fyne.Position) {
+	copies := []fyne.CanvasObject{}
+	cmds := []historyCommand{}
+
+	for _, data := range cardData {
+		data.ID = c.GenerateCardID()
+		data.PosX += offset.X
+		data.PosY += offset.Y
+		data.CreatedAt = time.Now()
+		copies = append(copies, c.addCardFromData(data))
+		cmds = append(cmds, cardCreateCommand{data: data})
+	}
+
+	// Keep segments of one stroke together under one new ID
+	order, groups := groupStrokeSegments(strokeData)
+	for _, oldID := range order {
+		strokeID := c.GenerateStrokeID()
+		segments := make([]storage.StrokeData, 0, len(groups[oldID]))
+		for _, segment := range groups[oldID] {
+			segment.StrokeID = strokeID
+			segment.P1X += offset.X
+			segment.P1Y += offset.Y
+			segment.P2X += offset.X
+			segment.P2Y += offset.Y
+			segments = append(segments, segment)
+		}
+		segments = c.separateStrokePieces(segments)
+		c.addStrokeSegments(segments)
+		pieces, _ := groupStrokeSegments(segments)
+		for _, id := range pieces {
+			copies = append(copies, c.StrokeByID(id))
+		}
+		cmds = append(cmds, strokeCreateCommand{segments: segments})
+	}
+
+	c.SetSelection(copies...)
+	c.commitCompound(cmds)
+}
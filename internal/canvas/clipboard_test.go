@@ -0,0 +1,95 @@
+package canvas
+
+import (
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/driver/desktop"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/F4tal1t/Mosugo/internal/cards"
+	"github.com/F4tal1t/Mosugo/internal/storage"
+	"github.com/F4tal1t/Mosugo/internal/strokes"
+	"github.com/F4tal1t/Mosugo/internal/testutil"
+)
+
+func TestCopyPasteAcrossWorkspaces(t *testing.T) {
+	src, a, _, stroke := newSelectionFixture(t)
+	a.SetText("notes")
+	clip := &testutil.MemoryClipboard{}
+
+	assert.False(t, src.CopySelection(clip), "Nothing selected")
+	src.SetSelection(a, stroke)
+	require.True(t, src.CopySelection(clip))
+
+	// Another day's workspace, with the pointer over world (95, 400)
+	dst, _, _, _ := newSelectionFixture(t)
+	dst.MouseMoved(&desktop.MouseEvent{PointEvent: fyne.PointEvent{Position: fyne.NewPos(95, 400)}})
+	require.True(t, dst.PasteClipboard(clip))
+	require.Len(t, dst.undoStack, 1, "A paste is one step")
+
+	pasted := dst.Selection()
+	require.Len(t, pasted, 2)
+	card := pasted[0].(*cards.MosuWidget)
+	copied := pasted[1].(*strokes.Stroke)
+
+	// The top-left of the copy, (0, 30), lands on the grid cell under the pointer
+	assert.Equal(t, "notes", card.GetText())
+	assert.Equal(t, fyne.NewPos(120, 390), card.WorldPos)
+	assert.NotEqual(t, "card-a", card.ID, "Pasted cards get fresh IDs")
+	assert.NotEqual(t, stroke.ID, copied.ID)
+	assert.Equal(t, fyne.NewPos(90, 560), copied.Points[0])
+
+	require.True(t, dst.Undo())
+	assert.Nil(t, dst.findCardByID(card.ID))
+	assert.Nil(t, dst.StrokeByID(copied.ID))
+}
+
+func TestPasteIgnoresForeignText(t *testing.T) {
+	c, _, _, _ := newSelectionFixture(t)
+	clip := &testutil.MemoryClipboard{Text: "just some text"}
+
+	assert.False(t, c.PasteClipboard(clip))
+	assert.Empty(t, c.undoStack)
+}
+
+func TestPasteTwiceGivesDistinctCopies(t *testing.T) {
+	c, a, _, _ := newSelectionFixture(t)
+	clip := &testutil.MemoryClipboard{}
+	c.SetSelection(a)
+	require.True(t, c.CopySelection(clip))
+
+	require.True(t, c.PasteClipboard(clip))
+	first := c.GetSelectedCard()
+	require.True(t, c.PasteClipboard(clip))
+	second := c.GetSelectedCard()
+
+	require.NotNil(t, first)
+	require.NotNil(t, second)
+	assert.NotEqual(t, first.ID, second.ID)
+	assert.Len(t, c.undoStack, 2)
+}
+
+func TestPasteDisconnectedStrokeUndoes(t *testing.T) {
+	c := NewMosugoCanvas()
+	clip := &testutil.MemoryClipboard{}
+	// A clip from elsewhere holding one stroke ID in two pieces
+	text, err := storage.EncodeClip(nil, []storage.StrokeData{
+		{P1X: 0, P1Y: 0, P2X: 10, P2Y: 0, StrokeID: 4},
+		{P1X: 100, P1Y: 100, P2X: 110, P2Y: 100, StrokeID: 4},
+	})
+	require.NoError(t, err)
+	clip.SetContent(text)
+
+	require.True(t, c.PasteClipboard(clip))
+	assert.Len(t, c.Selection(), 2, "Both pieces are selected")
+	require.Len(t, c.strokeByID, 2)
+
+	for i := 0; i < 3; i++ {
+		require.True(t, c.Undo())
+		assert.Empty(t, c.strokeByID, "Undo removes every piece")
+		require.True(t, c.Redo())
+		assert.Len(t, c.strokeByID, 2, "Redo does not add more pieces")
+	}
+}
@@ -14,7 +14,15 @@ func BoxGridPattern(c *MosugoCanvas, gridSize int, lineColor, bgColor color.Colo
 			devScale = 1.0
 		}
 
-		gSize := float64(gridSize) * devScale
+		zoom := float64(c.Scale)
+		if zoom <= 0 {
+			zoom = 1.0
+		}
+
+		gSize := float64(gridSize) * devScale * zoom
+		if gSize < 1 {
+			gSize = 1
+		}
 
 		distX := math.Abs(math.Remainder(float64(x), gSize))
 		distY := math.Abs(math.Remainder(float64(y), gSize))
@@ -1,17 +1,49 @@
 package canvas
 
 import (
+	"strings"
+	"time"
+	"unicode"
+
 	"fyne.io/fyne/v2"
-	"fyne.io/fyne/v2/canvas"
 
 	"github.com/F4tal1t/Mosugo/internal/cards"
 	"github.com/F4tal1t/Mosugo/internal/storage"
+	"github.com/F4tal1t/Mosugo/internal/strokes"
 	"github.com/F4tal1t/Mosugo/internal/theme"
+	"github.com/F4tal1t/Mosugo/internal/tools"
 )
 
 type historyCommand interface {
 	Apply(c *MosugoCanvas)
 	Undo(c *MosugoCanvas)
+	// entry returns the stored form of the command (see history_store.go)
+	entry() storage.HistoryEntry
+	// describe says what the command did, for the history timeline
+	describe() string
+}
+
+// historyStep is one step of the undo or redo stack: a command and when it
+// was committed.
+type historyStep struct {
+	cmd historyCommand
+	at  time.Time
+}
+
+// compoundCommand groups the commands of one action on several objects
+// into a single history step.
+type compoundCommand []historyCommand
+
+func (cmd compoundCommand) Apply(c *MosugoCanvas) {
+	for _, sub := range cmd {
+		sub.Apply(c)
+	}
+}
+
+func (cmd compoundCommand) Undo(c *MosugoCanvas) {
+	for i := len(cmd) - 1; i >= 0; i-- {
+		cmd[i].Undo(c)
+	}
 }
 
 type cardCreateCommand struct {
@@ -51,6 +83,7 @@ type cardMoveCommand struct {
 func (cmd cardMoveCommand) Apply(c *MosugoCanvas) {
 	if card := c.findCardByID(cmd.cardID); card != nil {
 		card.WorldPos = cmd.after
+		c.UpdateCardBounds(card)
 		c.refreshIfReady()
 	}
 }
@@ -58,6 +91,33 @@ func (cmd cardMoveCommand) Apply(c *MosugoCanvas) {
 func (cmd cardMoveCommand) Undo(c *MosugoCanvas) {
 	if card := c.findCardByID(cmd.cardID); card != nil {
 		card.WorldPos = cmd.before
+		c.UpdateCardBounds(card)
+		c.refreshIfReady()
+	}
+}
+
+type cardResizeCommand struct {
+	cardID     string
+	beforePos  fyne.Position
+	beforeSize fyne.Size
+	afterPos   fyne.Position
+	afterSize  fyne.Size
+}
+
+func (cmd cardResizeCommand) Apply(c *MosugoCanvas) {
+	if card := c.findCardByID(cmd.cardID); card != nil {
+		card.WorldPos = cmd.afterPos
+		card.WorldSize = cmd.afterSize
+		c.UpdateCardBounds(card)
+		c.refreshIfReady()
+	}
+}
+
+func (cmd cardResizeCommand) Undo(c *MosugoCanvas) {
+	if card := c.findCardByID(cmd.cardID); card != nil {
+		card.WorldPos = cmd.beforePos
+		card.WorldSize = cmd.beforeSize
+		c.UpdateCardBounds(card)
 		c.refreshIfReady()
 	}
 }
@@ -84,6 +144,26 @@ func (cmd cardTextCommand) Undo(c *MosugoCanvas) {
 	}
 }
 
+type cardColorCommand struct {
+	cardID string
+	before int
+	after  int
+}
+
+func (cmd cardColorCommand) Apply(c *MosugoCanvas) {
+	if card := c.findCardByID(cmd.cardID); card != nil {
+		card.SetColorIndex(cmd.after)
+		c.refreshIfReady()
+	}
+}
+
+func (cmd cardColorCommand) Undo(c *MosugoCanvas) {
+	if card := c.findCardByID(cmd.cardID); card != nil {
+		card.SetColorIndex(cmd.before)
+		c.refreshIfReady()
+	}
+}
+
 type strokeCreateCommand struct {
 	segments []storage.StrokeData
 }
@@ -116,6 +196,76 @@ func (cmd strokeDeleteCommand) Undo(c *MosugoCanvas) {
 	c.refreshIfReady()
 }
 
+type strokeMoveCommand struct {
+	strokeID int
+	delta    fyne.Position
+}
+
+func (cmd strokeMoveCommand) Apply(c *MosugoCanvas) {
+	if stroke := c.StrokeByID(cmd.strokeID); stroke != nil {
+		stroke.Translate(cmd.delta)
+		c.UpdateStrokeBounds(stroke)
+		c.refreshIfReady()
+	}
+}
+
+func (cmd strokeMoveCommand) Undo(c *MosugoCanvas) {
+	if stroke := c.StrokeByID(cmd.strokeID); stroke != nil {
+		stroke.Translate(fyne.NewPos(-cmd.delta.X, -cmd.delta.Y))
+		c.UpdateStrokeBounds(stroke)
+		c.refreshIfReady()
+	}
+}
+
+type strokeColorCommand struct {
+	strokeID int
+	before   int
+	after    int
+}
+
+func (cmd strokeColorCommand) Apply(c *MosugoCanvas) {
+	if stroke := c.StrokeByID(cmd.strokeID); stroke != nil {
+		c.setStrokeColor(stroke, cmd.after)
+		c.refreshIfReady()
+	}
+}
+
+func (cmd strokeColorCommand) Undo(c *MosugoCanvas) {
+	if stroke := c.StrokeByID(cmd.strokeID); stroke != nil {
+		c.setStrokeColor(stroke, cmd.before)
+		c.refreshIfReady()
+	}
+}
+
+// strokeShape is the geometry a transform changes: the points and pen width.
+type strokeShape struct {
+	points []fyne.Position
+	width  float32
+}
+
+type strokeShapeCommand struct {
+	strokeID int
+	before   strokeShape
+	after    strokeShape
+}
+
+func (cmd strokeShapeCommand) Apply(c *MosugoCanvas) {
+	c.setStrokeShape(cmd.strokeID, cmd.after)
+}
+
+func (cmd strokeShapeCommand) Undo(c *MosugoCanvas) {
+	c.setStrokeShape(cmd.strokeID, cmd.before)
+}
+
+func (c *MosugoCanvas) setStrokeShape(strokeID int, shape strokeShape) {
+	if stroke := c.StrokeByID(strokeID); stroke != nil {
+		stroke.Points = append([]fyne.Position(nil), shape.points...)
+		stroke.Width = shape.width
+		c.UpdateStrokeBounds(stroke)
+		c.refreshIfReady()
+	}
+}
+
 func cloneStrokeSegments(segments []storage.StrokeData) []storage.StrokeData {
 	if len(segments) == 0 {
 		return nil
@@ -125,20 +275,116 @@ func cloneStrokeSegments(segments []storage.StrokeData) []storage.StrokeData {
 	return cloned
 }
 
+const (
+	// DefaultUndoLimit is how many steps of history a canvas keeps by default
+	DefaultUndoLimit = 500
+	// textRunPause is how long typing may pause and still extend the same step
+	textRunPause = time.Second
+)
+
 func (c *MosugoCanvas) resetHistory() {
 	c.undoStack = nil
 	c.redoStack = nil
+	c.textRun = nil
+	c.txnCmds = nil
+	c.txnMarks = nil
+	c.historyChanged()
+}
+
+// SetUndoLimit caps how many steps Undo can go back; the oldest steps are
+// dropped once there are more. A limit below 1 keeps every step.
+func (c *MosugoCanvas) SetUndoLimit(limit int) {
+	c.undoLimit = limit
+	c.trimHistory()
+	c.historyChanged()
 }
 
 func (c *MosugoCanvas) commitCommand(cmd historyCommand) {
 	if c.suppressHistory {
 		return
 	}
-	c.undoStack = append(c.undoStack, cmd)
+	c.textRun = nil
+	if len(c.txnMarks) > 0 {
+		c.txnCmds = append(c.txnCmds, cmd)
+		c.notifyDirty()
+		return
+	}
+	c.undoStack = append(c.undoStack, historyStep{cmd: cmd, at: c.now()})
 	c.redoStack = nil
+	c.trimHistory()
+	c.notifyDirty()
+	c.historyChanged()
+}
+
+func (c *MosugoCanvas) trimHistory() {
+	if c.undoLimit < 1 || len(c.undoStack) <= c.undoLimit {
+		return
+	}
+	// Copy, so the dropped commands can be collected
+	c.undoStack = append([]historyStep(nil), c.undoStack[len(c.undoStack)-c.undoLimit:]...)
+}
+
+// BeginTransaction starts grouping history: every command recorded until the
+// matching CommitTransaction becomes part of one undo step. Transactions
+// nest; only the outermost commit adds the step.
+func (c *MosugoCanvas) BeginTransaction() {
+	c.textRun = nil
+	c.txnMarks = append(c.txnMarks, len(c.txnCmds))
+}
+
+// CommitTransaction closes the innermost open transaction. Closing the
+// outermost records everything since it began as one step, or nothing when
+// nothing changed.
+func (c *MosugoCanvas) CommitTransaction() {
+	if len(c.txnMarks) == 0 {
+		return
+	}
+	c.txnMarks = c.txnMarks[:len(c.txnMarks)-1]
+	if len(c.txnMarks) > 0 {
+		return
+	}
+	cmds := c.txnCmds
+	c.txnCmds = nil
+	c.commitCompound(cmds)
+}
+
+// RollbackTransaction closes the innermost open transaction, reverting the
+// commands recorded since it began.
+func (c *MosugoCanvas) RollbackTransaction() {
+	if len(c.txnMarks) == 0 {
+		return
+	}
+	mark := c.txnMarks[len(c.txnMarks)-1]
+	c.txnMarks = c.txnMarks[:len(c.txnMarks)-1]
+
+	c.suppressHistory = true
+	for i := len(c.txnCmds) - 1; i >= mark; i-- {
+		c.txnCmds[i].Undo(c)
+	}
+	c.suppressHistory = false
+	c.txnCmds = c.txnCmds[:mark]
+	if len(c.txnMarks) == 0 {
+		c.txnCmds = nil
+	}
 	c.notifyDirty()
 }
 
+// InTransaction reports whether a transaction is open.
+func (c *MosugoCanvas) InTransaction() bool {
+	return len(c.txnMarks) > 0
+}
+
+// commitCompound records the commands of one action as a single history step.
+func (c *MosugoCanvas) commitCompound(cmds []historyCommand) {
+	switch len(cmds) {
+	case 0:
+	case 1:
+		c.commitCommand(cmds[0])
+	default:
+		c.commitCommand(compoundCommand(cmds))
+	}
+}
+
 func (c *MosugoCanvas) refreshIfReady() {
 	if c.uiReady {
 		c.Refresh()
@@ -169,12 +415,110 @@ func (c *MosugoCanvas) CommitCardMoved(card *cards.MosuWidget, before fyne.Posit
 	c.commitCommand(cardMoveCommand{cardID: card.ID, before: before, after: card.WorldPos})
 }
 
-// CommitCardTextChanged records a completed card text edit as a reversible command.
+// CommitCardResized records a completed card resize as a reversible command.
+// Resizing from the left or top edge moves the card too, so both are kept.
+func (c *MosugoCanvas) CommitCardResized(card *cards.MosuWidget, beforePos fyne.Position, beforeSize fyne.Size) {
+	if card == nil {
+		return
+	}
+	if beforePos == card.WorldPos && beforeSize == card.WorldSize {
+		return
+	}
+	c.commitCommand(cardResizeCommand{
+		cardID:     card.ID,
+		beforePos:  beforePos,
+		beforeSize: beforeSize,
+		afterPos:   card.WorldPos,
+		afterSize:  card.WorldSize,
+	})
+}
+
+// textRun is a run of typing, or of deleting, in one card that the latest
+// history step still absorbs, so a word undoes in one step rather than a
+// character at a time.
+type textRun struct {
+	cardID string
+	typing bool      // inserting characters rather than deleting them
+	caret  int       // rune offset the last edit left the caret at
+	at     time.Time // when the last edit happened
+}
+
+// CommitCardTextChanged records a card text edit as a reversible command.
+// Typing or deleting a single character joins the latest step while it
+// continues at the caret of the edit before; the step ends after a word (a
+// typed or deleted space), after a pause, when the card loses focus and at
+// any other edit, such as a paste.
 func (c *MosugoCanvas) CommitCardTextChanged(card *cards.MosuWidget, before, after string) {
-	if card == nil || before == after {
+	if card == nil || before == after || c.suppressHistory {
+		return
+	}
+	pos, removed, inserted := diffText(before, after)
+	typing := len(removed) == 0 && cards.GraphemeCount(string(inserted)) == 1
+	deleting := len(inserted) == 0 && cards.GraphemeCount(string(removed)) == 1
+	now := c.now()
+
+	if c.InTransaction() {
+		c.commitCommand(cardTextCommand{cardID: card.ID, before: before, after: after})
 		return
 	}
-	c.commitCommand(cardTextCommand{cardID: card.ID, before: before, after: after})
+	if c.extendsTextRun(card.ID, before, typing, deleting, pos, len(removed), now) {
+		top := c.undoStack[len(c.undoStack)-1].cmd.(cardTextCommand)
+		c.undoStack[len(c.undoStack)-1] = historyStep{
+			cmd: cardTextCommand{cardID: card.ID, before: top.before, after: after},
+			at:  now,
+		}
+		c.redoStack = nil
+		c.notifyDirty()
+		c.historyChanged()
+	} else {
+		c.commitCommand(cardTextCommand{cardID: card.ID, before: before, after: after})
+	}
+
+	if (!typing && !deleting) || strings.IndexFunc(string(removed)+string(inserted), unicode.IsSpace) >= 0 {
+		// The edit finished a word, or was not one character
+		c.textRun = nil
+		return
+	}
+	c.textRun = &textRun{cardID: card.ID, typing: typing, caret: pos + len(inserted), at: now}
+}
+
+// extendsTextRun reports whether an edit at pos continues the open text run.
+func (c *MosugoCanvas) extendsTextRun(cardID, before string, typing, deleting bool, pos, removed int, now time.Time) bool {
+	run := c.textRun
+	if run == nil || run.cardID != cardID || (!typing && !deleting) || run.typing != typing {
+		return false
+	}
+	if now.Sub(run.at) > textRunPause || len(c.undoStack) == 0 {
+		return false
+	}
+	top, ok := c.undoStack[len(c.undoStack)-1].cmd.(cardTextCommand)
+	if !ok || top.cardID != cardID || top.after != before {
+		return false
+	}
+	if typing {
+		return pos == run.caret
+	}
+	// Backspace ends at the caret, Delete starts there
+	return pos+removed == run.caret || pos == run.caret
+}
+
+// EndTextRun closes the open text run, so the next edit starts a new step.
+func (c *MosugoCanvas) EndTextRun() {
+	c.textRun = nil
+}
+
+// diffText returns where before and after differ, as a rune offset, and the
+// runes removed from and inserted into before there.
+func diffText(before, after string) (pos int, removed, inserted []rune) {
+	b, a := []rune(before), []rune(after)
+	for pos < len(b) && pos < len(a) && b[pos] == a[pos] {
+		pos++
+	}
+	suffix := 0
+	for suffix < len(b)-pos && suffix < len(a)-pos && b[len(b)-1-suffix] == a[len(a)-1-suffix] {
+		suffix++
+	}
+	return pos, b[pos : len(b)-suffix], a[pos : len(a)-suffix]
 }
 
 // CommitStrokeCreated records a completed stroke as a reversible command.
@@ -182,7 +526,7 @@ func (c *MosugoCanvas) CommitStrokeCreated(segments []storage.StrokeData) {
 	if len(segments) == 0 {
 		return
 	}
-	c.commitCommand(strokeCreateCommand{segments: cloneStrokeSegments(segments)})
+	c.commitCommand(strokeCreateCommand{segments: c.separateStrokePieces(segments)})
 }
 
 // CommitStrokeDeleted records a removed stroke as a reversible command.
@@ -190,42 +534,47 @@ func (c *MosugoCanvas) CommitStrokeDeleted(segments []storage.StrokeData) {
 	if len(segments) == 0 {
 		return
 	}
-	c.commitCommand(strokeDeleteCommand{segments: cloneStrokeSegments(segments)})
+	c.commitCommand(strokeDeleteCommand{segments: c.separateStrokePieces(segments)})
 }
 
 // Undo reverts the latest committed command.
+// Nothing is undone while a transaction is open.
 func (c *MosugoCanvas) Undo() bool {
-	if len(c.undoStack) == 0 {
+	if len(c.undoStack) == 0 || c.InTransaction() {
 		return false
 	}
 
+	c.textRun = nil
 	last := c.undoStack[len(c.undoStack)-1]
 	c.undoStack = c.undoStack[:len(c.undoStack)-1]
 
 	c.suppressHistory = true
-	last.Undo(c)
+	last.cmd.Undo(c)
 	c.suppressHistory = false
 
 	c.redoStack = append(c.redoStack, last)
 	c.notifyDirty()
+	c.historyChanged()
 	return true
 }
 
-// Redo reapplies the latest undone command.
+// Redo reapplies the latest undone command, not while a transaction is open.
 func (c *MosugoCanvas) Redo() bool {
-	if len(c.redoStack) == 0 {
+	if len(c.redoStack) == 0 || c.InTransaction() {
 		return false
 	}
 
+	c.textRun = nil
 	last := c.redoStack[len(c.redoStack)-1]
 	c.redoStack = c.redoStack[:len(c.redoStack)-1]
 
 	c.suppressHistory = true
-	last.Apply(c)
+	last.cmd.Apply(c)
 	c.suppressHistory = false
 
 	c.undoStack = append(c.undoStack, last)
 	c.notifyDirty()
+	c.historyChanged()
 	return true
 }
 
@@ -257,13 +606,14 @@ func (c *MosugoCanvas) CollectCardData(card *cards.MosuWidget) storage.MosuData
 }
 
 func (c *MosugoCanvas) addCardFromData(data storage.MosuData) *cards.MosuWidget {
-	card := cards.NewMosuWidget(data.ID, theme.CardBg, data.ColorIdx)
+	card := cards.NewMosuWidget(data.ID, theme.CardColor(data.ColorIdx), data.ColorIdx)
 	card.WorldPos = fyne.NewPos(data.PosX, data.PosY)
 	card.WorldSize = fyne.NewSize(data.Width, data.Height)
 	card.CreatedAt = data.CreatedAt
 	card.SetText(data.Content)
 	card.RefreshContent()
 	c.wireCardCallbacks(card)
+	c.UpdateCardBounds(card)
 	c.Content.Add(card)
 	return card
 }
@@ -272,10 +622,7 @@ func (c *MosugoCanvas) removeCardByID(id string) {
 	for _, obj := range c.Content.Objects {
 		card, ok := obj.(*cards.MosuWidget)
 		if ok && card.ID == id {
-			c.Content.Remove(obj)
-			if c.selectedCard == card {
-				c.selectedCard = nil
-			}
+			c.RemoveObject(card)
 			return
 		}
 	}
@@ -289,64 +636,162 @@ func (c *MosugoCanvas) wireCardCallbacks(card *cards.MosuWidget) {
 	card.SetOnTextCommitted(func(before, after string) {
 		c.CommitCardTextChanged(card, before, after)
 	})
+	card.SetOnFocusChanged(func(focused bool) {
+		c.EndTextRun()
+	})
+	card.SetOnContextMenu(func(e *fyne.PointEvent) {
+		c.showCardColorMenu(card, e.AbsolutePosition)
+	})
+	card.SetOnTapped(func(e *fyne.PointEvent) {
+		// Clicks land on the card rather than the canvas, so pass them on
+		// for the Select tool to pick the card
+		if c.CurrentTool != tools.ToolSelect {
+			return
+		}
+		ev := *e
+		ev.Position = card.Position().Add(e.Position)
+		c.Tapped(&ev)
+	})
 }
 
 func (c *MosugoCanvas) addStrokeSegments(segments []storage.StrokeData) {
-	for _, segment := range segments {
-		c.addStrokeSegment(segment)
+	order, groups := groupStrokeSegments(c.separateStrokePieces(segments))
+	for _, id := range order {
+		group := groups[id]
+		width := group[0].Width
+		if width <= 0 {
+			width = c.StrokeWidth
+		}
+		for _, run := range chainSegments(group) {
+			for j := 1; j < len(run); j++ {
+				c.addStrokeSegment(run[j-1], run[j], id, group[0].ColorIdx, width)
+			}
+		}
 	}
 }
 
-func (c *MosugoCanvas) addStrokeSegment(segment storage.StrokeData) {
-	width := segment.Width
-	if width <= 0 {
-		width = c.StrokeWidth
+// separateStrokePieces returns segments with one continuous polyline per
+// stroke ID. Disconnected pieces of one ID after the first, and segments
+// without a valid ID (old format), each get a new ID. Commands hold their
+// segments separated, so the strokes redoing one creates are the ones
+// undoing it removes.
+func (c *MosugoCanvas) separateStrokePieces(segments []storage.StrokeData) []storage.StrokeData {
+	separated := make([]storage.StrokeData, 0, len(segments))
+	order, groups := groupStrokeSegments(segments)
+	for _, id := range order {
+		group := groups[id]
+		if !c.ValidateStrokeID(id) {
+			for _, segment := range group {
+				segment.StrokeID = c.GenerateStrokeID()
+				separated = append(separated, segment)
+			}
+			continue
+		}
+
+		runs := chainSegments(group)
+		if len(runs) == 1 {
+			separated = append(separated, group...)
+			continue
+		}
+		for i, run := range runs {
+			pieceID := id
+			if i > 0 {
+				pieceID = c.GenerateStrokeID()
+			}
+			for j := 1; j < len(run); j++ {
+				separated = append(separated, storage.StrokeData{
+					P1X:      run[j-1].X,
+					P1Y:      run[j-1].Y,
+					P2X:      run[j].X,
+					P2Y:      run[j].Y,
+					ColorIdx: group[0].ColorIdx,
+					Width:    group[0].Width,
+					StrokeID: pieceID,
+				})
+			}
+		}
+	}
+	return separated
+}
+
+// chainSegments links a stroke's segments end to start into continuous runs
+// of points. Older files stored segments in map order, so the saved order
+// can't be trusted to follow the pen.
+func chainSegments(segments []storage.StrokeData) [][]fyne.Position {
+	startsAt := map[fyne.Position][]int{}
+	endsAt := map[fyne.Position]int{}
+	for i, segment := range segments {
+		p1 := fyne.NewPos(segment.P1X, segment.P1Y)
+		p2 := fyne.NewPos(segment.P2X, segment.P2Y)
+		startsAt[p1] = append(startsAt[p1], i)
+		endsAt[p2]++
+	}
+
+	used := make([]bool, len(segments))
+	follow := func(i int) []fyne.Position {
+		used[i] = true
+		run := []fyne.Position{
+			fyne.NewPos(segments[i].P1X, segments[i].P1Y),
+			fyne.NewPos(segments[i].P2X, segments[i].P2Y),
+		}
+		for {
+			next := -1
+			for _, j := range startsAt[run[len(run)-1]] {
+				if !used[j] {
+					next = j
+					break
+				}
+			}
+			if next < 0 {
+				return run
+			}
+			used[next] = true
+			run = append(run, fyne.NewPos(segments[next].P2X, segments[next].P2Y))
+		}
 	}
 
-	previousWidth := c.StrokeWidth
-	c.StrokeWidth = width
-	c.AddStroke(fyne.NewPos(segment.P1X, segment.P1Y), fyne.NewPos(segment.P2X, segment.P2Y), segment.StrokeID)
-	c.StrokeWidth = previousWidth
+	runs := [][]fyne.Position{}
+	// Runs begin where no other segment ends; whatever is left over is a loop
+	for i, segment := range segments {
+		if !used[i] && endsAt[fyne.NewPos(segment.P1X, segment.P1Y)] == 0 {
+			runs = append(runs, follow(i))
+		}
+	}
+	for i := range segments {
+		if !used[i] {
+			runs = append(runs, follow(i))
+		}
+	}
+	return runs
 }
 
-// CollectStrokeDataByID gathers the visible segments for a stroke ID.
+// CollectStrokeDataByID gathers the segments of a stroke.
 func (c *MosugoCanvas) CollectStrokeDataByID(strokeID int) []storage.StrokeData {
+	stroke := c.StrokeByID(strokeID)
+	if stroke == nil {
+		return []storage.StrokeData{}
+	}
+	return strokeSegmentData(stroke)
+}
+
+func strokeSegmentData(stroke *strokes.Stroke) []storage.StrokeData {
 	segments := []storage.StrokeData{}
-	for line, coords := range c.strokesMap {
-		if c.glowLines[line] {
-			continue
-		}
-		id, ok := c.GetStrokeID(line)
-		if !ok || id != strokeID {
-			continue
-		}
+	for _, segment := range stroke.Segments() {
 		segments = append(segments, storage.StrokeData{
-			P1X:      coords.P1.X,
-			P1Y:      coords.P1.Y,
-			P2X:      coords.P2.X,
-			P2Y:      coords.P2.Y,
-			ColorIdx: 0,
-			Width:    c.StrokeWidth,
-			StrokeID: strokeID,
+			P1X:      segment[0].X,
+			P1Y:      segment[0].Y,
+			P2X:      segment[1].X,
+			P2Y:      segment[1].Y,
+			ColorIdx: stroke.ColorIndex,
+			Width:    stroke.Width,
+			StrokeID: stroke.ID,
 		})
 	}
 	return segments
 }
 
 func (c *MosugoCanvas) removeStrokeByID(strokeID int) {
-	objectsToRemove := []fyne.CanvasObject{}
-	for _, obj := range c.Content.Objects {
-		line, ok := obj.(*canvas.Line)
-		if !ok {
-			continue
-		}
-		id, exists := c.GetStrokeID(line)
-		if exists && id == strokeID {
-			objectsToRemove = append(objectsToRemove, line)
-		}
-	}
-
-	for _, obj := range objectsToRemove {
-		c.RemoveObject(obj)
+	if stroke := c.StrokeByID(strokeID); stroke != nil {
+		c.RemoveObject(stroke)
 	}
 }
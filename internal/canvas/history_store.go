@@ -0,0 +1,266 @@
+package canvas
+
+import (
+	"fmt"
+	"log"
+
+	"fyne.io/fyne/v2"
+
+	"github.com/F4tal1t/Mosugo/internal/storage"
+)
+
+// Kinds of stored history entries, one per historyCommand type
+const (
+	entryCompound     = "compound"
+	entryCardCreate   = "card_create"
+	entryCardDelete   = "card_delete"
+	entryCardMove     = "card_move"
+	entryCardResize   = "card_resize"
+	entryCardText     = "card_text"
+	entryCardColor    = "card_color"
+	entryStrokeCreate = "stroke_create"
+	entryStrokeDelete = "stroke_delete"
+	entryStrokeMove   = "stroke_move"
+	entryStrokeColor  = "stroke_color"
+	entryStrokeShape  = "stroke_shape"
+)
+
+func (cmd compoundCommand) entry() storage.HistoryEntry {
+	steps := make([]storage.HistoryEntry, len(cmd))
+	for i, sub := range cmd {
+		steps[i] = sub.entry()
+	}
+	return storage.HistoryEntry{Kind: entryCompound, Steps: steps}
+}
+
+func (cmd cardCreateCommand) entry() storage.HistoryEntry {
+	data := cmd.data
+	return storage.HistoryEntry{Kind: entryCardCreate, Card: &data}
+}
+
+func (cmd cardDeleteCommand) entry() storage.HistoryEntry {
+	data := cmd.data
+	return storage.HistoryEntry{Kind: entryCardDelete, Card: &data}
+}
+
+func (cmd cardMoveCommand) entry() storage.HistoryEntry {
+	return storage.HistoryEntry{
+		Kind:   entryCardMove,
+		CardID: cmd.cardID,
+		Before: &storage.HistoryValue{PosX: cmd.before.X, PosY: cmd.before.Y},
+		After:  &storage.HistoryValue{PosX: cmd.after.X, PosY: cmd.after.Y},
+	}
+}
+
+func (cmd cardResizeCommand) entry() storage.HistoryEntry {
+	return storage.HistoryEntry{
+		Kind:   entryCardResize,
+		CardID: cmd.cardID,
+		Before: &storage.HistoryValue{PosX: cmd.beforePos.X, PosY: cmd.beforePos.Y, Width: cmd.beforeSize.Width, Height: cmd.beforeSize.Height},
+		After:  &storage.HistoryValue{PosX: cmd.afterPos.X, PosY: cmd.afterPos.Y, Width: cmd.afterSize.Width, Height: cmd.afterSize.Height},
+	}
+}
+
+func (cmd cardTextCommand) entry() storage.HistoryEntry {
+	return storage.HistoryEntry{
+		Kind:   entryCardText,
+		CardID: cmd.cardID,
+		Before: &storage.HistoryValue{Text: cmd.before},
+		After:  &storage.HistoryValue{Text: cmd.after},
+	}
+}
+
+func (cmd cardColorCommand) entry() storage.HistoryEntry {
+	return storage.HistoryEntry{
+		Kind:   entryCardColor,
+		CardID: cmd.cardID,
+		Before: &storage.HistoryValue{ColorIdx: cmd.before},
+		After:  &storage.HistoryValue{ColorIdx: cmd.after},
+	}
+}
+
+func (cmd strokeCreateCommand) entry() storage.HistoryEntry {
+	return storage.HistoryEntry{Kind: entryStrokeCreate, Segments: cloneStrokeSegments(cmd.segments)}
+}
+
+func (cmd strokeDeleteCommand) entry() storage.HistoryEntry {
+	return storage.HistoryEntry{Kind: entryStrokeDelete, Segments: cloneStrokeSegments(cmd.segments)}
+}
+
+func (cmd strokeMoveCommand) entry() storage.HistoryEntry {
+	return storage.HistoryEntry{
+		Kind:     entryStrokeMove,
+		StrokeID: cmd.strokeID,
+		After:    &storage.HistoryValue{PosX: cmd.delta.X, PosY: cmd.delta.Y},
+	}
+}
+
+func (cmd strokeColorCommand) entry() storage.HistoryEntry {
+	return storage.HistoryEntry{
+		Kind:     entryStrokeColor,
+		StrokeID: cmd.strokeID,
+		Before:   &storage.HistoryValue{ColorIdx: cmd.before},
+		After:    &storage.HistoryValue{ColorIdx: cmd.after},
+	}
+}
+
+func (cmd strokeShapeCommand) entry() storage.HistoryEntry {
+	return storage.HistoryEntry{
+		Kind:     entryStrokeShape,
+		StrokeID: cmd.strokeID,
+		Before:   shapeValue(cmd.before),
+		After:    shapeValue(cmd.after),
+	}
+}
+
+func shapeValue(shape strokeShape) *storage.HistoryValue {
+	points := make([]float32, 0, 2*len(shape.points))
+	for _, p := range shape.points {
+		points = append(points, p.X, p.Y)
+	}
+	return &storage.HistoryValue{Width: shape.width, Points: points}
+}
+
+func valueShape(v *storage.HistoryValue) strokeShape {
+	points := make([]fyne.Position, 0, len(v.Points)/2)
+	for i := 0; i+1 < len(v.Points); i += 2 {
+		points = append(points, fyne.NewPos(v.Points[i], v.Points[i+1]))
+	}
+	return strokeShape{points: points, width: v.Width}
+}
+
+// commandFromEntry rebuilds the command a stored history entry records.
+func commandFromEntry(e storage.HistoryEntry) (historyCommand, error) {
+	needs := func(ok bool) error {
+		if !ok {
+			return fmt.Errorf("incomplete %s history entry", e.Kind)
+		}
+		return nil
+	}
+	hasValues := e.Before != nil && e.After != nil
+
+	switch e.Kind {
+	case entryCompound:
+		cmd := make(compoundCommand, 0, len(e.Steps))
+		for _, step := range e.Steps {
+			sub, err := commandFromEntry(step)
+			if err != nil {
+				return nil, err
+			}
+			cmd = append(cmd, sub)
+		}
+		return cmd, nil
+	case entryCardCreate:
+		if err := needs(e.Card != nil); err != nil {
+			return nil, err
+		}
+		return cardCreateCommand{data: *e.Card}, nil
+	case entryCardDelete:
+		if err := needs(e.Card != nil); err != nil {
+			return nil, err
+		}
+		return cardDeleteCommand{data: *e.Card}, nil
+	case entryCardMove:
+		if err := needs(hasValues); err != nil {
+			return nil, err
+		}
+		return cardMoveCommand{
+			cardID: e.CardID,
+			before: fyne.NewPos(e.Before.PosX, e.Before.PosY),
+			after:  fyne.NewPos(e.After.PosX, e.After.PosY),
+		}, nil
+	case entryCardResize:
+		if err := needs(hasValues); err != nil {
+			return nil, err
+		}
+		return cardResizeCommand{
+			cardID:     e.CardID,
+			beforePos:  fyne.NewPos(e.Before.PosX, e.Before.PosY),
+			beforeSize: fyne.NewSize(e.Before.Width, e.Before.Height),
+			afterPos:   fyne.NewPos(e.After.PosX, e.After.PosY),
+			afterSize:  fyne.NewSize(e.After.Width, e.After.Height),
+		}, nil
+	case entryCardText:
+		if err := needs(hasValues); err != nil {
+			return nil, err
+		}
+		return cardTextCommand{cardID: e.CardID, before: e.Before.Text, after: e.After.Text}, nil
+	case entryCardColor:
+		if err := needs(hasValues); err != nil {
+			return nil, err
+		}
+		return cardColorCommand{cardID: e.CardID, before: e.Before.ColorIdx, after: e.After.ColorIdx}, nil
+	case entryStrokeCreate:
+		return strokeCreateCommand{segments: cloneStrokeSegments(e.Segments)}, nil
+	case entryStrokeDelete:
+		return strokeDeleteCommand{segments: cloneStrokeSegments(e.Segments)}, nil
+	case entryStrokeMove:
+		if err := needs(e.After != nil); err != nil {
+			return nil, err
+		}
+		return strokeMoveCommand{strokeID: e.StrokeID, delta: fyne.NewPos(e.After.PosX, e.After.PosY)}, nil
+	case entryStrokeColor:
+		if err := needs(hasValues); err != nil {
+			return nil, err
+		}
+		return strokeColorCommand{strokeID: e.StrokeID, before: e.Before.ColorIdx, after: e.After.ColorIdx}, nil
+	case entryStrokeShape:
+		if err := needs(hasValues); err != nil {
+			return nil, err
+		}
+		return strokeShapeCommand{strokeID: e.StrokeID, before: valueShape(e.Before), after: valueShape(e.After)}, nil
+	}
+	return nil, fmt.Errorf("unknown history entry kind %q", e.Kind)
+}
+
+// historyState captures the undo and redo stacks in their stored form.
+func (c *MosugoCanvas) historyState() storage.HistoryState {
+	state := storage.HistoryState{
+		Date: c.currentDate.Format("2006-01-02"),
+		Undo: make([]storage.HistoryEntry, len(c.undoStack)),
+		Redo: make([]storage.HistoryEntry, len(c.redoStack)),
+	}
+	for i, step := range c.undoStack {
+		state.Undo[i] = step.entry()
+	}
+	for i, step := range c.redoStack {
+		state.Redo[i] = step.entry()
+	}
+	return state
+}
+
+func (step historyStep) entry() storage.HistoryEntry {
+	e := step.cmd.entry()
+	if !step.at.IsZero() {
+		at := step.at
+		e.At = &at
+	}
+	return e
+}
+
+// restoreHistory replaces the undo and redo stacks with stored ones. A stack
+// holding an entry this build cannot read is dropped whole, since its other
+// steps may depend on the missing one.
+func (c *MosugoCanvas) restoreHistory(state storage.HistoryState) {
+	c.resetHistory()
+	decode := func(entries []storage.HistoryEntry) []historyStep {
+		steps := make([]historyStep, 0, len(entries))
+		for _, e := range entries {
+			cmd, err := commandFromEntry(e)
+			if err != nil {
+				log.Println("Dropping saved history:", err)
+				return nil
+			}
+			step := historyStep{cmd: cmd}
+			if e.At != nil {
+				step.at = *e.At
+			}
+			steps = append(steps, step)
+		}
+		return steps
+	}
+	c.undoStack = decode(state.Undo)
+	c.redoStack = decode(state.Redo)
+	c.trimHistory()
+	c.historyChanged()
+}
@@ -0,0 +1,118 @@
+package canvas
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/F4tal1t/Mosugo/internal/storage"
+)
+
+func TestEveryCommandRoundtrips(t *testing.T) {
+	card := storage.MosuData{ID: "card_1", Content: "x", PosX: 30, Width: 120, Height: 90, ColorIdx: 1}
+	segments := []storage.StrokeData{{P1X: 1, P1Y: 2, P2X: 3, P2Y: 4, ColorIdx: 2, Width: 2.5, StrokeID: 5}}
+	shape := strokeShape{points: []fyne.Position{{X: 1, Y: 2}, {X: 3, Y: 4}}, width: 2.5}
+
+	cmds := []historyCommand{
+		cardCreateCommand{data: card},
+		cardDeleteCommand{data: card},
+		cardMoveCommand{cardID: "card_1", before: fyne.NewPos(0, 0), after: fyne.NewPos(30, 60)},
+		cardResizeCommand{cardID: "card_1", beforePos: fyne.NewPos(0, 0), beforeSize: fyne.NewSize(60, 60), afterPos: fyne.NewPos(30, 0), afterSize: fyne.NewSize(90, 120)},
+		cardTextCommand{cardID: "card_1", before: "", after: "héllo\n[x] done"},
+		cardColorCommand{cardID: "card_1", before: 0, after: 3},
+		strokeCreateCommand{segments: segments},
+		strokeDeleteCommand{segments: segments},
+		strokeMoveCommand{strokeID: 5, delta: fyne.NewPos(-30, 15)},
+		strokeColorCommand{strokeID: 5, before: 2, after: 0},
+		strokeShapeCommand{strokeID: 5, before: shape, after: strokeShape{points: []fyne.Position{{X: 2, Y: 4}, {X: 6, Y: 8}}, width: 5}},
+	}
+	cmds = append(cmds, compoundCommand{cmds[2], compoundCommand{cmds[5], cmds[8]}})
+
+	for _, cmd := range cmds {
+		data, err := json.Marshal(cmd.entry())
+		require.NoError(t, err)
+		var entry storage.HistoryEntry
+		require.NoError(t, json.Unmarshal(data, &entry))
+
+		restored, err := commandFromEntry(entry)
+		require.NoError(t, err, entry.Kind)
+		assert.Equal(t, cmd, restored, entry.Kind)
+	}
+}
+
+func TestCommandFromEntryRejectsBadEntries(t *testing.T) {
+	_, err := commandFromEntry(storage.HistoryEntry{Kind: "teleport"})
+	assert.Error(t, err)
+	_, err = commandFromEntry(storage.HistoryEntry{Kind: entryCardText, CardID: "card_1"})
+	assert.Error(t, err, "Missing before and after")
+	_, err = commandFromEntry(storage.HistoryEntry{Kind: entryCompound, Steps: []storage.HistoryEntry{{Kind: entryCardCreate}}})
+	assert.Error(t, err, "Bad steps spoil the compound")
+}
+
+func TestRestoredHistoryUndoes(t *testing.T) {
+	c, a, _, stroke := newSelectionFixture(t)
+	c.SetSelection(a, stroke)
+	c.CommitSelectionMoved(fyne.NewPos(0, 0))
+	c.MoveSelection(fyne.NewPos(30, 30))
+	c.CommitSelectionMoved(fyne.NewPos(30, 30))
+	c.CommitCardTextChanged(a, "", "moved")
+	a.SetText("moved")
+	c.SetCardColor(a, 2)
+	require.True(t, c.Undo())
+
+	// Reopen the day: the same content on a fresh canvas, with the saved history
+	state := c.historyState()
+	require.Len(t, state.Undo, 2)
+	require.Len(t, state.Redo, 1)
+	data, err := json.Marshal(state)
+	require.NoError(t, err)
+	var loaded storage.HistoryState
+	require.NoError(t, json.Unmarshal(data, &loaded))
+
+	reopened := NewMosugoCanvas()
+	card := reopened.addCardFromData(c.CollectCardData(a))
+	reopened.addStrokeSegments(c.CollectStrokeDataByID(stroke.ID))
+	reopened.restoreHistory(loaded)
+
+	require.True(t, reopened.Redo())
+	assert.Equal(t, 2, card.ColorIndex)
+	require.True(t, reopened.Undo())
+	require.True(t, reopened.Undo())
+	assert.Equal(t, "", card.GetText())
+	require.True(t, reopened.Undo())
+	assert.Equal(t, fyne.NewPos(30, 30), card.WorldPos)
+	assert.Equal(t, fyne.NewPos(0, 200), reopened.StrokeByID(stroke.ID).Points[0])
+	assert.False(t, reopened.Undo())
+}
+
+func TestUnreadableHistoryIsDropped(t *testing.T) {
+	c := NewMosugoCanvas()
+	c.restoreHistory(storage.HistoryState{
+		Undo: []storage.HistoryEntry{{Kind: entryStrokeColor, StrokeID: 1, Before: &storage.HistoryValue{}, After: &storage.HistoryValue{ColorIdx: 1}}, {Kind: "from_the_future"}},
+		Redo: []storage.HistoryEntry{{Kind: entryStrokeMove, StrokeID: 1, After: &storage.HistoryValue{PosX: 30}}},
+	})
+	assert.Empty(t, c.undoStack)
+	assert.Len(t, c.redoStack, 1)
+}
+
+func TestSavedHistoryFollowsUndoLimit(t *testing.T) {
+	store := storage.NewMemoryStore()
+	date := time.Date(2099, 2, 2, 0, 0, 0, 0, time.UTC)
+	c := NewMosugoCanvas()
+	c.SetStore(store)
+	c.SetCurrentDate(date)
+	c.SetUndoLimit(3)
+
+	for i := 0; i < 5; i++ {
+		addTestStroke(c, float32(i*10))
+	}
+	require.NoError(t, c.SaveCurrentWorkspace())
+
+	loaded, err := store.LoadHistory(date)
+	require.NoError(t, err)
+	assert.Len(t, loaded.Undo, 3, "The canvas's limit is the only one")
+}
@@ -2,26 +2,26 @@ package canvas
 
 import (
 	"testing"
+	"time"
+	"unicode/utf8"
 
 	"fyne.io/fyne/v2"
-	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/driver/desktop"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/F4tal1t/Mosugo/internal/cards"
+	"github.com/F4tal1t/Mosugo/internal/storage"
+	"github.com/F4tal1t/Mosugo/internal/strokes"
+	"github.com/F4tal1t/Mosugo/internal/testutil"
 	"github.com/F4tal1t/Mosugo/internal/theme"
+	"github.com/F4tal1t/Mosugo/internal/tools"
 )
 
-func countStrokeLines(c *MosugoCanvas, strokeID int) int {
+func countStrokes(c *MosugoCanvas, strokeID int) int {
 	count := 0
 	for _, obj := range c.Content.Objects {
-		line, ok := obj.(*canvas.Line)
-		if !ok {
-			continue
-		}
-		id, exists := c.GetStrokeID(line)
-		if exists && id == strokeID {
+		if stroke, ok := obj.(*strokes.Stroke); ok && stroke.ID == strokeID {
 			count++
 		}
 	}
@@ -38,10 +38,13 @@ func TestUndoRedoCommands(t *testing.T) {
 	c.CommitStrokeCreated(c.CollectStrokeDataByID(strokeID))
 
 	require.True(t, c.Undo(), "undo should restore the previous snapshot")
-	assert.Equal(t, 0, countStrokeLines(c, strokeID))
+	assert.Equal(t, 0, countStrokes(c, strokeID))
 
 	require.True(t, c.Redo(), "redo should restore the reverted snapshot")
-	assert.Greater(t, countStrokeLines(c, strokeID), 0)
+	assert.Equal(t, 1, countStrokes(c, strokeID))
+	stroke := c.StrokeByID(strokeID)
+	require.NotNil(t, stroke)
+	assert.Equal(t, []fyne.Position{p1, p2}, stroke.Points)
 }
 
 func TestFocusedCardShortcutForwarding(t *testing.T) {
@@ -74,3 +77,353 @@ func TestCardTextEditsCommitImmediately(t *testing.T) {
 	require.True(t, c.Redo())
 	assert.Equal(t, "b", card.GetText())
 }
+
+func TestCardClipboard(t *testing.T) {
+	c := NewMosugoCanvas()
+	card := c.addCardFromData(storage.MosuData{ID: "card-1", Content: "hello world", Width: 120, Height: 90})
+	clip := &testutil.MemoryClipboard{}
+
+	card.TypedShortcut(&fyne.ShortcutCopy{Clipboard: clip})
+	assert.Empty(t, clip.Text, "Copy without a selection leaves the clipboard alone")
+
+	card.TypedShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyLeft, Modifier: fyne.KeyModifierControl | fyne.KeyModifierShift})
+	card.TypedShortcut(&fyne.ShortcutCopy{Clipboard: clip})
+	assert.Equal(t, "world", clip.Text)
+	assert.Empty(t, c.undoStack, "Copy is not an edit")
+
+	card.TypedShortcut(&fyne.ShortcutCut{Clipboard: clip})
+	assert.Equal(t, "hello ", card.GetText())
+	require.Len(t, c.undoStack, 1)
+
+	clip.Text = "one\r\ntwo\nthree\x00"
+	card.TypedShortcut(&fyne.ShortcutPaste{Clipboard: clip})
+	assert.Equal(t, "hello one\ntwo\nthree", card.GetText())
+	require.Len(t, c.undoStack, 2, "A multi-line paste is one step")
+	card.TypedRune('!')
+	assert.Equal(t, "hello one\ntwo\nthree!", card.GetText(), "Caret ends after the pasted text")
+
+	require.True(t, c.Undo())
+	require.True(t, c.Undo())
+	assert.Equal(t, "hello ", card.GetText())
+	require.True(t, c.Undo())
+	assert.Equal(t, "hello world", card.GetText())
+}
+
+func typeText(card *cards.MosuWidget, text string) {
+	for _, r := range text {
+		card.TypedRune(r)
+	}
+}
+
+func TestTypingUndoesByWord(t *testing.T) {
+	c := NewMosugoCanvas()
+	card := c.addCardFromData(storage.MosuData{ID: "card-1", Width: 120, Height: 90})
+
+	typeText(card, "hello wörld 👍🏽")
+	require.Len(t, c.undoStack, 3, "Each word, with the space after it, is one step")
+
+	require.True(t, c.Undo())
+	assert.Equal(t, "hello wörld ", card.GetText())
+	require.True(t, c.Undo())
+	assert.Equal(t, "hello ", card.GetText())
+	require.True(t, c.Redo())
+	assert.Equal(t, "hello wörld ", card.GetText())
+
+	// Typing after an undo starts a new step rather than joining the old one
+	typeText(card, "x")
+	require.Len(t, c.undoStack, 3)
+	assert.Empty(t, c.redoStack)
+}
+
+func TestBackspaceRunIsOneStep(t *testing.T) {
+	c := NewMosugoCanvas()
+	card := c.addCardFromData(storage.MosuData{ID: "card-1", Content: "one two", Width: 120, Height: 90})
+
+	for i := 0; i < 3; i++ {
+		card.TypedKey(&fyne.KeyEvent{Name: fyne.KeyBackspace})
+	}
+	require.Len(t, c.undoStack, 1)
+	require.True(t, c.Undo())
+	assert.Equal(t, "one two", card.GetText())
+}
+
+func TestTextRunBreaks(t *testing.T) {
+	c := NewMosugoCanvas()
+	now := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	c.now = func() time.Time { return now }
+	card := c.addCardFromData(storage.MosuData{ID: "card-1", Width: 120, Height: 90})
+
+	typeText(card, "ab")
+	now = now.Add(2 * textRunPause)
+	typeText(card, "c")
+	require.Len(t, c.undoStack, 2, "A pause ends the step")
+
+	card.FocusLost()
+	card.FocusGained()
+	typeText(card, "d")
+	require.Len(t, c.undoStack, 3, "Focus changes end the step")
+
+	card.TypedKey(&fyne.KeyEvent{Name: fyne.KeyLeft})
+	typeText(card, "e")
+	require.Len(t, c.undoStack, 4, "Moving the caret ends the step")
+
+	card.TypedKey(&fyne.KeyEvent{Name: fyne.KeyEnd})
+	card.TypedKey(&fyne.KeyEvent{Name: fyne.KeyBackspace})
+	require.Len(t, c.undoStack, 5, "Deleting does not join typing")
+	assert.Equal(t, "abce", card.GetText())
+}
+
+func TestUndoLimit(t *testing.T) {
+	c := NewMosugoCanvas()
+	assert.Equal(t, DefaultUndoLimit, c.undoLimit)
+	c.SetUndoLimit(3)
+
+	ids := []int{}
+	for i := 0; i < 5; i++ {
+		id := c.GenerateStrokeID()
+		c.AddStroke(fyne.NewPos(0, float32(i*10)), fyne.NewPos(50, float32(i*10)), id)
+		c.CommitStrokeCreated(c.CollectStrokeDataByID(id))
+		ids = append(ids, id)
+	}
+	require.Len(t, c.undoStack, 3, "The oldest steps are dropped")
+
+	for c.Undo() {
+	}
+	assert.NotNil(t, c.StrokeByID(ids[1]), "Steps past the limit cannot be undone")
+	assert.Nil(t, c.StrokeByID(ids[2]))
+
+	c.SetUndoLimit(0)
+	for i := 0; i < 2*DefaultUndoLimit; i++ {
+		c.commitCommand(compoundCommand(nil))
+	}
+	assert.Len(t, c.undoStack, 2*DefaultUndoLimit, "A limit below 1 keeps every step")
+}
+
+func TestDisconnectedStrokePiecesUndo(t *testing.T) {
+	c := NewMosugoCanvas()
+	c.nextStrokeID = 10
+	// One stroke ID in two pieces, as an older or edited file can have it
+	segments := []storage.StrokeData{
+		{P1X: 0, P1Y: 0, P2X: 10, P2Y: 0, StrokeID: 7},
+		{P1X: 100, P1Y: 100, P2X: 110, P2Y: 100, StrokeID: 7},
+	}
+	c.addStrokeSegments(segments)
+	require.Len(t, c.strokeByID, 2, "Each piece is a stroke of its own")
+	ids := []int{}
+	for id := range c.strokeByID {
+		ids = append(ids, id)
+	}
+
+	c.CommitStrokeDeleted(segments)
+	c.removeStrokeByID(ids[0])
+	c.removeStrokeByID(ids[1])
+	for i := 0; i < 3; i++ {
+		require.True(t, c.Undo())
+		require.Len(t, c.strokeByID, 2, "Undo does not add more pieces")
+		require.True(t, c.Redo())
+		assert.Empty(t, c.strokeByID, "Redo removes every piece")
+	}
+}
+
+func TestSeparateStrokePieces(t *testing.T) {
+	c := NewMosugoCanvas()
+	c.nextStrokeID = 10
+	connected := []storage.StrokeData{
+		{P1X: 10, P1Y: 0, P2X: 20, P2Y: 0, StrokeID: 3},
+		{P1X: 0, P1Y: 0, P2X: 10, P2Y: 0, StrokeID: 3},
+	}
+	assert.Equal(t, connected, c.separateStrokePieces(connected), "A continuous stroke is left alone")
+
+	separated := c.separateStrokePieces([]storage.StrokeData{
+		{P1X: 0, P1Y: 0, P2X: 10, P2Y: 0, ColorIdx: 2, Width: 4, StrokeID: 3},
+		{P1X: 50, P1Y: 0, P2X: 60, P2Y: 0, ColorIdx: 2, Width: 4, StrokeID: 3},
+		{P1X: 0, P1Y: 9, P2X: 5, P2Y: 9},
+	})
+	assert.Equal(t, []storage.StrokeData{
+		{P1X: 0, P1Y: 0, P2X: 10, P2Y: 0, ColorIdx: 2, Width: 4, StrokeID: 3},
+		{P1X: 50, P1Y: 0, P2X: 60, P2Y: 0, ColorIdx: 2, Width: 4, StrokeID: 10},
+		{P1X: 0, P1Y: 9, P2X: 5, P2Y: 9, StrokeID: 11},
+	}, separated)
+}
+
+func addTestStroke(c *MosugoCanvas, y float32) int {
+	id := c.GenerateStrokeID()
+	c.AddStroke(fyne.NewPos(0, y), fyne.NewPos(100, y), id)
+	c.CommitStrokeCreated(c.CollectStrokeDataByID(id))
+	return id
+}
+
+func TestTransactionIsOneStep(t *testing.T) {
+	c := NewMosugoCanvas()
+
+	c.BeginTransaction()
+	first := addTestStroke(c, 0)
+	c.BeginTransaction()
+	second := addTestStroke(c, 50)
+	c.CommitTransaction()
+	assert.Empty(t, c.undoStack, "Nothing is recorded until the outermost commit")
+	assert.False(t, c.Undo(), "History waits for the transaction")
+	c.CommitTransaction()
+
+	require.Len(t, c.undoStack, 1)
+	require.True(t, c.Undo())
+	assert.Nil(t, c.StrokeByID(first))
+	assert.Nil(t, c.StrokeByID(second))
+
+	c.BeginTransaction()
+	c.CommitTransaction()
+	assert.Len(t, c.undoStack, 0, "An empty transaction records nothing")
+}
+
+func TestRollbackTransaction(t *testing.T) {
+	c := NewMosugoCanvas()
+	kept := addTestStroke(c, 0)
+
+	c.BeginTransaction()
+	c.BeginTransaction()
+	inner := addTestStroke(c, 50)
+	c.RollbackTransaction()
+	assert.Nil(t, c.StrokeByID(inner), "Rollback reverts the inner transaction only")
+
+	outer := addTestStroke(c, 100)
+	c.RollbackTransaction()
+	assert.Nil(t, c.StrokeByID(outer))
+	assert.NotNil(t, c.StrokeByID(kept))
+	assert.Len(t, c.undoStack, 1)
+	assert.False(t, c.InTransaction())
+}
+
+// quietCanvas lets tools drive a canvas that was never shown.
+type quietCanvas struct {
+	*MosugoCanvas
+}
+
+func (quietCanvas) Refresh() {}
+
+func TestEraseDragIsOneStep(t *testing.T) {
+	c, a, b, stroke := newSelectionFixture(t)
+	eraser := &tools.EraseTool{}
+
+	for _, pos := range []fyne.Position{{X: 60, Y: 60}, {X: 50, Y: 230}, {X: 330, Y: 60}, {X: 600, Y: 600}} {
+		eraser.OnDragged(quietCanvas{c}, &fyne.DragEvent{PointEvent: fyne.PointEvent{Position: pos}})
+	}
+	eraser.OnDragEnd(quietCanvas{c})
+	assert.Nil(t, c.findCardByID(a.ID))
+	assert.Nil(t, c.findCardByID(b.ID))
+	assert.Nil(t, c.StrokeByID(stroke.ID))
+	require.Len(t, c.undoStack, 1)
+
+	require.True(t, c.Undo())
+	assert.NotNil(t, c.findCardByID(a.ID))
+	assert.NotNil(t, c.findCardByID(b.ID))
+	assert.NotNil(t, c.StrokeByID(stroke.ID))
+
+	// A click erases on its own
+	eraser.OnTapped(quietCanvas{c}, &fyne.PointEvent{Position: fyne.NewPos(60, 60)})
+	assert.Len(t, c.undoStack, 1)
+	assert.False(t, c.InTransaction())
+}
+
+func TestUndoRedoKeepsStrokeStyle(t *testing.T) {
+	c := NewMosugoCanvas()
+	c.StrokeColorIdx, c.StrokeWidth = 2, 5
+
+	strokeID := c.GenerateStrokeID()
+	c.AddStroke(fyne.NewPos(0, 0), fyne.NewPos(40, 40), strokeID)
+	segments := c.CollectStrokeDataByID(strokeID)
+	c.removeStrokeByID(strokeID)
+	c.CommitStrokeDeleted(segments)
+
+	// The pen changing in between must not leak into the restored stroke
+	c.StrokeColorIdx, c.StrokeWidth = 0, 2.5
+	require.True(t, c.Undo())
+	stroke := c.StrokeByID(strokeID)
+	require.NotNil(t, stroke)
+	assert.Equal(t, 2, stroke.ColorIndex)
+	assert.Equal(t, theme.StrokePalette[2], stroke.Color)
+	assert.Equal(t, float32(5), stroke.Width)
+}
+
+func TestCardColorChangesAreUndoable(t *testing.T) {
+	c := NewMosugoCanvas()
+	assert.False(t, c.CycleSelectionColor(), "Nothing to recolor without a selection")
+
+	card := c.addCardFromData(storage.MosuData{ID: "card-1", Width: 120, Height: 90, ColorIdx: 2})
+	c.SetSelectedCard(card)
+
+	require.True(t, c.CycleSelectionColor())
+	assert.Equal(t, 3, card.ColorIndex)
+	require.True(t, c.CycleSelectionColor())
+	assert.Equal(t, 0, card.ColorIndex, "Cycling wraps around the palette")
+
+	// Picking the current color records nothing
+	c.SetCardColor(card, 0)
+	c.SetCardColor(card, 1)
+	assert.Len(t, c.undoStack, 3)
+
+	require.True(t, c.Undo())
+	assert.Equal(t, 0, card.ColorIndex)
+	require.True(t, c.Undo())
+	assert.Equal(t, 3, card.ColorIndex)
+	require.True(t, c.Redo())
+	assert.Equal(t, 0, card.ColorIndex)
+	assert.Equal(t, 0, c.CollectCardData(card).ColorIdx)
+}
+
+func TestCardResizeIsUndoable(t *testing.T) {
+	c := NewMosugoCanvas()
+	card := c.addCardFromData(storage.MosuData{ID: "card-1", PosX: 30, PosY: 30, Width: 120, Height: 90})
+
+	beforePos, beforeSize := card.WorldPos, card.WorldSize
+	c.CommitCardResized(card, beforePos, beforeSize)
+	assert.Empty(t, c.undoStack, "An unchanged card records nothing")
+
+	// Dragging the top-left handle moves the card as well as resizing it
+	card.WorldPos = fyne.NewPos(0, 0)
+	card.WorldSize = fyne.NewSize(150, 120)
+	c.UpdateCardBounds(card)
+	c.CommitCardResized(card, beforePos, beforeSize)
+
+	require.True(t, c.Undo())
+	assert.Equal(t, beforePos, card.WorldPos)
+	assert.Equal(t, beforeSize, card.WorldSize)
+	assert.Nil(t, c.CardAt(c.WorldToScreen(fyne.NewPos(10, 10))), "Index follows the undo")
+
+	require.True(t, c.Redo())
+	assert.Equal(t, fyne.NewPos(0, 0), card.WorldPos)
+	assert.Equal(t, fyne.NewSize(150, 120), card.WorldSize)
+	assert.Same(t, card, c.CardAt(c.WorldToScreen(fyne.NewPos(10, 10))))
+}
+
+// FuzzCardEditingKeepsValidUTF8 types, deletes and moves through a card the
+// way the keyboard does; what would be saved must always be valid UTF-8,
+// including after undoing and redoing every edit.
+func FuzzCardEditingKeepsValidUTF8(f *testing.F) {
+	f.Add("café", []byte{1, 0, 1, 3, 2, 4})
+	f.Add("👩\u200d👩\u200d👧", []byte{3, 1, 5, 0, 0, 1})
+
+	keys := []fyne.KeyName{fyne.KeyBackspace, fyne.KeyDelete, fyne.KeyLeft, fyne.KeyRight, fyne.KeyHome, fyne.KeyEnd}
+	f.Fuzz(func(t *testing.T, typed string, ops []byte) {
+		c := NewMosugoCanvas()
+		card := c.addCardFromData(storage.MosuData{ID: "card-1", Width: 120, Height: 90})
+
+		runes := []rune(typed)
+		for _, op := range ops {
+			if int(op)%2 == 0 && len(runes) > 0 {
+				card.TypedRune(runes[0])
+				runes = runes[1:]
+			} else {
+				card.TypedKey(&fyne.KeyEvent{Name: keys[int(op)%len(keys)]})
+			}
+			content := c.CollectCardData(card).Content
+			require.True(t, utf8.ValidString(content), "content %q", content)
+		}
+
+		for c.Undo() {
+			require.True(t, utf8.ValidString(c.CollectCardData(card).Content))
+		}
+		for c.Redo() {
+			require.True(t, utf8.ValidString(c.CollectCardData(card).Content))
+		}
+	})
+}
@@ -0,0 +1,169 @@
+package canvas
+
+import (
+	"reflect"
+	"slices"
+
+	"fyne.io/fyne/v2"
+
+	"github.com/F4tal1t/Mosugo/internal/cards"
+	"github.com/F4tal1t/Mosugo/internal/storage"
+)
+
+// IsDirty reports whether the canvas has changes that are not saved yet.
+func (c *MosugoCanvas) IsDirty() bool {
+	return c.isDirty
+}
+
+// ReloadWorkspace replaces the canvas with the open day as it now is in the
+// store, after it changed there, keeping the view where it is. The change is
+// recorded as one step on top of the history, so undo goes back through it
+// to the steps made before instead of replaying them against the new
+// contents.
+func (c *MosugoCanvas) ReloadWorkspace() error {
+	state, err := c.store.LoadWorkspace(c.currentDate)
+	if err != nil {
+		return err
+	}
+	// state is the base of the next merge, so nothing may share its slices
+	c.saved = state
+	c.saved.Cards = slices.Clone(state.Cards)
+	c.saved.Strokes = slices.Clone(state.Strokes)
+
+	cmds := c.cardMergeCommands(state.Cards)
+	cmds = append(cmds, c.strokeMergeCommands(state.Strokes)...)
+	for _, cmd := range cmds {
+		cmd.Apply(c)
+	}
+	c.commitCompound(cmds)
+	// The canvas now matches the store
+	c.isDirty = false
+
+	if state.Recovery != nil {
+		c.notifyDirty()
+		c.reportRecovery(c.currentDate, *state.Recovery)
+	}
+	return nil
+}
+
+// MergeWorkspace merges theirs, a version of the open day saved elsewhere,
+// into the canvas as one step. What changed here and what changed there
+// since the day was last loaded or saved combine, as storage.Merge does it.
+// When theirs was recovered from a damaged file, the recovery is reported
+// like a damaged load, so a salvaged version is never merged unnoticed. It
+// returns the conflicts the merge reported, and false when the canvas did
+// not change.
+func (c *MosugoCanvas) MergeWorkspace(theirs storage.WorkspaceState) ([]storage.MergeConflict, bool) {
+	result := storage.Merge(c.saved, c.workspaceState(), theirs)
+	// Until the next save, theirs is what is on disk
+	c.saved = theirs
+	c.saved.Cards = slices.Clone(theirs.Cards)
+	c.saved.Strokes = slices.Clone(theirs.Strokes)
+
+	cmds := c.cardMergeCommands(result.State.Cards)
+	cmds = append(cmds, c.strokeMergeCommands(result.State.Strokes)...)
+	changed := len(cmds) > 0
+	if changed {
+		for _, cmd := range cmds {
+			cmd.Apply(c)
+		}
+		c.commitCompound(cmds)
+	}
+
+	if theirs.Recovery != nil {
+		// The damaged file may have been moved aside, so the merge needs saving
+		c.notifyDirty()
+		c.reportRecovery(c.currentDate, *theirs.Recovery)
+	}
+	return result.Conflicts, changed
+}
+
+// cardMergeCommands returns the steps that turn the cards on the canvas into
+// merged. Cards kept on both keep their place in the stacking order.
+func (c *MosugoCanvas) cardMergeCommands(merged []storage.MosuData) []historyCommand {
+	cmds := []historyCommand{}
+	wanted := make(map[string]storage.MosuData, len(merged))
+	for _, data := range merged {
+		wanted[data.ID] = data
+	}
+
+	for _, obj := range c.Content.Objects {
+		card, ok := obj.(*cards.MosuWidget)
+		if !ok {
+			continue
+		}
+		have := c.CollectCardData(card)
+		want, kept := wanted[card.ID]
+		if !kept {
+			cmds = append(cmds, cardDeleteCommand{data: have})
+			continue
+		}
+		if want.Content != have.Content {
+			cmds = append(cmds, cardTextCommand{cardID: card.ID, before: have.Content, after: want.Content})
+		}
+		if want.PosX != have.PosX || want.PosY != have.PosY || want.Width != have.Width || want.Height != have.Height {
+			cmds = append(cmds, cardResizeCommand{
+				cardID:     card.ID,
+				beforePos:  fyne.NewPos(have.PosX, have.PosY),
+				beforeSize: fyne.NewSize(have.Width, have.Height),
+				afterPos:   fyne.NewPos(want.PosX, want.PosY),
+				afterSize:  fyne.NewSize(want.Width, want.Height),
+			})
+		}
+		if want.ColorIdx != have.ColorIdx {
+			cmds = append(cmds, cardColorCommand{cardID: card.ID, before: have.ColorIdx, after: want.ColorIdx})
+		}
+	}
+
+	for _, data := range merged {
+		if c.findCardByID(data.ID) == nil {
+			cmds = append(cmds, cardCreateCommand{data: data})
+		}
+	}
+	return cmds
+}
+
+// strokeMergeCommands returns the steps that turn the strokes on the canvas
+// into merged. A stroke that changed is replaced as a whole.
+func (c *MosugoCanvas) strokeMergeCommands(merged []storage.StrokeData) []historyCommand {
+	cmds := []historyCommand{}
+	// The commands must not leave it to addStrokeSegments to split strokes
+	order, wanted := groupStrokeSegments(c.separateStrokePieces(merged))
+
+	for _, stroke := range c.sortedStrokes() {
+		have := strokeSegmentData(stroke)
+		want, kept := wanted[stroke.ID]
+		if len(have) == 0 || kept && reflect.DeepEqual(have, want) {
+			// A stroke without segments is not saved, so the merge never sees it
+			continue
+		}
+		cmds = append(cmds, strokeDeleteCommand{segments: have})
+		if kept {
+			cmds = append(cmds, strokeCreateCommand{segments: want})
+		}
+	}
+
+	for _, id := range order {
+		if c.StrokeByID(id) == nil {
+			cmds = append(cmds, strokeCreateCommand{segments: wanted[id]})
+		}
+		if id >= c.nextStrokeID {
+			c.nextStrokeID = id + 1
+		}
+	}
+	return cmds
+}
+
+// groupStrokeSegments groups segments by stroke ID, keeping the order in
+// which IDs first appear
+func groupStrokeSegments(segments []storage.StrokeData) ([]int, map[int][]storage.StrokeData) {
+	order := []int{}
+	groups := map[int][]storage.StrokeData{}
+	for _, segment := range segments {
+		if _, ok := groups[segment.StrokeID]; !ok {
+			order = append(order, segment.StrokeID)
+		}
+		groups[segment.StrokeID] = append(groups[segment.StrokeID], segment)
+	}
+	return order, groups
+}
@@ -0,0 +1,192 @@
+package canvas
+
+import (
+	"testing"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/F4tal1t/Mosugo/internal/cards"
+	"github.com/F4tal1t/Mosugo/internal/storage"
+)
+
+func cardTexts(c *MosugoCanvas) []string {
+	texts := []string{}
+	for _, obj := range c.Content.Objects {
+		if card, ok := obj.(*cards.MosuWidget); ok {
+			texts = append(texts, card.GetText())
+		}
+	}
+	return texts
+}
+
+func TestMergeWorkspaceCombinesChanges(t *testing.T) {
+	store := storage.NewMemoryStore()
+	date := time.Date(2099, 3, 1, 0, 0, 0, 0, time.UTC)
+	c := NewMosugoCanvas()
+	c.SetStore(store)
+	c.SetCurrentDate(date)
+
+	c.addCardFromData(storage.MosuData{ID: "card_1", Content: "shopping", Width: 120, Height: 90})
+	c.addCardFromData(storage.MosuData{ID: "card_2", Content: "todo", PosX: 300, Width: 120, Height: 90})
+	kept := addTestStroke(c, 0)
+	erased := addTestStroke(c, 50)
+	require.NoError(t, c.SaveCurrentWorkspace())
+
+	theirs, err := store.LoadWorkspace(date)
+	require.NoError(t, err)
+	_, changed := c.MergeWorkspace(theirs)
+	assert.False(t, changed, "Nothing to merge from an identical version")
+
+	// Here card_1 is edited, card_2 moved and a stroke erased
+	c.findCardByID("card_1").SetText("shopping: milk")
+	c.findCardByID("card_2").WorldPos = fyne.NewPos(300, 200)
+	c.removeStrokeByID(erased)
+
+	// There card_1 is edited too, card_2 edited, a stroke recolored and a card added
+	theirs.Cards[0].Content = "shopping: eggs"
+	theirs.Cards[1].Content = "todo: call"
+	for i := range theirs.Strokes {
+		if theirs.Strokes[i].StrokeID == kept {
+			theirs.Strokes[i].ColorIdx = 3
+		}
+	}
+	theirs.Cards = append(theirs.Cards, storage.MosuData{ID: "card_9", Content: "new", PosY: 300, Width: 120, Height: 90})
+	undoDepth := len(c.undoStack)
+
+	conflicts, changed := c.MergeWorkspace(theirs)
+	require.True(t, changed)
+	assert.Equal(t, []storage.MergeConflict{{CardID: "card_1", Reason: "text changed on both sides"}}, conflicts)
+	assert.Len(t, c.undoStack, undoDepth+1, "A merge is one step")
+
+	assert.Equal(t, "shopping: milk", c.findCardByID("card_1").GetText(), "Ours wins a conflict")
+	assert.Equal(t, storage.ConflictNote+"\nshopping: eggs", c.findCardByID("card_1_conflict").GetText(), "Theirs is kept beside it")
+	assert.Equal(t, "todo: call", c.findCardByID("card_2").GetText())
+	assert.Equal(t, fyne.NewPos(300, 200), c.findCardByID("card_2").WorldPos, "A move here and an edit there combine")
+	assert.Equal(t, "new", c.findCardByID("card_9").GetText())
+	assert.Equal(t, 3, c.StrokeByID(kept).ColorIndex)
+	assert.Nil(t, c.StrokeByID(erased), "An unchanged stroke erased here stays erased")
+
+	_, changed = c.MergeWorkspace(theirs)
+	assert.False(t, changed, "Merging the same version again changes nothing")
+
+	require.True(t, c.Undo())
+	assert.ElementsMatch(t, []string{"shopping: milk", "todo"}, cardTexts(c))
+	assert.Equal(t, 0, c.StrokeByID(kept).ColorIndex)
+}
+
+func TestMergeWorkspaceReportsRecovery(t *testing.T) {
+	date := time.Date(2099, 3, 3, 0, 0, 0, 0, time.UTC)
+	c := NewMosugoCanvas()
+	c.SetStore(storage.NewMemoryStore())
+	c.SetCurrentDate(date)
+	c.addCardFromData(storage.MosuData{ID: "card_1", Content: "mine", Width: 120, Height: 90})
+
+	var reported []time.Time
+	c.SetOnRecovered(func(date time.Time, recovery storage.Recovery) {
+		reported = append(reported, date)
+		assert.Equal(t, 1, recovery.SalvagedCards)
+	})
+
+	salvaged := storage.WorkspaceState{
+		Scale:    1.0,
+		Cards:    []storage.MosuData{{ID: "card_1", Content: "mine", Width: 120, Height: 90}},
+		Recovery: &storage.Recovery{SalvagedCards: 1},
+	}
+	_, changed := c.MergeWorkspace(salvaged)
+	assert.False(t, changed)
+	assert.Equal(t, []time.Time{date}, reported, "A salvaged version is not merged silently")
+	assert.True(t, c.IsDirty(), "The recovered day is saved again")
+}
+
+func TestReloadWorkspaceIsOneStep(t *testing.T) {
+	store := storage.NewMemoryStore()
+	date := time.Date(2099, 3, 2, 0, 0, 0, 0, time.UTC)
+	c := NewMosugoCanvas()
+	c.SetStore(store)
+	c.SetCurrentDate(date)
+
+	card := c.addCardFromData(storage.MosuData{ID: "card_1", Content: "a", Width: 120, Height: 90})
+	c.CommitCardCreated(card)
+	card.SetText("ab")
+	c.CommitCardTextChanged(card, "a", "ab")
+	require.NoError(t, c.SaveCurrentWorkspace())
+	c.Scale = 2
+
+	// Another program rewrites the day
+	external, err := store.LoadWorkspace(date)
+	require.NoError(t, err)
+	external.Cards[0].Content = "external edit"
+	external.Cards = append(external.Cards, storage.MosuData{ID: "card_2", Content: "added", PosY: 200, Width: 120, Height: 90})
+	require.NoError(t, store.SaveWorkspace(date, external))
+
+	undoDepth := len(c.undoStack)
+	require.NoError(t, c.ReloadWorkspace())
+	assert.Equal(t, []string{"external edit", "added"}, cardTexts(c))
+	assert.Len(t, c.undoStack, undoDepth+1, "A reload is one step")
+	assert.False(t, c.IsDirty(), "The canvas matches the store")
+	assert.Equal(t, float32(2), c.Scale, "The view is kept")
+
+	require.True(t, c.Undo())
+	assert.Equal(t, []string{"ab"}, cardTexts(c), "Undo goes back to before the reload")
+	require.True(t, c.Undo())
+	assert.Equal(t, []string{"a"}, cardTexts(c))
+	require.True(t, c.Redo())
+	require.True(t, c.Redo())
+	assert.Equal(t, []string{"external edit", "added"}, cardTexts(c), "The external edit stays in the history")
+
+	undoDepth = len(c.undoStack)
+	require.NoError(t, c.ReloadWorkspace())
+	assert.Len(t, c.undoStack, undoDepth, "Reloading an unchanged day adds no step")
+}
+
+func TestReloadDisconnectedStrokeUndoes(t *testing.T) {
+	store := storage.NewMemoryStore()
+	date := time.Date(2099, 3, 4, 0, 0, 0, 0, time.UTC)
+	c := NewMosugoCanvas()
+	c.SetStore(store)
+	c.SetCurrentDate(date)
+	c.nextStrokeID = 10
+
+	// Another program writes one stroke ID in two pieces
+	require.NoError(t, store.SaveWorkspace(date, storage.WorkspaceState{Scale: 1.0, Strokes: []storage.StrokeData{
+		{P1X: 0, P1Y: 0, P2X: 10, P2Y: 0, StrokeID: 7},
+		{P1X: 100, P1Y: 100, P2X: 110, P2Y: 100, StrokeID: 7},
+	}}))
+	require.NoError(t, c.ReloadWorkspace())
+	require.Len(t, c.strokeByID, 2, "Each piece is a stroke of its own")
+
+	for i := 0; i < 3; i++ {
+		require.True(t, c.Undo())
+		assert.Empty(t, c.strokeByID, "Undo removes every piece")
+		require.True(t, c.Redo())
+		assert.Len(t, c.strokeByID, 2, "Redo does not add more pieces")
+	}
+}
+
+// sharingStore hands out the same workspace on every load, as a store that
+// does not copy might
+type sharingStore struct {
+	*storage.MemoryStore
+	state storage.WorkspaceState
+}
+
+func (s *sharingStore) LoadWorkspace(time.Time) (storage.WorkspaceState, error) {
+	return s.state, nil
+}
+
+func TestReloadWorkspaceOwnsMergeBase(t *testing.T) {
+	store := &sharingStore{MemoryStore: storage.NewMemoryStore(), state: storage.WorkspaceState{
+		Scale: 1.0,
+		Cards: []storage.MosuData{{ID: "card_1", Content: "disk", Width: 120, Height: 90}},
+	}}
+	c := NewMosugoCanvas()
+	c.SetStore(store)
+	c.SetCurrentDate(time.Date(2099, 3, 5, 0, 0, 0, 0, time.UTC))
+
+	require.NoError(t, c.ReloadWorkspace())
+	store.state.Cards[0].Content = "changed under it"
+	assert.Equal(t, "disk", c.saved.Cards[0].Content, "The merge base is the canvas's own copy")
+}
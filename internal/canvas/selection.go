@@ -0,0 +1,316 @@
+package canvas
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/F4tal1t/Mosugo/internal/cards"
+	"github.com/F4tal1t/Mosugo/internal/strokes"
+	"github.com/F4tal1t/Mosugo/internal/theme"
+	"github.com/F4tal1t/Mosugo/internal/tools"
+)
+
+// Selection returns the selected cards and strokes in stacking order.
+func (c *MosugoCanvas) Selection() []fyne.CanvasObject {
+	objs := []fyne.CanvasObject{}
+	if len(c.selected) == 0 {
+		return objs
+	}
+	for _, obj := range c.Content.Objects {
+		if c.selected[obj] {
+			objs = append(objs, obj)
+		}
+	}
+	return objs
+}
+
+// IsSelected reports whether a card or stroke is part of the selection.
+func (c *MosugoCanvas) IsSelected(obj fyne.CanvasObject) bool {
+	return c.selected[obj]
+}
+
+// SetSelection replaces the selection. Calling it with no objects clears it.
+func (c *MosugoCanvas) SetSelection(objs ...fyne.CanvasObject) {
+	c.selected = make(map[fyne.CanvasObject]bool)
+	c.AddToSelection(objs...)
+}
+
+// AddToSelection adds cards and strokes to the selection.
+func (c *MosugoCanvas) AddToSelection(objs ...fyne.CanvasObject) {
+	if c.selected == nil {
+		c.selected = make(map[fyne.CanvasObject]bool)
+	}
+	for _, obj := range objs {
+		if isSelectable(obj) {
+			c.selected[obj] = true
+		}
+	}
+	c.syncSelection()
+}
+
+// ToggleSelected adds an object to the selection, or removes it if already selected.
+func (c *MosugoCanvas) ToggleSelected(obj fyne.CanvasObject) {
+	if c.selected[obj] {
+		delete(c.selected, obj)
+		c.syncSelection()
+		return
+	}
+	c.AddToSelection(obj)
+}
+
+// GetSelectedCard returns the selected card when it is the only selected object.
+func (c *MosugoCanvas) GetSelectedCard() *cards.MosuWidget {
+	if len(c.selected) != 1 {
+		return nil
+	}
+	for obj := range c.selected {
+		if card, ok := obj.(*cards.MosuWidget); ok {
+			return card
+		}
+	}
+	return nil
+}
+
+// SetSelectedCard selects only the given card, or clears the selection for nil.
+func (c *MosugoCanvas) SetSelectedCard(card *cards.MosuWidget) {
+	if card == nil {
+		c.SetSelection()
+		return
+	}
+	c.SetSelection(card)
+}
+
+// ShiftHeld reports whether Shift was held when the last mouse button went down.
+func (c *MosugoCanvas) ShiftHeld() bool {
+	return c.modifiers&fyne.KeyModifierShift != 0
+}
+
+// SelectionToolActive reports whether the active tool works on the
+// selection, which is when copy, paste and duplicate apply to it.
+func (c *MosugoCanvas) SelectionToolActive() bool {
+	return c.CurrentTool == tools.ToolSelect || c.CurrentTool == tools.ToolLasso
+}
+
+func isSelectable(obj fyne.CanvasObject) bool {
+	switch obj.(type) {
+	case *cards.MosuWidget, *strokes.Stroke:
+		return true
+	}
+	return false
+}
+
+// syncSelection updates the selection highlight of every card and stroke.
+// Resize handles only show while a single card is selected with the Select tool.
+func (c *MosugoCanvas) syncSelection() {
+	single := c.GetSelectedCard()
+	if c.CurrentTool != tools.ToolSelect {
+		single = nil
+	}
+	for _, obj := range c.Content.Objects {
+		switch o := obj.(type) {
+		case *cards.MosuWidget:
+			o.SetSelected(c.selected[o])
+			o.ShowHandles(o == single)
+		case *strokes.Stroke:
+			o.SetSelected(c.selected[o])
+		}
+	}
+	c.refreshIfReady()
+}
+
+// selectionBounds returns the world rectangle around the selection.
+func (c *MosugoCanvas) selectionBounds() (worldRect, bool) {
+	var bounds worldRect
+	found := false
+	for _, obj := range c.Selection() {
+		var r worldRect
+		switch o := obj.(type) {
+		case *cards.MosuWidget:
+			r = rectFromPosSize(o.WorldPos, o.WorldSize)
+		case *strokes.Stroke:
+			r = rectFromPoints(o.Bounds())
+		}
+		if !found {
+			bounds, found = r, true
+		} else {
+			bounds = bounds.union(r)
+		}
+	}
+	return bounds, found
+}
+
+// --- Group Operations ---
+
+// MoveSelection moves every selected object by delta world units.
+// It records nothing; CommitSelectionMoved does once the move is done.
+func (c *MosugoCanvas) MoveSelection(delta fyne.Position) {
+	for _, obj := range c.Selection() {
+		switch o := obj.(type) {
+		case *cards.MosuWidget:
+			o.WorldPos = o.WorldPos.Add(delta)
+			c.UpdateCardBounds(o)
+		case *strokes.Stroke:
+			o.Translate(delta)
+			c.UpdateStrokeBounds(o)
+		}
+	}
+}
+
+// CommitSelectionMoved records a completed move of the selection by delta as one step.
+func (c *MosugoCanvas) CommitSelectionMoved(delta fyne.Position) {
+	if delta.IsZero() {
+		return
+	}
+	cmds := []historyCommand{}
+	for _, obj := range c.Selection() {
+		switch o := obj.(type) {
+		case *cards.MosuWidget:
+			cmds = append(cmds, cardMoveCommand{cardID: o.ID, before: o.WorldPos.Subtract(delta), after: o.WorldPos})
+		case *strokes.Stroke:
+			cmds = append(cmds, strokeMoveCommand{strokeID: o.ID, delta: delta})
+		}
+	}
+	c.commitCompound(cmds)
+}
+
+// DeleteSelection removes every selected object as one step.
+// It returns false when nothing is selected.
+func (c *MosugoCanvas) DeleteSelection() bool {
+	selection := c.Selection()
+	if len(selection) == 0 {
+		return false
+	}
+	// Removed objects need no highlight update
+	c.selected = nil
+
+	cmds := []historyCommand{}
+	for _, obj := range selection {
+		switch o := obj.(type) {
+		case *cards.MosuWidget:
+			cmds = append(cmds, cardDeleteCommand{data: c.CollectCardData(o)})
+		case *strokes.Stroke:
+			cmds = append(cmds, strokeDeleteCommand{segments: c.CollectStrokeDataByID(o.ID)})
+		}
+		c.RemoveObject(obj)
+	}
+	c.commitCompound(cmds)
+	c.refreshIfReady()
+	return true
+}
+
+// DuplicateSelection copies the selection one grid cell down and to the
+// right as one step, and selects the copies. It returns false when nothing
+// is selected.
+func (c *MosugoCanvas) DuplicateSelection() bool {
+	selection := c.Selection()
+	if len(selection) == 0 {
+		return false
+	}
+	cardData, strokeData := c.selectionData()
+	c.insertCopies(cardData, strokeData, fyne.NewPos(GridSize, GridSize))
+	return true
+}
+
+// GenerateCardID returns a card ID no card on the canvas uses yet.
+func (c *MosugoCanvas) GenerateCardID() string {
+	for n := len(c.Content.Objects); ; n++ {
+		id := fmt.Sprintf("card_%d", n)
+		if c.findCardByID(id) == nil {
+			return id
+		}
+	}
+}
+
+// --- Colors ---
+
+// SetCardColor switches a card to entry colorIdx of the card palette. When
+// the card is selected, every selected card changes with it, as one step.
+func (c *MosugoCanvas) SetCardColor(card *cards.MosuWidget, colorIdx int) {
+	if card == nil {
+		return
+	}
+	targets := []*cards.MosuWidget{card}
+	if c.selected[card] {
+		targets = targets[:0]
+		for _, obj := range c.Selection() {
+			if selectedCard, ok := obj.(*cards.MosuWidget); ok {
+				targets = append(targets, selectedCard)
+			}
+		}
+	}
+
+	cmds := []historyCommand{}
+	for _, target := range targets {
+		if target.ColorIndex == colorIdx {
+			continue
+		}
+		cmds = append(cmds, cardColorCommand{cardID: target.ID, before: target.ColorIndex, after: colorIdx})
+		target.SetColorIndex(colorIdx)
+	}
+	c.commitCompound(cmds)
+	c.refreshIfReady()
+}
+
+// CycleSelectionColor moves every selected card and stroke on to the next
+// color of its palette, as one step. It returns false when nothing is selected.
+func (c *MosugoCanvas) CycleSelectionColor() bool {
+	selection := c.Selection()
+	if len(selection) == 0 {
+		return false
+	}
+	cmds := []historyCommand{}
+	for _, obj := range selection {
+		switch o := obj.(type) {
+		case *cards.MosuWidget:
+			next := nextColorIndex(o.ColorIndex, len(theme.CardPalette))
+			cmds = append(cmds, cardColorCommand{cardID: o.ID, before: o.ColorIndex, after: next})
+			o.SetColorIndex(next)
+		case *strokes.Stroke:
+			next := nextColorIndex(o.ColorIndex, len(theme.StrokePalette))
+			cmds = append(cmds, strokeColorCommand{strokeID: o.ID, before: o.ColorIndex, after: next})
+			c.setStrokeColor(o, next)
+		}
+	}
+	c.commitCompound(cmds)
+	c.refreshIfReady()
+	return true
+}
+
+func nextColorIndex(i, paletteLen int) int {
+	if i < 0 || i+1 >= paletteLen {
+		return 0
+	}
+	return i + 1
+}
+
+// setStrokeColor switches a stroke to entry colorIdx of the stroke palette.
+func (c *MosugoCanvas) setStrokeColor(stroke *strokes.Stroke, colorIdx int) {
+	stroke.ColorIndex = colorIdx
+	stroke.Color = theme.StrokeColor(colorIdx)
+	stroke.Invalidate()
+}
+
+// showCardColorMenu pops up the card palette at pos, in absolute window coordinates.
+func (c *MosugoCanvas) showCardColorMenu(card *cards.MosuWidget, pos fyne.Position) {
+	app := fyne.CurrentApp()
+	if app == nil || app.Driver() == nil {
+		return
+	}
+	cnv := app.Driver().CanvasForObject(c)
+	if cnv == nil {
+		return
+	}
+
+	items := make([]*fyne.MenuItem, 0, len(theme.CardColorNames))
+	for i, name := range theme.CardColorNames {
+		colorIdx := i
+		item := fyne.NewMenuItem(name, func() {
+			c.SetCardColor(card, colorIdx)
+		})
+		item.Checked = card.ColorIndex == colorIdx
+		items = append(items, item)
+	}
+	widget.ShowPopUpMenuAtPosition(fyne.NewMenu("", items...), cnv, pos)
+}
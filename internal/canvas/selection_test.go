@@ -0,0 +1,168 @@
+package canvas
+
+import (
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/F4tal1t/Mosugo/internal/cards"
+	"github.com/F4tal1t/Mosugo/internal/storage"
+	"github.com/F4tal1t/Mosugo/internal/strokes"
+)
+
+// newSelectionFixture returns a canvas holding two cards and one stroke.
+func newSelectionFixture(t *testing.T) (*MosugoCanvas, *cards.MosuWidget, *cards.MosuWidget, *strokes.Stroke) {
+	t.Helper()
+	c := NewMosugoCanvas()
+	a := c.addCardFromData(storage.MosuData{ID: "card-a", PosX: 30, PosY: 30, Width: 120, Height: 90})
+	b := c.addCardFromData(storage.MosuData{ID: "card-b", PosX: 300, PosY: 30, Width: 120, Height: 90})
+
+	strokeID := c.GenerateStrokeID()
+	c.AddStroke(fyne.NewPos(0, 200), fyne.NewPos(100, 260), strokeID)
+	stroke := c.StrokeByID(strokeID)
+	require.NotNil(t, stroke)
+	return c, a, b, stroke
+}
+
+func TestSelectionSetOperations(t *testing.T) {
+	c, a, b, stroke := newSelectionFixture(t)
+
+	c.SetSelection(a)
+	assert.Same(t, a, c.GetSelectedCard())
+
+	c.ToggleSelected(stroke)
+	c.AddToSelection(b)
+	assert.Equal(t, []fyne.CanvasObject{a, b, stroke}, c.Selection(), "Selection follows stacking order")
+	assert.Nil(t, c.GetSelectedCard(), "No single card with several objects selected")
+
+	c.ToggleSelected(a)
+	assert.False(t, c.IsSelected(a))
+	assert.True(t, c.IsSelected(stroke))
+
+	c.RemoveObject(stroke)
+	assert.Equal(t, []fyne.CanvasObject{b}, c.Selection(), "Removed objects leave the selection")
+
+	c.SetSelection()
+	assert.Empty(t, c.Selection())
+}
+
+func TestSelectionMoveIsOneStep(t *testing.T) {
+	c, a, b, stroke := newSelectionFixture(t)
+	c.SetSelection(a, stroke)
+
+	// The Select tool moves in several steps and commits the total once
+	c.MoveSelection(fyne.NewPos(30, 0))
+	c.MoveSelection(fyne.NewPos(30, 60))
+	c.CommitSelectionMoved(fyne.NewPos(60, 60))
+	require.Len(t, c.undoStack, 1)
+
+	assert.Equal(t, fyne.NewPos(90, 90), a.WorldPos)
+	assert.Equal(t, fyne.NewPos(300, 30), b.WorldPos, "Unselected card stays put")
+	assert.Equal(t, fyne.NewPos(60, 260), stroke.Points[0])
+
+	require.True(t, c.Undo())
+	assert.Equal(t, fyne.NewPos(30, 30), a.WorldPos)
+	assert.Equal(t, fyne.NewPos(0, 200), stroke.Points[0])
+
+	require.True(t, c.Redo())
+	assert.Equal(t, fyne.NewPos(90, 90), a.WorldPos)
+	assert.Equal(t, fyne.NewPos(160, 320), stroke.Points[1])
+}
+
+func TestDeleteSelectionIsOneStep(t *testing.T) {
+	c, a, b, stroke := newSelectionFixture(t)
+	assert.False(t, c.DeleteSelection(), "Nothing selected")
+
+	c.SetSelection(a, stroke)
+	require.True(t, c.DeleteSelection())
+	require.Len(t, c.undoStack, 1)
+	assert.Empty(t, c.Selection())
+	assert.Nil(t, c.findCardByID("card-a"))
+	assert.Nil(t, c.StrokeByID(stroke.ID))
+	assert.NotNil(t, c.findCardByID(b.ID))
+
+	require.True(t, c.Undo())
+	assert.NotNil(t, c.findCardByID("card-a"))
+	assert.NotNil(t, c.StrokeByID(stroke.ID))
+}
+
+func TestDuplicateSelectionIsOneStep(t *testing.T) {
+	c, a, _, stroke := newSelectionFixture(t)
+	c.SetSelection(a, stroke)
+
+	require.True(t, c.DuplicateSelection())
+	require.Len(t, c.undoStack, 1)
+
+	copies := c.Selection()
+	require.Len(t, copies, 2, "The copies become the selection")
+	cardCopy, ok := copies[0].(*cards.MosuWidget)
+	require.True(t, ok)
+	assert.NotEqual(t, a.ID, cardCopy.ID)
+	assert.Equal(t, fyne.NewPos(60, 60), cardCopy.WorldPos)
+
+	strokeCopy, ok := copies[1].(*strokes.Stroke)
+	require.True(t, ok)
+	assert.NotEqual(t, stroke.ID, strokeCopy.ID)
+	assert.Equal(t, fyne.NewPos(30, 230), strokeCopy.Points[0])
+
+	require.True(t, c.Undo())
+	assert.Nil(t, c.findCardByID(cardCopy.ID))
+	assert.Nil(t, c.StrokeByID(strokeCopy.ID))
+}
+
+func TestRecolorSelectionIsOneStep(t *testing.T) {
+	c, a, b, stroke := newSelectionFixture(t)
+	c.SetSelection(a, b, stroke)
+
+	require.True(t, c.CycleSelectionColor())
+	require.Len(t, c.undoStack, 1)
+	assert.Equal(t, 1, a.ColorIndex)
+	assert.Equal(t, 1, b.ColorIndex)
+	assert.Equal(t, 1, stroke.ColorIndex)
+
+	// Picking a color for one selected card recolors every selected card
+	c.SetCardColor(a, 3)
+	require.Len(t, c.undoStack, 2)
+	assert.Equal(t, 3, b.ColorIndex)
+
+	require.True(t, c.Undo())
+	require.True(t, c.Undo())
+	assert.Equal(t, 0, a.ColorIndex)
+	assert.Equal(t, 0, b.ColorIndex)
+	assert.Equal(t, 0, stroke.ColorIndex)
+}
+
+func TestSelectionBoundsCoverWholeSelection(t *testing.T) {
+	c, a, b, stroke := newSelectionFixture(t)
+
+	_, ok := c.selectionBounds()
+	assert.False(t, ok, "Nothing selected")
+
+	c.SetSelection(a, b)
+	bounds, ok := c.selectionBounds()
+	require.True(t, ok)
+	assert.Equal(t, worldRect{Min: fyne.NewPos(30, 30), Max: fyne.NewPos(420, 120)}, bounds)
+
+	// Stroke bounds include the pen width
+	c.AddToSelection(stroke)
+	bounds, _ = c.selectionBounds()
+	assert.Equal(t, bounds.union(rectFromPoints(stroke.Bounds())), bounds)
+	assert.LessOrEqual(t, bounds.Min.X, float32(0))
+	assert.GreaterOrEqual(t, bounds.Max.Y, float32(260))
+}
+
+func TestGenerateCardIDSkipsIDsInUse(t *testing.T) {
+	c := NewMosugoCanvas()
+	first := c.GenerateCardID()
+	c.addCardFromData(storage.MosuData{ID: first, Width: 120, Height: 90})
+	second := c.GenerateCardID()
+	c.addCardFromData(storage.MosuData{ID: second, Width: 120, Height: 90})
+
+	// Deleting the first card makes the count match the second card's ID again
+	c.removeCardByID(first)
+	third := c.GenerateCardID()
+	assert.NotEqual(t, second, third)
+	assert.Nil(t, c.findCardByID(third))
+}
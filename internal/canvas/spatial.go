@@ -0,0 +1,368 @@
+package canvas
+
+import (
+	"math"
+	"sort"
+
+	"fyne.io/fyne/v2"
+
+	"github.com/F4tal1t/Mosugo/internal/cards"
+	"github.com/F4tal1t/Mosugo/internal/strokes"
+)
+
+const (
+	// quadNodeCapacity is how many items a node holds before it splits.
+	quadNodeCapacity = 16
+	// quadMinNodeSize stops subdividing once a node is this small in world units.
+	quadMinNodeSize = 32
+	// quadInitialSize is the side length of the first root node.
+	quadInitialSize = 1024
+	// quadWorldLimit bounds indexed coordinates, so the root never has to
+	// grow past what a float32 can double
+	quadWorldLimit = 1 << 30
+	// quadMaxGrowth caps how many times one insert may grow the root
+	quadMaxGrowth = 64
+)
+
+func (r worldRect) intersects(o worldRect) bool {
+	return r.Min.X <= o.Max.X && r.Max.X >= o.Min.X &&
+		r.Min.Y <= o.Max.Y && r.Max.Y >= o.Min.Y
+}
+
+func (r worldRect) contains(o worldRect) bool {
+	return o.Min.X >= r.Min.X && o.Max.X <= r.Max.X &&
+		o.Min.Y >= r.Min.Y && o.Max.Y <= r.Max.Y
+}
+
+// indexable returns the rectangle with every coordinate a finite number
+// within quadWorldLimit. NaN counts as 0, and infinities as the limit.
+func (r worldRect) indexable() worldRect {
+	clamp := func(v float32) float32 {
+		switch {
+		case math.IsNaN(float64(v)):
+			return 0
+		case v > quadWorldLimit:
+			return quadWorldLimit
+		case v < -quadWorldLimit:
+			return -quadWorldLimit
+		}
+		return v
+	}
+	minX, maxX := clamp(r.Min.X), clamp(r.Max.X)
+	minY, maxY := clamp(r.Min.Y), clamp(r.Max.Y)
+	return worldRect{
+		Min: fyne.NewPos(min(minX, maxX), min(minY, maxY)),
+		Max: fyne.NewPos(max(minX, maxX), max(minY, maxY)),
+	}
+}
+
+// expand grows the rectangle by margin on every side.
+func (r worldRect) expand(margin float32) worldRect {
+	return worldRect{
+		Min: fyne.NewPos(r.Min.X-margin, r.Min.Y-margin),
+		Max: fyne.NewPos(r.Max.X+margin, r.Max.Y+margin),
+	}
+}
+
+type spatialEntry struct {
+	obj  fyne.CanvasObject
+	rect worldRect
+	seq  int // insertion order, higher is drawn on top
+	node *quadNode
+}
+
+type quadNode struct {
+	bounds   worldRect
+	entries  []*spatialEntry
+	children *[4]*quadNode
+}
+
+// spatialIndex is a world-space quadtree over canvas objects.
+// The root grows outwards on demand, so the infinite canvas has no fixed extent.
+// Items that straddle a quadrant boundary stay in the parent node.
+type spatialIndex struct {
+	root    *quadNode
+	entries map[fyne.CanvasObject]*spatialEntry
+	nextSeq int
+}
+
+func newSpatialIndex() *spatialIndex {
+	return &spatialIndex{entries: make(map[fyne.CanvasObject]*spatialEntry)}
+}
+
+// Len returns the number of indexed objects.
+func (s *spatialIndex) Len() int {
+	return len(s.entries)
+}
+
+// Bounds returns the indexed world rectangle for an object.
+func (s *spatialIndex) Bounds(obj fyne.CanvasObject) (worldRect, bool) {
+	e, ok := s.entries[obj]
+	if !ok {
+		return worldRect{}, false
+	}
+	return e.rect, true
+}
+
+// Insert adds an object, or moves it if it is already indexed.
+// Moving keeps the original stacking order. Bounds that are not finite, or
+// lie beyond quadWorldLimit, are clamped, as damaged or merged files can
+// hold any number.
+func (s *spatialIndex) Insert(obj fyne.CanvasObject, rect worldRect) {
+	rect = rect.indexable()
+	if e, ok := s.entries[obj]; ok {
+		if e.rect == rect {
+			return
+		}
+		e.node.remove(e)
+		e.rect = rect
+		s.place(e)
+		return
+	}
+
+	s.nextSeq++
+	e := &spatialEntry{obj: obj, rect: rect, seq: s.nextSeq}
+	s.entries[obj] = e
+	s.place(e)
+}
+
+// Remove drops an object from the index. Unknown objects are ignored.
+func (s *spatialIndex) Remove(obj fyne.CanvasObject) {
+	e, ok := s.entries[obj]
+	if !ok {
+		return
+	}
+	e.node.remove(e)
+	delete(s.entries, obj)
+}
+
+// Query returns every object whose bounds intersect rect, top-most first.
+func (s *spatialIndex) Query(rect worldRect) []fyne.CanvasObject {
+	if s.root == nil {
+		return nil
+	}
+	var found []*spatialEntry
+	s.root.query(rect, &found)
+	sort.Slice(found, func(i, j int) bool {
+		return found[i].seq > found[j].seq
+	})
+
+	objs := make([]fyne.CanvasObject, len(found))
+	for i, e := range found {
+		objs[i] = e.obj
+	}
+	return objs
+}
+
+func (s *spatialIndex) place(e *spatialEntry) {
+	if s.root == nil {
+		side := float32(quadInitialSize)
+		for side < 2*(e.rect.Max.X-e.rect.Min.X) || side < 2*(e.rect.Max.Y-e.rect.Min.Y) {
+			side *= 2
+		}
+		center := e.rect.center()
+		origin := fyne.NewPos(center.X-side/2, center.Y-side/2)
+		s.root = &quadNode{bounds: worldRect{Min: origin, Max: origin.Add(fyne.NewPos(side, side))}}
+	}
+	for i := 0; i < quadMaxGrowth && !s.root.bounds.contains(e.rect); i++ {
+		s.grow(e.rect)
+	}
+	s.root.insert(e)
+}
+
+// grow doubles the root towards rect, keeping the old root as one quadrant.
+func (s *spatialIndex) grow(rect worldRect) {
+	old := s.root
+	size := old.bounds.Max.X - old.bounds.Min.X
+
+	origin := old.bounds.Min
+	quadrant := 0
+	if rect.Min.X < old.bounds.Min.X {
+		origin.X -= size
+		quadrant |= 1
+	}
+	if rect.Min.Y < old.bounds.Min.Y {
+		origin.Y -= size
+		quadrant |= 2
+	}
+
+	root := &quadNode{bounds: worldRect{Min: origin, Max: origin.Add(fyne.NewPos(size*2, size*2))}}
+	root.children = &[4]*quadNode{}
+	root.children[quadrant] = old
+	s.root = root
+}
+
+func (n *quadNode) childBounds(i int) worldRect {
+	half := (n.bounds.Max.X - n.bounds.Min.X) / 2
+	origin := n.bounds.Min
+	if i&1 != 0 {
+		origin.X += half
+	}
+	if i&2 != 0 {
+		origin.Y += half
+	}
+	return worldRect{Min: origin, Max: origin.Add(fyne.NewPos(half, half))}
+}
+
+// childFor returns the quadrant that fully contains rect, or -1.
+func (n *quadNode) childFor(rect worldRect) int {
+	if n.bounds.Max.X-n.bounds.Min.X <= quadMinNodeSize {
+		return -1
+	}
+	for i := 0; i < 4; i++ {
+		if n.childBounds(i).contains(rect) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (n *quadNode) insert(e *spatialEntry) {
+	if n.children != nil {
+		if i := n.childFor(e.rect); i >= 0 {
+			n.child(i).insert(e)
+			return
+		}
+	}
+
+	e.node = n
+	n.entries = append(n.entries, e)
+
+	if n.children == nil && len(n.entries) > quadNodeCapacity {
+		n.split()
+	}
+}
+
+func (n *quadNode) child(i int) *quadNode {
+	if n.children[i] == nil {
+		n.children[i] = &quadNode{bounds: n.childBounds(i)}
+	}
+	return n.children[i]
+}
+
+func (n *quadNode) split() {
+	if n.bounds.Max.X-n.bounds.Min.X <= quadMinNodeSize {
+		return
+	}
+	n.children = &[4]*quadNode{}
+
+	kept := n.entries[:0]
+	for _, e := range n.entries {
+		if i := n.childFor(e.rect); i >= 0 {
+			n.child(i).insert(e)
+			continue
+		}
+		kept = append(kept, e)
+	}
+	for i := len(kept); i < len(n.entries); i++ {
+		n.entries[i] = nil
+	}
+	n.entries = kept
+}
+
+func (n *quadNode) remove(e *spatialEntry) {
+	for i, candidate := range n.entries {
+		if candidate == e {
+			last := len(n.entries) - 1
+			n.entries[i] = n.entries[last]
+			n.entries[last] = nil
+			n.entries = n.entries[:last]
+			return
+		}
+	}
+}
+
+func (n *quadNode) query(rect worldRect, found *[]*spatialEntry) {
+	if !n.bounds.intersects(rect) {
+		return
+	}
+	for _, e := range n.entries {
+		if e.rect.intersects(rect) {
+			*found = append(*found, e)
+		}
+	}
+	if n.children == nil {
+		return
+	}
+	for _, child := range n.children {
+		if child != nil {
+			child.query(rect, found)
+		}
+	}
+}
+
+// ------------------------------------------------------------------
+// Canvas queries backed by the index
+// ------------------------------------------------------------------
+
+func (c *MosugoCanvas) objectIndex() *spatialIndex {
+	if c.index == nil {
+		c.index = newSpatialIndex()
+	}
+	return c.index
+}
+
+// UpdateCardBounds re-indexes a card after its WorldPos or WorldSize changed.
+func (c *MosugoCanvas) UpdateCardBounds(card *cards.MosuWidget) {
+	if card == nil {
+		return
+	}
+	c.indexObject(card, rectFromPosSize(card.WorldPos, card.WorldSize))
+}
+
+// indexObject stores an object's world bounds and queues it for the next
+// culling pass, which decides whether it is shown.
+func (c *MosugoCanvas) indexObject(obj fyne.CanvasObject, rect worldRect) {
+	c.objectIndex().Insert(obj, rect)
+	if c.cullPending == nil {
+		c.cullPending = make(map[fyne.CanvasObject]bool)
+	}
+	c.cullPending[obj] = true
+}
+
+// ObjectsInRect returns the cards and strokes intersecting a world-space
+// rectangle, top-most first.
+func (c *MosugoCanvas) ObjectsInRect(min, max fyne.Position) []fyne.CanvasObject {
+	return c.objectIndex().Query(rectFromPoints(min, max))
+}
+
+// CardAt returns the top-most card under a screen position, or nil.
+func (c *MosugoCanvas) CardAt(screenPos fyne.Position) *cards.MosuWidget {
+	world := c.ScreenToWorld(screenPos)
+	for _, obj := range c.objectIndex().Query(worldRect{Min: world, Max: world}) {
+		if card, ok := obj.(*cards.MosuWidget); ok {
+			return card
+		}
+	}
+	return nil
+}
+
+// UpdateStrokeBounds re-indexes a stroke after its points or width changed.
+func (c *MosugoCanvas) UpdateStrokeBounds(stroke *strokes.Stroke) {
+	if stroke == nil {
+		return
+	}
+	stroke.Invalidate()
+	c.indexObject(stroke, rectFromPoints(stroke.Bounds()))
+}
+
+// StrokeAt returns the top-most stroke passing within tolerance screen pixels
+// of a screen position, or nil.
+func (c *MosugoCanvas) StrokeAt(screenPos fyne.Position, tolerance float32) *strokes.Stroke {
+	world := c.ScreenToWorld(screenPos)
+	worldTolerance := tolerance / c.Scale
+	area := worldRect{Min: world, Max: world}.expand(worldTolerance)
+
+	for _, obj := range c.objectIndex().Query(area) {
+		stroke, ok := obj.(*strokes.Stroke)
+		if !ok {
+			continue
+		}
+		for _, segment := range stroke.Segments() {
+			if perpendicularDistance(world, segment[0], segment[1]) <= worldTolerance {
+				return stroke
+			}
+		}
+	}
+	return nil
+}
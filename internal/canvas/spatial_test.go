@@ -0,0 +1,282 @@
+package canvas
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/F4tal1t/Mosugo/internal/cards"
+	"github.com/F4tal1t/Mosugo/internal/strokes"
+	"github.com/F4tal1t/Mosugo/internal/theme"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func rectAt(x, y, w, h float32) worldRect {
+	return rectFromPosSize(fyne.NewPos(x, y), fyne.NewSize(w, h))
+}
+
+// TestSpatialIndexInsertQueryRemove tests the basic index lifecycle
+func TestSpatialIndexInsertQueryRemove(t *testing.T) {
+	idx := newSpatialIndex()
+	a := canvas.NewRectangle(theme.CardBg)
+	b := canvas.NewRectangle(theme.CardBg)
+
+	idx.Insert(a, rectAt(0, 0, 100, 100))
+	idx.Insert(b, rectAt(500, 500, 50, 50))
+	assert.Equal(t, 2, idx.Len())
+
+	assert.Equal(t, []fyne.CanvasObject{a}, idx.Query(rectAt(10, 10, 1, 1)))
+	assert.Equal(t, []fyne.CanvasObject{b}, idx.Query(rectAt(520, 520, 1, 1)))
+	assert.Empty(t, idx.Query(rectAt(200, 200, 10, 10)))
+
+	// Moving an object updates its bounds
+	idx.Insert(a, rectAt(1000, 1000, 10, 10))
+	assert.Empty(t, idx.Query(rectAt(10, 10, 1, 1)))
+	assert.Equal(t, []fyne.CanvasObject{a}, idx.Query(rectAt(1005, 1005, 1, 1)))
+	assert.Equal(t, 2, idx.Len())
+
+	idx.Remove(a)
+	idx.Remove(a) // unknown objects are ignored
+	assert.Empty(t, idx.Query(rectAt(1005, 1005, 1, 1)))
+	assert.Equal(t, 1, idx.Len())
+
+	_, ok := idx.Bounds(a)
+	assert.False(t, ok)
+	bounds, ok := idx.Bounds(b)
+	require.True(t, ok)
+	assert.Equal(t, rectAt(500, 500, 50, 50), bounds)
+}
+
+// TestSpatialIndexGrowsToFarCoordinates tests that the root expands in every direction
+func TestSpatialIndexGrowsToFarCoordinates(t *testing.T) {
+	idx := newSpatialIndex()
+	positions := []fyne.Position{
+		fyne.NewPos(0, 0),
+		fyne.NewPos(-50000, 20),
+		fyne.NewPos(30, -80000),
+		fyne.NewPos(120000, 90000),
+		fyne.NewPos(-1e6, -1e6),
+	}
+
+	objs := make([]fyne.CanvasObject, len(positions))
+	for i, pos := range positions {
+		objs[i] = canvas.NewRectangle(theme.CardBg)
+		idx.Insert(objs[i], rectFromPosSize(pos, fyne.NewSize(10, 10)))
+	}
+
+	for i, pos := range positions {
+		found := idx.Query(rectFromPosSize(pos.Add(fyne.NewPos(5, 5)), fyne.NewSize(0, 0)))
+		assert.Equal(t, []fyne.CanvasObject{objs[i]}, found, "Object at %v", pos)
+	}
+}
+
+// TestSpatialIndexClampsUnusableBounds tests that bounds no quadtree can hold are indexed without hanging
+func TestSpatialIndexClampsUnusableBounds(t *testing.T) {
+	nan := float32(math.NaN())
+	inf := float32(math.Inf(1))
+	tests := []struct {
+		name string
+		rect worldRect
+		want worldRect
+	}{
+		{"NaN", worldRect{Min: fyne.NewPos(nan, 10), Max: fyne.NewPos(20, nan)}, rectAt(0, 0, 20, 10)},
+		{"infinite", worldRect{Min: fyne.NewPos(-inf, 0), Max: fyne.NewPos(inf, 10)}, rectAt(-quadWorldLimit, 0, 2*quadWorldLimit, 10)},
+		{"too large to double", rectAt(3e38, -3e38, 1, 1), worldRect{Min: fyne.NewPos(quadWorldLimit, -quadWorldLimit), Max: fyne.NewPos(quadWorldLimit, -quadWorldLimit)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idx := newSpatialIndex()
+			near := canvas.NewRectangle(theme.CardBg)
+			idx.Insert(near, rectAt(-100, -100, 10, 10))
+
+			obj := canvas.NewRectangle(theme.CardBg)
+			idx.Insert(obj, tt.rect)
+			bounds, ok := idx.Bounds(obj)
+			require.True(t, ok)
+			assert.Equal(t, tt.want, bounds)
+			assert.Contains(t, idx.Query(tt.want), obj, "The clamped bounds can be found")
+			assert.Equal(t, []fyne.CanvasObject{near}, idx.Query(rectAt(-95, -95, 1, 1)), "The rest of the index still works")
+		})
+	}
+}
+
+// TestSpatialIndexStackingOrder tests that queries return the most recently added object first
+func TestSpatialIndexStackingOrder(t *testing.T) {
+	idx := newSpatialIndex()
+	bottom := canvas.NewRectangle(theme.CardBg)
+	top := canvas.NewRectangle(theme.CardBg)
+
+	idx.Insert(bottom, rectAt(0, 0, 100, 100))
+	idx.Insert(top, rectAt(50, 50, 100, 100))
+	assert.Equal(t, []fyne.CanvasObject{top, bottom}, idx.Query(rectAt(60, 60, 1, 1)))
+
+	// Moving keeps the original stacking order
+	idx.Insert(bottom, rectAt(40, 40, 100, 100))
+	assert.Equal(t, []fyne.CanvasObject{top, bottom}, idx.Query(rectAt(60, 60, 1, 1)))
+}
+
+// TestSpatialIndexMatchesLinearScan cross-checks queries against brute force
+func TestSpatialIndexMatchesLinearScan(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	idx := newSpatialIndex()
+	rects := map[fyne.CanvasObject]worldRect{}
+
+	randomRect := func() worldRect {
+		x := rng.Float32()*20000 - 10000
+		y := rng.Float32()*20000 - 10000
+		return rectAt(x, y, rng.Float32()*300, rng.Float32()*300)
+	}
+
+	for i := 0; i < 2000; i++ {
+		obj := canvas.NewRectangle(theme.CardBg)
+		r := randomRect()
+		rects[obj] = r
+		idx.Insert(obj, r)
+	}
+	// Move and remove a share of the objects
+	i := 0
+	for obj := range rects {
+		switch i % 3 {
+		case 0:
+			r := randomRect()
+			rects[obj] = r
+			idx.Insert(obj, r)
+		case 1:
+			delete(rects, obj)
+			idx.Remove(obj)
+		}
+		i++
+	}
+	require.Equal(t, len(rects), idx.Len())
+
+	for q := 0; q < 200; q++ {
+		area := randomRect().expand(rng.Float32() * 500)
+
+		expected := []fyne.CanvasObject{}
+		for obj, r := range rects {
+			if r.intersects(area) {
+				expected = append(expected, obj)
+			}
+		}
+		assert.ElementsMatch(t, expected, idx.Query(area))
+	}
+}
+
+// TestCardAtUsesWorldBounds tests card hit-testing through the camera transform
+func TestCardAtUsesWorldBounds(t *testing.T) {
+	c := NewMosugoCanvas()
+	c.Scale = 2.0
+	c.Offset = fyne.NewPos(100, 50)
+
+	below := cards.NewMosuWidget("below", theme.CardBg, 0)
+	below.WorldPos = fyne.NewPos(0, 0)
+	below.WorldSize = fyne.NewSize(120, 120)
+	c.AddObject(below)
+
+	above := cards.NewMosuWidget("above", theme.CardBg, 0)
+	above.WorldPos = fyne.NewPos(60, 60)
+	above.WorldSize = fyne.NewSize(120, 120)
+	c.AddObject(above)
+
+	assert.Same(t, below, c.CardAt(c.WorldToScreen(fyne.NewPos(30, 30))))
+	assert.Same(t, above, c.CardAt(c.WorldToScreen(fyne.NewPos(90, 90))), "Top-most card wins")
+	assert.Nil(t, c.CardAt(c.WorldToScreen(fyne.NewPos(500, 500))))
+
+	// Moved cards are found at their new position once re-indexed
+	above.WorldPos = fyne.NewPos(600, 600)
+	c.UpdateCardBounds(above)
+	assert.Same(t, below, c.CardAt(c.WorldToScreen(fyne.NewPos(90, 90))))
+	assert.Same(t, above, c.CardAt(c.WorldToScreen(fyne.NewPos(610, 610))))
+
+	c.RemoveObject(below)
+	assert.Nil(t, c.CardAt(c.WorldToScreen(fyne.NewPos(30, 30))))
+}
+
+// TestStrokeAtToleranceIsInScreenPixels tests that stroke hit-testing respects zoom
+func TestStrokeAtToleranceIsInScreenPixels(t *testing.T) {
+	c := NewMosugoCanvas()
+	c.Scale = 0.5
+	strokeID := c.GenerateStrokeID()
+	c.AddStroke(fyne.NewPos(0, 0), fyne.NewPos(200, 0), strokeID)
+	c.AddStroke(fyne.NewPos(200, 0), fyne.NewPos(200, 200), strokeID)
+
+	// 16 world units off the line is 8 screen pixels at scale 0.5
+	stroke := c.StrokeAt(c.WorldToScreen(fyne.NewPos(100, 16)), 10)
+	require.NotNil(t, stroke)
+	assert.Equal(t, strokeID, stroke.ID)
+	assert.Same(t, stroke, c.StrokeAt(c.WorldToScreen(fyne.NewPos(190, 150)), 10), "Any segment hits")
+
+	// 24 world units is 12 screen pixels, outside the tolerance
+	assert.Nil(t, c.StrokeAt(c.WorldToScreen(fyne.NewPos(100, 24)), 10))
+	// Inside the bounding box but away from every segment
+	assert.Nil(t, c.StrokeAt(c.WorldToScreen(fyne.NewPos(100, 100)), 10))
+	// Past the segment end the distance is measured to the endpoint
+	assert.Nil(t, c.StrokeAt(c.WorldToScreen(fyne.NewPos(-40, 0)), 10))
+}
+
+// TestUpdateStrokeBoundsReindexes tests hit-testing after a stroke's points change
+func TestUpdateStrokeBoundsReindexes(t *testing.T) {
+	c := NewMosugoCanvas()
+	strokeID := c.GenerateStrokeID()
+	c.AddStroke(fyne.NewPos(0, 0), fyne.NewPos(20, 0), strokeID)
+	stroke := c.StrokeByID(strokeID)
+
+	stroke.Points = []fyne.Position{fyne.NewPos(1000, 1000), fyne.NewPos(1020, 1000)}
+	c.UpdateStrokeBounds(stroke)
+
+	assert.Nil(t, c.StrokeAt(c.WorldToScreen(fyne.NewPos(10, 0)), 2))
+	assert.Same(t, stroke, c.StrokeAt(c.WorldToScreen(fyne.NewPos(1010, 1000)), 2))
+
+	c.removeStrokeByID(strokeID)
+	assert.Nil(t, c.StrokeAt(c.WorldToScreen(fyne.NewPos(1010, 1000)), 2))
+	assert.Zero(t, c.objectIndex().Len())
+}
+
+// newBenchCanvas scatters n short strokes over a large world area.
+func newBenchCanvas(n int) *MosugoCanvas {
+	rng := rand.New(rand.NewSource(42))
+	c := NewMosugoCanvas()
+	for i := 0; i < n; i++ {
+		p1 := fyne.NewPos(rng.Float32()*50000, rng.Float32()*50000)
+		p2 := p1.Add(fyne.NewPos(rng.Float32()*40-20, rng.Float32()*40-20))
+		c.AddStroke(p1, p2, c.GenerateStrokeID())
+	}
+	return c
+}
+
+// linearStrokeAt is the pre-index hit test: scan every stroke segment.
+func linearStrokeAt(c *MosugoCanvas, screenPos fyne.Position, tolerance float32) *strokes.Stroke {
+	world := c.ScreenToWorld(screenPos)
+	for _, stroke := range c.strokeByID {
+		for _, segment := range stroke.Segments() {
+			if perpendicularDistance(world, segment[0], segment[1]) <= tolerance/c.Scale {
+				return stroke
+			}
+		}
+	}
+	return nil
+}
+
+func BenchmarkStrokeAt(b *testing.B) {
+	for _, n := range []int{1000, 10000, 100000} {
+		c := newBenchCanvas(n)
+		rng := rand.New(rand.NewSource(7))
+
+		b.Run(fmt.Sprintf("index/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				c.StrokeAt(fyne.NewPos(rng.Float32()*50000, rng.Float32()*50000), 10)
+			}
+		})
+		b.Run(fmt.Sprintf("linear/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				linearStrokeAt(c, fyne.NewPos(rng.Float32()*50000, rng.Float32()*50000), 10)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package canvas
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/F4tal1t/Mosugo/internal/storage"
+)
+
+// HistoryItem is one step of the day's history as the timeline shows it.
+type HistoryItem struct {
+	Description string
+	At          time.Time // zero for steps saved before times were kept
+}
+
+// Timeline returns every step of the day's history, oldest first, and how
+// many of them are applied: the first pos steps can be undone, the rest
+// redone.
+func (c *MosugoCanvas) Timeline() (items []HistoryItem, pos int) {
+	items = make([]HistoryItem, 0, len(c.undoStack)+len(c.redoStack))
+	for _, step := range c.undoStack {
+		items = append(items, HistoryItem{Description: step.cmd.describe(), At: step.at})
+	}
+	// The redo stack has the next step to redo on top
+	for i := len(c.redoStack) - 1; i >= 0; i-- {
+		step := c.redoStack[i]
+		items = append(items, HistoryItem{Description: step.cmd.describe(), At: step.at})
+	}
+	return items, len(c.undoStack)
+}
+
+// TravelTo undoes or redoes steps until pos of them are applied, so any
+// point of the timeline can be previewed. Nothing is lost: every step stays
+// on the timeline. It returns false when pos is out of range or a
+// transaction is open.
+func (c *MosugoCanvas) TravelTo(pos int) bool {
+	if pos < 0 || pos > len(c.undoStack)+len(c.redoStack) || c.InTransaction() {
+		return false
+	}
+	if pos == len(c.undoStack) {
+		return true
+	}
+
+	c.traveling = true
+	for len(c.undoStack) > pos && c.Undo() {
+	}
+	for len(c.undoStack) < pos && c.Redo() {
+	}
+	c.traveling = false
+	c.historyChanged()
+	return true
+}
+
+// BranchAt travels to pos and drops the steps after it, so the day carries
+// on from that point.
+func (c *MosugoCanvas) BranchAt(pos int) bool {
+	if !c.TravelTo(pos) {
+		return false
+	}
+	if len(c.redoStack) > 0 {
+		c.redoStack = nil
+		c.textRun = nil
+		c.notifyDirty()
+		c.historyChanged()
+	}
+	return true
+}
+
+// SetOnHistoryChanged registers a callback for changes to the timeline.
+func (c *MosugoCanvas) SetOnHistoryChanged(callback func()) {
+	c.onHistory = callback
+}
+
+func (c *MosugoCanvas) historyChanged() {
+	if c.onHistory != nil && !c.traveling {
+		c.onHistory()
+	}
+}
+
+func (cmd compoundCommand) describe() string {
+	switch len(cmd) {
+	case 0:
+		return "Nothing"
+	case 1:
+		return cmd[0].describe()
+	case 2:
+		return cmd[0].describe() + ", " + cmd[1].describe()
+	}
+	return fmt.Sprintf("%s and %d more", cmd[0].describe(), len(cmd)-1)
+}
+
+func (cmd cardCreateCommand) describe() string { return "Created " + cmd.data.ID }
+func (cmd cardDeleteCommand) describe() string { return "Deleted " + cmd.data.ID }
+func (cmd cardMoveCommand) describe() string   { return "Moved " + cmd.cardID }
+func (cmd cardResizeCommand) describe() string { return "Resized " + cmd.cardID }
+func (cmd cardTextCommand) describe() string   { return "Edited " + cmd.cardID }
+func (cmd cardColorCommand) describe() string  { return "Recolored " + cmd.cardID }
+
+func (cmd strokeCreateCommand) describe() string {
+	return fmt.Sprintf("Drew stroke %d", segmentsStrokeID(cmd.segments))
+}
+
+func (cmd strokeDeleteCommand) describe() string {
+	return fmt.Sprintf("Erased stroke %d", segmentsStrokeID(cmd.segments))
+}
+
+func (cmd strokeMoveCommand) describe() string {
+	return fmt.Sprintf("Moved stroke %d", cmd.strokeID)
+}
+
+func (cmd strokeColorCommand) describe() string {
+	return fmt.Sprintf("Recolored stroke %d", cmd.strokeID)
+}
+
+func (cmd strokeShapeCommand) describe() string {
+	return fmt.Sprintf("Transformed stroke %d", cmd.strokeID)
+}
+
+func segmentsStrokeID(segments []storage.StrokeData) int {
+	if len(segments) == 0 {
+		return 0
+	}
+	return segments[0].StrokeID
+}
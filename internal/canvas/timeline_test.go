@@ -0,0 +1,114 @@
+package canvas
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/F4tal1t/Mosugo/internal/storage"
+)
+
+func TestTimelineDescribesSteps(t *testing.T) {
+	c := NewMosugoCanvas()
+	now := time.Date(2026, 3, 4, 9, 30, 0, 0, time.Local)
+	c.now = func() time.Time { return now }
+
+	stroke := addTestStroke(c, 0)
+	now = now.Add(time.Minute)
+	card := c.addCardFromData(storage.MosuData{ID: "card_3", Width: 120, Height: 90})
+	c.SetCardColor(card, 2)
+	now = now.Add(time.Minute)
+	c.CommitStrokeDeleted(c.CollectStrokeDataByID(stroke))
+
+	items, pos := c.Timeline()
+	require.Len(t, items, 3)
+	assert.Equal(t, 3, pos)
+	assert.Equal(t, "Drew stroke 1", items[0].Description)
+	assert.Equal(t, "Recolored card_3", items[1].Description)
+	assert.Equal(t, "Erased stroke 1", items[2].Description)
+	assert.Equal(t, now.Add(-2*time.Minute), items[0].At)
+	assert.Equal(t, now, items[2].At)
+
+	// Undone steps stay on the timeline, after the current point
+	require.True(t, c.Undo())
+	after, pos := c.Timeline()
+	assert.Equal(t, items, after)
+	assert.Equal(t, 2, pos)
+}
+
+func TestTravelToPreviewsAnyPoint(t *testing.T) {
+	c := NewMosugoCanvas()
+	first := addTestStroke(c, 0)
+	second := addTestStroke(c, 60)
+	third := addTestStroke(c, 120)
+
+	notified := 0
+	c.SetOnHistoryChanged(func() { notified++ })
+
+	require.True(t, c.TravelTo(1))
+	assert.Equal(t, 1, notified, "A jump notifies once, however many steps it takes")
+	assert.NotNil(t, c.StrokeByID(first))
+	assert.Nil(t, c.StrokeByID(second))
+	assert.Nil(t, c.StrokeByID(third))
+
+	require.True(t, c.TravelTo(3))
+	assert.NotNil(t, c.StrokeByID(third))
+	require.True(t, c.TravelTo(0))
+	assert.Nil(t, c.StrokeByID(first))
+
+	items, pos := c.Timeline()
+	assert.Len(t, items, 3, "Travelling loses no steps")
+	assert.Equal(t, 0, pos)
+
+	assert.False(t, c.TravelTo(4))
+	assert.False(t, c.TravelTo(-1))
+	c.BeginTransaction()
+	assert.False(t, c.TravelTo(2), "No travel in the middle of a gesture")
+	c.RollbackTransaction()
+}
+
+func TestBranchAtDropsLaterSteps(t *testing.T) {
+	c := NewMosugoCanvas()
+	first := addTestStroke(c, 0)
+	second := addTestStroke(c, 60)
+	addTestStroke(c, 120)
+
+	require.True(t, c.BranchAt(1))
+	items, pos := c.Timeline()
+	assert.Len(t, items, 1)
+	assert.Equal(t, 1, pos)
+	assert.NotNil(t, c.StrokeByID(first))
+	assert.Nil(t, c.StrokeByID(second))
+	assert.False(t, c.Redo())
+
+	addTestStroke(c, 180)
+	items, _ = c.Timeline()
+	assert.Len(t, items, 2, "New steps carry on from the branch point")
+}
+
+func TestStepTimesArePersisted(t *testing.T) {
+	c := NewMosugoCanvas()
+	now := time.Date(2026, 3, 4, 9, 30, 0, 0, time.UTC)
+	c.now = func() time.Time { return now }
+	addTestStroke(c, 0)
+	addTestStroke(c, 60)
+	require.True(t, c.Undo())
+
+	state := c.historyState()
+	restored := NewMosugoCanvas()
+	restored.restoreHistory(state)
+
+	items, pos := restored.Timeline()
+	require.Len(t, items, 2)
+	assert.Equal(t, 1, pos)
+	assert.True(t, now.Equal(items[0].At))
+	assert.True(t, now.Equal(items[1].At))
+
+	// Entries saved before step times were kept have none
+	state.Undo[0].At = nil
+	restored.restoreHistory(state)
+	items, _ = restored.Timeline()
+	assert.True(t, items[0].At.IsZero())
+}
@@ -0,0 +1,218 @@
+package canvas
+
+import (
+	"image/color"
+	"math"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+
+	"github.com/F4tal1t/Mosugo/internal/cards"
+	"github.com/F4tal1t/Mosugo/internal/strokes"
+	"github.com/F4tal1t/Mosugo/internal/theme"
+	"github.com/F4tal1t/Mosugo/internal/tools"
+)
+
+const (
+	// lassoWidth is the on-screen width of the lasso loop while it is drawn
+	lassoWidth float32 = 1.5
+	// transformHandleSize is the on-screen size of a transform box handle
+	transformHandleSize float32 = 10
+)
+
+// transformBox is the frame and handles drawn around the selection while the
+// Lasso tool is active. Handles follow tools.TransformHandlePositions.
+type transformBox struct {
+	frame   *canvas.Rectangle
+	stem    *canvas.Line
+	handles []fyne.CanvasObject
+}
+
+func newTransformBox() *transformBox {
+	b := &transformBox{
+		frame: canvas.NewRectangle(color.Transparent),
+		stem:  canvas.NewLine(theme.SelectionBlue),
+	}
+	b.frame.StrokeColor = theme.SelectionBlue
+	b.frame.StrokeWidth = 1.5
+
+	for i := 0; i < 4; i++ {
+		square := canvas.NewRectangle(theme.InkWhite)
+		square.StrokeColor = theme.SelectionBlue
+		square.StrokeWidth = 1.5
+		b.handles = append(b.handles, square)
+	}
+	knob := canvas.NewCircle(theme.InkWhite)
+	knob.StrokeColor = theme.SelectionBlue
+	knob.StrokeWidth = 1.5
+	b.handles = append(b.handles, knob)
+
+	b.hide()
+	return b
+}
+
+func (b *transformBox) objects() []fyne.CanvasObject {
+	return append([]fyne.CanvasObject{b.frame, b.stem}, b.handles...)
+}
+
+func (b *transformBox) hide() {
+	for _, obj := range b.objects() {
+		obj.Hide()
+	}
+}
+
+// layout frames the screen rectangle lo-hi.
+func (b *transformBox) layout(lo, hi fyne.Position) {
+	b.frame.Move(lo)
+	b.frame.Resize(fyne.NewSize(hi.X-lo.X, hi.Y-lo.Y))
+
+	positions := tools.TransformHandlePositions(lo, hi)
+	for i, handle := range b.handles {
+		handle.Resize(fyne.NewSize(transformHandleSize, transformHandleSize))
+		handle.Move(positions[i].SubtractXY(transformHandleSize/2, transformHandleSize/2))
+	}
+	b.stem.Position1 = fyne.NewPos((lo.X+hi.X)/2, lo.Y)
+	b.stem.Position2 = positions[len(positions)-1]
+
+	for _, obj := range b.objects() {
+		obj.Show()
+	}
+}
+
+// layoutOverlay places the lasso loop and the transform box above the content.
+func (r *mosugoRenderer) layoutOverlay(size fyne.Size) {
+	c := r.canvas
+	if c.lasso.Visible() {
+		c.lasso.Width = lassoWidth / c.Scale
+		c.lasso.Invalidate()
+		r.layoutStroke(c.lasso, size)
+	}
+
+	bounds, ok := c.selectionBounds()
+	if !ok || c.CurrentTool != tools.ToolLasso {
+		c.transformBox.hide()
+		return
+	}
+	c.transformBox.layout(c.WorldToScreen(bounds.Min), c.WorldToScreen(bounds.Max))
+}
+
+// Lasso returns the loop drawn by the Lasso tool, in world coordinates.
+func (c *MosugoCanvas) Lasso() *strokes.Stroke {
+	return c.lasso
+}
+
+// SelectionBounds returns the world rectangle around the selection.
+// ok is false when nothing is selected.
+func (c *MosugoCanvas) SelectionBounds() (min, max fyne.Position, ok bool) {
+	bounds, ok := c.selectionBounds()
+	return bounds.Min, bounds.Max, ok
+}
+
+// shapeSnapshot is the geometry of a selected object when a transform began.
+type shapeSnapshot struct {
+	points []fyne.Position
+	width  float32
+	pos    fyne.Position
+	size   fyne.Size
+}
+
+// BeginSelectionTransform records the geometry of the selection, which
+// TransformSelection then maps from until CommitSelectionTransform.
+func (c *MosugoCanvas) BeginSelectionTransform() {
+	c.transformBase = make(map[fyne.CanvasObject]shapeSnapshot)
+	for _, obj := range c.Selection() {
+		switch o := obj.(type) {
+		case *cards.MosuWidget:
+			c.transformBase[o] = shapeSnapshot{pos: o.WorldPos, size: o.WorldSize}
+		case *strokes.Stroke:
+			c.transformBase[o] = shapeSnapshot{points: append([]fyne.Position(nil), o.Points...), width: o.Width}
+		}
+	}
+}
+
+// TransformSelection maps the selection, as it was when the transform began,
+// through t. Strokes take on the full transform; cards cannot rotate, so their
+// centers follow it while their size only scales, and both snap to the grid.
+func (c *MosugoCanvas) TransformSelection(t tools.Transform) {
+	if c.transformBase == nil {
+		c.BeginSelectionTransform()
+	}
+	for _, obj := range c.Selection() {
+		base, ok := c.transformBase[obj]
+		if !ok {
+			continue
+		}
+		switch o := obj.(type) {
+		case *cards.MosuWidget:
+			size := fyne.NewSize(
+				fyne.Max(snapRound(base.size.Width*t.Scale), GridSize),
+				fyne.Max(snapRound(base.size.Height*t.Scale), GridSize),
+			)
+			center := t.Apply(base.pos.AddXY(base.size.Width/2, base.size.Height/2))
+			o.WorldPos = fyne.NewPos(snapRound(center.X-size.Width/2), snapRound(center.Y-size.Height/2))
+			o.WorldSize = size
+			c.UpdateCardBounds(o)
+		case *strokes.Stroke:
+			for i, p := range base.points {
+				o.Points[i] = t.Apply(p)
+			}
+			o.Width = base.width * t.Scale
+			c.UpdateStrokeBounds(o)
+		}
+	}
+}
+
+// CommitSelectionTransform records the transform since BeginSelectionTransform as one step.
+func (c *MosugoCanvas) CommitSelectionTransform() {
+	base := c.transformBase
+	c.transformBase = nil
+
+	cmds := []historyCommand{}
+	for _, obj := range c.Selection() {
+		before, ok := base[obj]
+		if !ok {
+			continue
+		}
+		switch o := obj.(type) {
+		case *cards.MosuWidget:
+			if o.WorldPos == before.pos && o.WorldSize == before.size {
+				continue
+			}
+			cmds = append(cmds, cardResizeCommand{
+				cardID:     o.ID,
+				beforePos:  before.pos,
+				beforeSize: before.size,
+				afterPos:   o.WorldPos,
+				afterSize:  o.WorldSize,
+			})
+		case *strokes.Stroke:
+			after := strokeShape{points: append([]fyne.Position(nil), o.Points...), width: o.Width}
+			if samePoints(before.points, after.points) && before.width == after.width {
+				continue
+			}
+			cmds = append(cmds, strokeShapeCommand{
+				strokeID: o.ID,
+				before:   strokeShape{points: before.points, width: before.width},
+				after:    after,
+			})
+		}
+	}
+	c.commitCompound(cmds)
+}
+
+func samePoints(a, b []fyne.Position) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// snapRound snaps v to the nearest grid line.
+func snapRound(v float32) float32 {
+	return float32(math.Round(float64(v)/GridSize) * GridSize)
+}
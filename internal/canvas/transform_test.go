@@ -0,0 +1,102 @@
+package canvas
+
+import (
+	"math"
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/F4tal1t/Mosugo/internal/storage"
+	"github.com/F4tal1t/Mosugo/internal/testutil"
+	"github.com/F4tal1t/Mosugo/internal/tools"
+)
+
+func TestTransformApply(t *testing.T) {
+	pivot := fyne.NewPos(10, 10)
+
+	moved := tools.Translation(fyne.NewPos(5, -5)).Apply(fyne.NewPos(1, 2))
+	testutil.PositionEqual(t, fyne.NewPos(6, -3), moved)
+
+	scaled := tools.Transform{Pivot: pivot, Scale: 2}.Apply(fyne.NewPos(20, 10))
+	testutil.PositionEqual(t, fyne.NewPos(30, 10), scaled)
+
+	// A quarter turn is clockwise on screen, where Y points down
+	rotated := tools.Transform{Pivot: pivot, Scale: 1, Angle: math.Pi / 2}.Apply(fyne.NewPos(20, 10))
+	testutil.PositionEqual(t, fyne.NewPos(10, 20), rotated)
+}
+
+func TestTransformSelectionIsOneStep(t *testing.T) {
+	c, a, b, stroke := newSelectionFixture(t)
+	c.SetSelection(a, stroke)
+	beforePoints := append([]fyne.Position(nil), stroke.Points...)
+
+	// Dragging sends many transforms, each mapped from the starting geometry
+	c.BeginSelectionTransform()
+	c.TransformSelection(tools.Transform{Pivot: fyne.NewPos(0, 0), Scale: 1.5})
+	c.TransformSelection(tools.Transform{Pivot: fyne.NewPos(0, 0), Scale: 2})
+	c.CommitSelectionTransform()
+	require.Len(t, c.undoStack, 1)
+
+	assert.Equal(t, fyne.NewPos(0, 400), stroke.Points[0])
+	assert.Equal(t, fyne.NewPos(200, 520), stroke.Points[1])
+	testutil.Float32Equal(t, 5, stroke.Width)
+	assert.Equal(t, fyne.NewSize(240, 180), a.WorldSize)
+	assert.Equal(t, fyne.NewPos(60, 60), a.WorldPos)
+	assert.Equal(t, fyne.NewPos(300, 30), b.WorldPos, "Unselected card stays put")
+	assert.Same(t, stroke, c.StrokeAt(c.WorldToScreen(fyne.NewPos(100, 460)), 2), "Index follows the transform")
+
+	require.True(t, c.Undo())
+	assert.Equal(t, beforePoints, stroke.Points)
+	testutil.Float32Equal(t, 2.5, stroke.Width)
+	assert.Equal(t, fyne.NewPos(30, 30), a.WorldPos)
+	assert.Equal(t, fyne.NewSize(120, 90), a.WorldSize)
+
+	require.True(t, c.Redo())
+	assert.Equal(t, fyne.NewPos(0, 400), stroke.Points[0])
+	assert.Equal(t, fyne.NewSize(240, 180), a.WorldSize)
+}
+
+func TestRotateSelectionKeepsCardsUpright(t *testing.T) {
+	c := NewMosugoCanvas()
+	card := c.addCardFromData(storage.MosuData{ID: "card-1", PosX: 90, PosY: 0, Width: 60, Height: 60})
+	c.SetSelection(card)
+
+	c.BeginSelectionTransform()
+	c.TransformSelection(tools.Transform{Pivot: fyne.NewPos(0, 0), Scale: 1, Angle: math.Pi / 2})
+	c.CommitSelectionTransform()
+
+	// The center (120, 30) turns to (-30, 120); the card itself does not turn
+	assert.Equal(t, fyne.NewSize(60, 60), card.WorldSize)
+	assert.Equal(t, fyne.NewPos(-60, 90), card.WorldPos)
+	require.True(t, c.Undo())
+	assert.Equal(t, fyne.NewPos(90, 0), card.WorldPos)
+}
+
+func TestUnchangedTransformRecordsNothing(t *testing.T) {
+	c, a, _, stroke := newSelectionFixture(t)
+	c.SetSelection(a, stroke)
+
+	c.BeginSelectionTransform()
+	c.TransformSelection(tools.Translation(fyne.NewPos(0, 0)))
+	c.CommitSelectionTransform()
+	assert.Empty(t, c.undoStack)
+}
+
+func TestSelectionBoundsAndLasso(t *testing.T) {
+	c, a, _, _ := newSelectionFixture(t)
+
+	_, _, ok := c.SelectionBounds()
+	assert.False(t, ok)
+
+	c.SetSelection(a)
+	lo, hi, ok := c.SelectionBounds()
+	require.True(t, ok)
+	assert.Equal(t, fyne.NewPos(30, 30), lo)
+	assert.Equal(t, fyne.NewPos(150, 120), hi)
+
+	require.NotNil(t, c.Lasso())
+	assert.False(t, c.Lasso().Visible(), "Lasso only shows while drawn")
+	assert.Nil(t, c.StrokeByID(c.Lasso().ID), "Lasso is not a stroke of the workspace")
+}
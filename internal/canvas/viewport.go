@@ -0,0 +1,300 @@
+package canvas
+
+import (
+	"math"
+	"time"
+
+	"fyne.io/fyne/v2"
+
+	"github.com/F4tal1t/Mosugo/internal/cards"
+	"github.com/F4tal1t/Mosugo/internal/tools"
+)
+
+const (
+	MinScale = 0.1
+	MaxScale = 5.0
+
+	// zoomSensitivity converts scroll delta into an exponential zoom factor, so
+	// a mouse wheel notch and many small trackpad deltas zoom by the same amount.
+	zoomSensitivity = 0.01
+
+	// Camera commands never zoom in further than this, so framing a single
+	// small card doesn't blow it up to MaxScale.
+	maxFitScale = 2.0
+	// fitPadding is the screen-space margin kept around framed content.
+	fitPadding = 60
+
+	viewTweenDuration = 300 * time.Millisecond
+
+	// cullMargin is how far past the window edge, in screen pixels, objects are
+	// still laid out, so a pan never reveals an object before it is positioned.
+	cullMargin = 120
+)
+
+// worldRect is an axis-aligned rectangle in world coordinates.
+type worldRect struct {
+	Min, Max fyne.Position
+}
+
+func rectFromPosSize(pos fyne.Position, size fyne.Size) worldRect {
+	return worldRect{Min: pos, Max: pos.Add(fyne.NewPos(size.Width, size.Height))}
+}
+
+func rectFromPoints(p1, p2 fyne.Position) worldRect {
+	return worldRect{
+		Min: fyne.NewPos(fyne.Min(p1.X, p2.X), fyne.Min(p1.Y, p2.Y)),
+		Max: fyne.NewPos(fyne.Max(p1.X, p2.X), fyne.Max(p1.Y, p2.Y)),
+	}
+}
+
+func (r worldRect) union(o worldRect) worldRect {
+	return worldRect{
+		Min: fyne.NewPos(fyne.Min(r.Min.X, o.Min.X), fyne.Min(r.Min.Y, o.Min.Y)),
+		Max: fyne.NewPos(fyne.Max(r.Max.X, o.Max.X), fyne.Max(r.Max.Y, o.Max.Y)),
+	}
+}
+
+func (r worldRect) center() fyne.Position {
+	return fyne.NewPos((r.Min.X+r.Max.X)/2, (r.Min.Y+r.Max.Y)/2)
+}
+
+// clampScale keeps a zoom level inside [MinScale, MaxScale].
+// Non-positive values (e.g. from an unset workspace field) fall back to 1:1.
+func clampScale(scale float32) float32 {
+	if scale <= 0 {
+		return 1.0
+	}
+	if scale < MinScale {
+		return MinScale
+	}
+	if scale > MaxScale {
+		return MaxScale
+	}
+	return scale
+}
+
+// Scrolled zooms the canvas with the mouse wheel or trackpad, keeping the
+// world point under the cursor fixed on screen.
+func (c *MosugoCanvas) Scrolled(e *fyne.ScrollEvent) {
+	if e.Scrolled.DY == 0 {
+		return
+	}
+	factor := float32(math.Exp(float64(e.Scrolled.DY) * zoomSensitivity))
+	c.ZoomAt(e.Position, c.Scale*factor)
+}
+
+// ZoomAt changes the scale while anchoring the world point at screenPos.
+// The new view is marked dirty so it is persisted with the workspace.
+func (c *MosugoCanvas) ZoomAt(screenPos fyne.Position, scale float32) {
+	c.stopViewAnimation()
+	scale = clampScale(scale)
+	if scale == c.Scale {
+		return
+	}
+
+	anchor := c.ScreenToWorld(screenPos)
+	c.Scale = scale
+	drift := screenPos.Subtract(c.WorldToScreen(anchor))
+	c.Offset = c.Offset.Add(drift)
+
+	c.MarkDirty()
+	c.refreshIfReady()
+}
+
+// contentBounds returns the world-space bounds of every card and stroke.
+func (c *MosugoCanvas) contentBounds() (worldRect, bool) {
+	var bounds worldRect
+	found := false
+	include := func(r worldRect) {
+		if !found {
+			bounds = r
+			found = true
+			return
+		}
+		bounds = bounds.union(r)
+	}
+
+	for _, obj := range c.Content.Objects {
+		if card, ok := obj.(*cards.MosuWidget); ok {
+			include(rectFromPosSize(card.WorldPos, card.WorldSize))
+		}
+	}
+	for _, stroke := range c.strokeByID {
+		include(rectFromPoints(stroke.Bounds()))
+	}
+	return bounds, found
+}
+
+// fitView computes the offset and scale that center bounds in a viewport of
+// the given size, leaving padding screen pixels on every side.
+func fitView(bounds worldRect, viewport fyne.Size, padding float32) (fyne.Position, float32) {
+	availW := fyne.Max(viewport.Width-2*padding, 1)
+	availH := fyne.Max(viewport.Height-2*padding, 1)
+
+	scale := float32(maxFitScale)
+	if w := bounds.Max.X - bounds.Min.X; w > 0 {
+		scale = fyne.Min(scale, availW/w)
+	}
+	if h := bounds.Max.Y - bounds.Min.Y; h > 0 {
+		scale = fyne.Min(scale, availH/h)
+	}
+	scale = clampScale(scale)
+
+	center := bounds.center()
+	offset := fyne.NewPos(
+		viewport.Width/2-center.X*scale,
+		viewport.Height/2-center.Y*scale,
+	)
+	return offset, scale
+}
+
+// ZoomToFit frames all cards and strokes. It returns false when the canvas is empty or has no
+// size yet.
+func (c *MosugoCanvas) ZoomToFit() bool {
+	bounds, ok := c.contentBounds()
+	if !ok {
+		return false
+	}
+	return c.frame(bounds)
+}
+
+// ZoomToSelection frames the selected cards and strokes. It returns false when nothing is selected
+// or the canvas has no size yet.
+func (c *MosugoCanvas) ZoomToSelection() bool {
+	bounds, ok := c.selectionBounds()
+	if !ok {
+		return false
+	}
+	return c.frame(bounds)
+}
+
+// ResetView eases back to 1:1 scale with the world origin at the top-left corner.
+func (c *MosugoCanvas) ResetView() {
+	c.animateView(fyne.NewPos(0, 0), 1.0)
+}
+
+func (c *MosugoCanvas) frame(bounds worldRect) bool {
+	size := c.Size()
+	if size.Width <= 0 || size.Height <= 0 {
+		return false
+	}
+	offset, scale := fitView(bounds, size, fitPadding)
+	c.animateView(offset, scale)
+	return true
+}
+
+// setView applies a camera position immediately.
+func (c *MosugoCanvas) setView(offset fyne.Position, scale float32) {
+	c.Offset = offset
+	c.Scale = scale
+	c.refreshIfReady()
+}
+
+func (c *MosugoCanvas) stopViewAnimation() {
+	c.viewAnimSeq++
+}
+
+// animateView tweens the camera to the target offset and scale. The world
+// point at the center of the viewport moves linearly while the scale is
+// interpolated geometrically, so zooming feels uniform in both directions.
+// Any zoom or pan started by the user cancels the running tween.
+func (c *MosugoCanvas) animateView(offset fyne.Position, scale float32) {
+	c.stopViewAnimation()
+	if !c.uiReady {
+		c.setView(offset, scale)
+		c.MarkDirty()
+		return
+	}
+
+	seq := c.viewAnimSeq
+	size := c.Size()
+	screenCenter := fyne.NewPos(size.Width/2, size.Height/2)
+
+	startScale := c.Scale
+	startCenter := c.ScreenToWorld(screenCenter)
+	endCenter := fyne.NewPos(
+		(screenCenter.X-offset.X)/scale,
+		(screenCenter.Y-offset.Y)/scale,
+	)
+	start := time.Now()
+
+	go func() {
+		ticker := time.NewTicker(16 * time.Millisecond)
+		defer ticker.Stop()
+
+		for {
+			progress := float32(time.Since(start)) / float32(viewTweenDuration)
+			finished := progress >= 1
+			if finished {
+				progress = 1
+			}
+			t := tools.EaseOutCubic(progress)
+
+			s := startScale * float32(math.Pow(float64(scale/startScale), float64(t)))
+			center := fyne.NewPos(
+				startCenter.X+(endCenter.X-startCenter.X)*t,
+				startCenter.Y+(endCenter.Y-startCenter.Y)*t,
+			)
+
+			cancelled := false
+			fyne.DoAndWait(func() {
+				if c.viewAnimSeq != seq {
+					cancelled = true
+					return
+				}
+				if finished {
+					c.setView(offset, scale)
+					c.MarkDirty()
+					return
+				}
+				c.setView(fyne.NewPos(screenCenter.X-center.X*s, screenCenter.Y-center.Y*s), s)
+			})
+			if finished || cancelled {
+				return
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// visibleWorldRect returns the world area covered by a viewport of the given
+// size, grown by cullMargin.
+func (c *MosugoCanvas) visibleWorldRect(size fyne.Size) worldRect {
+	scale := c.Scale
+	if scale <= 0 {
+		scale = 1.0
+	}
+	view := worldRect{
+		Min: c.ScreenToWorld(fyne.NewPos(0, 0)),
+		Max: c.ScreenToWorld(fyne.NewPos(size.Width, size.Height)),
+	}
+	return view.expand(cullMargin / scale)
+}
+
+// cullContent shows the indexed objects that intersect the viewport, hides
+// the ones that left it, and returns the visible objects for layout.
+func (c *MosugoCanvas) cullContent(size fyne.Size) []fyne.CanvasObject {
+	visible := c.objectIndex().Query(c.visibleWorldRect(size))
+
+	shown := make(map[fyne.CanvasObject]bool, len(visible))
+	for _, obj := range visible {
+		shown[obj] = true
+		if !obj.Visible() {
+			obj.Show()
+		}
+	}
+	for obj := range c.onScreen {
+		if !shown[obj] {
+			obj.Hide()
+		}
+	}
+	for obj := range c.cullPending {
+		if !shown[obj] {
+			obj.Hide()
+		}
+	}
+
+	c.onScreen = shown
+	c.cullPending = make(map[fyne.CanvasObject]bool)
+	return visible
+}
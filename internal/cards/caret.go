@@ -0,0 +1,213 @@
+package cards
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+
+	"github.com/F4tal1t/Mosugo/internal/theme"
+)
+
+const (
+	// cardTextSize is the size card text is drawn and measured at
+	cardTextSize float32 = 14
+	// cardPadding is the space between the card edge and its text
+	cardPadding float32 = 16
+	// checkLabelIndent is the space a checkbox icon takes before its label
+	checkLabelIndent float32 = 24
+	// caretWidth is the on-screen width of the text caret
+	caretWidth float32 = 2
+)
+
+type lineKind int
+
+const (
+	lineText lineKind = iota
+	lineBullet
+	lineTodo
+	lineDone
+)
+
+// lineView is how one line of raw card text is shown.
+type lineView struct {
+	kind  lineKind
+	shown string // text drawn for the line
+	start int    // rune offset in the raw line where its own text begins
+	lead  string // drawn before the raw text from start, e.g. a bullet
+}
+
+// parseLine works out how a raw line is shown: "[] " and "[x] " lines become
+// checkboxes, "- " lines bullets, anything else plain text.
+func parseLine(line string) lineView {
+	trimmed := strings.TrimSpace(line)
+	indent := utf8.RuneCountInString(line) - utf8.RuneCountInString(strings.TrimLeft(line, " \t"))
+
+	for _, p := range []struct {
+		prefix string
+		kind   lineKind
+	}{
+		{"[] ", lineTodo}, {"[ ] ", lineTodo}, {"[x] ", lineDone}, {"[X] ", lineDone},
+	} {
+		if strings.HasPrefix(trimmed, p.prefix) {
+			return lineView{
+				kind:  p.kind,
+				shown: strings.TrimPrefix(trimmed, p.prefix),
+				start: indent + len(p.prefix),
+			}
+		}
+	}
+	if strings.HasPrefix(trimmed, "- ") {
+		return lineView{
+			kind:  lineBullet,
+			shown: "• " + strings.TrimPrefix(trimmed, "- "),
+			start: indent + 2,
+			lead:  "• ",
+		}
+	}
+	if trimmed == "" {
+		// Empty lines keep their height with a space
+		return lineView{kind: lineText, shown: " "}
+	}
+	return lineView{kind: lineText, shown: line}
+}
+
+// indent is where the line's text starts, relative to the line object.
+func (v lineView) indent() float32 {
+	if v.kind == lineTodo || v.kind == lineDone {
+		return checkLabelIndent
+	}
+	return 0
+}
+
+// columnX returns the x offset of column col of raw line.
+func (v lineView) columnX(line string, col int) float32 {
+	runes := []rune(line)
+	col = clampInt(col, v.start, len(runes))
+	if v.start > len(runes) {
+		return v.indent() + measureText(v.lead)
+	}
+	return v.indent() + measureText(v.lead+string(runes[v.start:col]))
+}
+
+// columnAt returns the column of raw line nearest to x.
+func (v lineView) columnAt(line string, x float32) int {
+	runes := []rune(line)
+	best, bestDist := clampInt(v.start, 0, len(runes)), float32(-1)
+	for col := best; col <= len(runes); col++ {
+		dist := v.columnX(line, col) - x
+		if dist < 0 {
+			dist = -dist
+		}
+		if bestDist >= 0 && dist > bestDist {
+			break
+		}
+		best, bestDist = col, dist
+	}
+	return best
+}
+
+func measureText(s string) float32 {
+	return fyne.MeasureText(s, cardTextSize, fyne.TextStyle{}).Width
+}
+
+func lineHeight() float32 {
+	return fyne.MeasureText("M", cardTextSize, fyne.TextStyle{}).Height
+}
+
+// caretLayout keeps the caret overlay in step with the text below it.
+// The overlay is stacked over the content, so both share coordinates.
+type caretLayout struct {
+	card *MosuWidget
+}
+
+func (l *caretLayout) Layout(_ []fyne.CanvasObject, _ fyne.Size) {
+	l.card.layoutCaret()
+}
+
+func (l *caretLayout) MinSize(_ []fyne.CanvasObject) fyne.Size {
+	return fyne.NewSize(0, 0)
+}
+
+// layoutCaret draws the caret and the selection over the lines of text.
+func (m *MosuWidget) layoutCaret() {
+	if !m.uiReady || m.overlay == nil {
+		return
+	}
+	lineObjs := m.contentVBox.Objects
+	if !m.hasFocus || len(lineObjs) == 0 {
+		m.overlay.Objects = nil
+		canvas.Refresh(m.overlay)
+		return
+	}
+
+	height := lineHeight()
+	objs := []fyne.CanvasObject{}
+
+	start, end := m.buf.Selection()
+	if start != end {
+		startLine, startCol := m.buf.LineCol(start)
+		endLine, endCol := m.buf.LineCol(end)
+		for line := startLine; line <= endLine && line < len(lineObjs); line++ {
+			raw := m.buf.Line(line)
+			view := parseLine(raw)
+			from, to := 0, len([]rune(raw))
+			if line == startLine {
+				from = startCol
+			}
+			if line == endLine {
+				to = endCol
+			}
+			x1, x2 := view.columnX(raw, from), view.columnX(raw, to)
+			if line != endLine {
+				// Show the selected line break
+				x2 += measureText(" ")
+			}
+			rect := canvas.NewRectangle(theme.SelectionHalo)
+			rect.Move(fyne.NewPos(x1, lineObjs[line].Position().Y))
+			rect.Resize(fyne.NewSize(x2-x1, height))
+			objs = append(objs, rect)
+		}
+	}
+
+	line, col := m.buf.LineCol(m.buf.caret)
+	if line < len(lineObjs) {
+		raw := m.buf.Line(line)
+		m.caret.FillColor = m.ink
+		m.caret.Move(fyne.NewPos(parseLine(raw).columnX(raw, col), lineObjs[line].Position().Y))
+		m.caret.Resize(fyne.NewSize(caretWidth, height))
+		if m.cursorVisible {
+			m.caret.Show()
+		} else {
+			m.caret.Hide()
+		}
+		objs = append(objs, m.caret)
+	}
+
+	// Refreshing the container would lay it out, and so call back in here
+	m.overlay.Objects = objs
+	m.caret.Refresh()
+	canvas.Refresh(m.overlay)
+}
+
+// caretAt returns the text position under pos, relative to the card.
+func (m *MosuWidget) caretAt(pos fyne.Position) int {
+	lineObjs := m.contentVBox.Objects
+	if len(lineObjs) == 0 {
+		return 0
+	}
+	content := pos.SubtractXY(cardPadding, cardPadding)
+	if m.scroll != nil {
+		content = content.Add(m.scroll.Offset)
+	}
+
+	line := 0
+	for i, obj := range lineObjs {
+		if content.Y >= obj.Position().Y {
+			line = i
+		}
+	}
+	raw := m.buf.Line(line)
+	return m.buf.PosAt(line, parseLine(raw).columnAt(raw, content.X))
+}
@@ -0,0 +1,372 @@
+package cards
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/go-text/typesetting/segmenter"
+)
+
+// textBuffer is the editing model behind a card: its text, the caret and the
+// selection anchor. Positions are rune offsets into the text, and the caret
+// and anchor only rest between grapheme clusters, so an accented letter or an
+// emoji is moved over and deleted as one character and the text always stays
+// valid UTF-8. Nothing is selected while anchor equals caret.
+type textBuffer struct {
+	text   []rune
+	caret  int
+	anchor int
+	goal   int   // column vertical moves aim for; -1 until the first one
+	bounds []int // cluster boundaries of text, nil until needed
+}
+
+func newTextBuffer(text string) *textBuffer {
+	b := &textBuffer{goal: -1}
+	b.SetText(text)
+	return b
+}
+
+func (b *textBuffer) String() string {
+	return string(b.text)
+}
+
+// SetText replaces the text and puts the caret at its end.
+func (b *textBuffer) SetText(text string) {
+	b.setRunes([]rune(text))
+	b.collapse(len(b.text))
+}
+
+// ReplaceText swaps in new text, keeping the caret where it was as far as
+// the new text allows.
+func (b *textBuffer) ReplaceText(text string) {
+	b.setRunes([]rune(text))
+	b.collapse(b.caret)
+}
+
+func (b *textBuffer) setRunes(text []rune) {
+	b.text = text
+	b.bounds = nil
+}
+
+// Selection returns the selected range, start <= end.
+func (b *textBuffer) Selection() (start, end int) {
+	if b.anchor < b.caret {
+		return b.anchor, b.caret
+	}
+	return b.caret, b.anchor
+}
+
+func (b *textBuffer) HasSelection() bool {
+	return b.anchor != b.caret
+}
+
+func (b *textBuffer) SelectedText() string {
+	start, end := b.Selection()
+	return string(b.text[start:end])
+}
+
+func (b *textBuffer) SelectAll() {
+	b.anchor = 0
+	b.caret = len(b.text)
+	b.goal = -1
+}
+
+// Insert replaces the selection with s and leaves the caret after it.
+func (b *textBuffer) Insert(s string) {
+	start, end := b.Selection()
+	inserted := []rune(s)
+	text := make([]rune, 0, len(b.text)-(end-start)+len(inserted))
+	text = append(text, b.text[:start]...)
+	text = append(text, inserted...)
+	text = append(text, b.text[end:]...)
+	b.setRunes(text)
+	// Inserted combining marks join the cluster before them, so the caret
+	// moves on to the end of that cluster
+	b.collapse(b.snapForward(start + len(inserted)))
+}
+
+// DeleteBackward removes the selection, or the character before the caret.
+// It reports whether the text changed.
+func (b *textBuffer) DeleteBackward() bool {
+	if !b.HasSelection() {
+		if b.caret == 0 {
+			return false
+		}
+		b.anchor = b.prevBoundary(b.caret)
+	}
+	b.Insert("")
+	return true
+}
+
+// DeleteForward removes the selection, or the character after the caret.
+// It reports whether the text changed.
+func (b *textBuffer) DeleteForward() bool {
+	if !b.HasSelection() {
+		if b.caret == len(b.text) {
+			return false
+		}
+		b.anchor = b.nextBoundary(b.caret)
+	}
+	b.Insert("")
+	return true
+}
+
+// MoveTo puts the caret at pos. With extend the selection grows from its
+// anchor, otherwise it collapses.
+func (b *textBuffer) MoveTo(pos int, extend bool) {
+	b.moveTo(pos, extend)
+	b.goal = -1
+}
+
+func (b *textBuffer) moveTo(pos int, extend bool) {
+	pos = b.snapBack(pos)
+	b.caret = pos
+	if !extend {
+		b.anchor = pos
+	}
+}
+
+func (b *textBuffer) collapse(pos int) {
+	b.moveTo(pos, false)
+	b.goal = -1
+}
+
+// Left moves the caret one character back. Without extend a selection
+// collapses to its start instead.
+func (b *textBuffer) Left(extend bool) {
+	if !extend && b.HasSelection() {
+		start, _ := b.Selection()
+		b.collapse(start)
+		return
+	}
+	b.MoveTo(b.prevBoundary(b.caret), extend)
+}
+
+// Right moves the caret one character on. Without extend a selection
+// collapses to its end instead.
+func (b *textBuffer) Right(extend bool) {
+	if !extend && b.HasSelection() {
+		_, end := b.Selection()
+		b.collapse(end)
+		return
+	}
+	b.MoveTo(b.nextBoundary(b.caret), extend)
+}
+
+// WordLeft moves the caret to the start of the word before it.
+func (b *textBuffer) WordLeft(extend bool) {
+	pos := b.caret
+	for pos > 0 && unicode.IsSpace(b.text[pos-1]) {
+		pos--
+	}
+	if pos > 0 {
+		class := runeClass(b.text[pos-1])
+		for pos > 0 && runeClass(b.text[pos-1]) == class {
+			pos--
+		}
+	}
+	b.MoveTo(b.snapBack(pos), extend)
+}
+
+// WordRight moves the caret past the end of the word after it.
+func (b *textBuffer) WordRight(extend bool) {
+	pos := b.caret
+	for pos < len(b.text) && unicode.IsSpace(b.text[pos]) {
+		pos++
+	}
+	if pos < len(b.text) {
+		class := runeClass(b.text[pos])
+		for pos < len(b.text) && runeClass(b.text[pos]) == class {
+			pos++
+		}
+	}
+	b.MoveTo(b.snapForward(pos), extend)
+}
+
+// LineStart moves the caret to the start of its line.
+func (b *textBuffer) LineStart(extend bool) {
+	line, _ := b.LineCol(b.caret)
+	b.MoveTo(b.PosAt(line, 0), extend)
+}
+
+// LineEnd moves the caret to the end of its line.
+func (b *textBuffer) LineEnd(extend bool) {
+	line, _ := b.LineCol(b.caret)
+	b.MoveTo(b.PosAt(line, len(b.text)), extend)
+}
+
+// Up moves the caret to the line above, keeping to the column the first of a
+// run of vertical moves started from. On the first line it goes to the start.
+func (b *textBuffer) Up(extend bool) {
+	b.vertical(-1, extend)
+}
+
+// Down moves the caret to the line below, like Up. On the last line it goes to the end.
+func (b *textBuffer) Down(extend bool) {
+	b.vertical(1, extend)
+}
+
+func (b *textBuffer) vertical(dir int, extend bool) {
+	line, col := b.LineCol(b.caret)
+	if b.goal < 0 {
+		b.goal = col
+	}
+	target := line + dir
+	switch {
+	case target < 0:
+		b.moveTo(0, extend)
+	case target >= b.LineCount():
+		b.moveTo(len(b.text), extend)
+	default:
+		b.moveTo(b.PosAt(target, b.goal), extend)
+	}
+}
+
+// LineCount returns the number of lines, counting the one after a final newline.
+func (b *textBuffer) LineCount() int {
+	count := 1
+	for _, r := range b.text {
+		if r == '\n' {
+			count++
+		}
+	}
+	return count
+}
+
+// LineCol returns the line and column of pos.
+func (b *textBuffer) LineCol(pos int) (line, col int) {
+	pos = clampInt(pos, 0, len(b.text))
+	for _, r := range b.text[:pos] {
+		if r == '\n' {
+			line++
+			col = 0
+		} else {
+			col++
+		}
+	}
+	return line, col
+}
+
+// PosAt returns the position of column col on line, clamped to the line.
+func (b *textBuffer) PosAt(line, col int) int {
+	pos := 0
+	for ; line > 0 && pos < len(b.text); pos++ {
+		if b.text[pos] == '\n' {
+			line--
+		}
+	}
+	for ; col > 0 && pos < len(b.text) && b.text[pos] != '\n'; col-- {
+		pos++
+	}
+	return pos
+}
+
+// Line returns the text of a line, without its newline.
+func (b *textBuffer) Line(line int) string {
+	lines := strings.Split(string(b.text), "\n")
+	if line < 0 || line >= len(lines) {
+		return ""
+	}
+	return lines[line]
+}
+
+// graphemes returns the offsets where grapheme clusters start, followed by
+// the end of the text.
+func (b *textBuffer) graphemes() []int {
+	if b.bounds == nil {
+		b.bounds = graphemeBounds(b.text)
+	}
+	return b.bounds
+}
+
+func graphemeBounds(text []rune) []int {
+	bounds := make([]int, 0, len(text)+1)
+	if len(text) > 0 {
+		var seg segmenter.Segmenter
+		seg.Init(text)
+		iter := seg.GraphemeIterator()
+		for iter.Next() {
+			bounds = append(bounds, iter.Grapheme().Offset)
+		}
+	}
+	return append(bounds, len(text))
+}
+
+// GraphemeCount returns how many characters s shows as, counting each
+// grapheme cluster once.
+func GraphemeCount(s string) int {
+	return len(graphemeBounds([]rune(s))) - 1
+}
+
+// snapBack returns the cluster boundary at or before pos.
+func (b *textBuffer) snapBack(pos int) int {
+	bounds := b.graphemes()
+	i := sort.SearchInts(bounds, pos+1) - 1
+	if i < 0 {
+		return 0
+	}
+	return bounds[i]
+}
+
+// snapForward returns the cluster boundary at or after pos.
+func (b *textBuffer) snapForward(pos int) int {
+	bounds := b.graphemes()
+	i := sort.SearchInts(bounds, pos)
+	if i >= len(bounds) {
+		return len(b.text)
+	}
+	return bounds[i]
+}
+
+func (b *textBuffer) prevBoundary(pos int) int {
+	if pos <= 0 {
+		return 0
+	}
+	return b.snapBack(pos - 1)
+}
+
+func (b *textBuffer) nextBoundary(pos int) int {
+	if pos >= len(b.text) {
+		return len(b.text)
+	}
+	return b.snapForward(pos + 1)
+}
+
+// cleanText makes outside text, such as a paste, fit for a card: invalid
+// UTF-8 is dropped, line breaks become "\n" and other control characters
+// except tabs are removed.
+func cleanText(s string) string {
+	s = strings.ToValidUTF8(s, "")
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	s = strings.ReplaceAll(s, "\r", "\n")
+	return strings.Map(func(r rune) rune {
+		if r != '\n' && r != '\t' && unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// runeClass groups runes for word jumps: spaces, word characters and the rest.
+// Combining marks belong to the word they decorate.
+func runeClass(r rune) int {
+	switch {
+	case unicode.IsSpace(r):
+		return 0
+	case r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r):
+		return 1
+	default:
+		return 2
+	}
+}
+
+func clampInt(v, lo, hi int) int {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
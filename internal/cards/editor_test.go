@@ -0,0 +1,231 @@
+package cards
+
+import (
+	"testing"
+	"unicode/utf8"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/driver/desktop"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/F4tal1t/Mosugo/internal/theme"
+)
+
+func TestTextBufferIsRuneAware(t *testing.T) {
+	b := newTextBuffer("héllo")
+	assert.Equal(t, 5, b.caret, "Caret counts runes, not bytes")
+
+	b.Left(false)
+	b.Left(false)
+	b.Left(false)
+	b.Left(false)
+	b.Insert("ü")
+	assert.Equal(t, "hüéllo", b.String())
+
+	assert.True(t, b.DeleteForward())
+	assert.Equal(t, "hüllo", b.String())
+	assert.True(t, b.DeleteBackward())
+	assert.Equal(t, "hllo", b.String())
+}
+
+func TestTextBufferGraphemeClusters(t *testing.T) {
+	family := "👩\u200d👩\u200d👧"
+	b := newTextBuffer("a" + family + "🇯🇵e\u0301")
+
+	b.Left(false)
+	assert.Equal(t, 3+len([]rune(family)), b.caret, "Decomposed é is one character")
+	b.Left(false)
+	assert.Equal(t, 1+len([]rune(family)), b.caret, "A flag is one character")
+	assert.True(t, b.DeleteBackward())
+	assert.Equal(t, "a🇯🇵e\u0301", b.String(), "Backspace removes the whole family")
+
+	b.MoveTo(2, false)
+	assert.Equal(t, 1, b.caret, "Positions inside a cluster snap to its start")
+
+	// A combining mark typed after a letter joins it
+	b.MoveTo(len(b.text), false)
+	b.Insert("\u0308")
+	assert.Equal(t, len(b.text), b.caret)
+	assert.True(t, b.DeleteBackward())
+	assert.Equal(t, "a🇯🇵", b.String())
+
+	assert.Equal(t, 1, GraphemeCount(family))
+	assert.Equal(t, 3, GraphemeCount("e\u0301x🇯🇵"))
+	assert.Equal(t, 0, GraphemeCount(""))
+}
+
+func TestTextBufferPipesAreText(t *testing.T) {
+	b := newTextBuffer("a|b")
+	b.MoveTo(1, false)
+	b.Insert("|")
+	assert.Equal(t, "a||b", b.String())
+}
+
+func TestTextBufferShiftSelection(t *testing.T) {
+	b := newTextBuffer("hello world")
+	b.MoveTo(0, false)
+	b.Right(true)
+	b.Right(true)
+	assert.Equal(t, "he", b.SelectedText())
+
+	// Without Shift an arrow collapses the selection to its edge
+	b.Left(false)
+	assert.False(t, b.HasSelection())
+	assert.Equal(t, 0, b.caret)
+
+	b.WordRight(true)
+	assert.Equal(t, "hello", b.SelectedText())
+	b.Insert("bye")
+	assert.Equal(t, "bye world", b.String())
+
+	b.SelectAll()
+	assert.True(t, b.DeleteBackward())
+	assert.Equal(t, "", b.String())
+}
+
+func TestTextBufferWordJumps(t *testing.T) {
+	b := newTextBuffer("one, two  three")
+	b.WordLeft(false)
+	assert.Equal(t, 10, b.caret)
+	b.WordLeft(false)
+	assert.Equal(t, 5, b.caret)
+	b.WordLeft(false)
+	assert.Equal(t, 3, b.caret, "Punctuation is its own word")
+
+	b.MoveTo(0, false)
+	b.WordRight(false)
+	assert.Equal(t, 3, b.caret)
+	b.WordRight(false)
+	assert.Equal(t, 4, b.caret)
+	b.WordRight(false)
+	assert.Equal(t, 8, b.caret)
+}
+
+func TestTextBufferVerticalNavigation(t *testing.T) {
+	b := newTextBuffer("long first line\nab\nthird line")
+	b.MoveTo(b.PosAt(0, 10), false)
+
+	b.Down(false)
+	line, col := b.LineCol(b.caret)
+	assert.Equal(t, 1, line)
+	assert.Equal(t, 2, col, "Short lines clamp the column")
+
+	b.Down(false)
+	line, col = b.LineCol(b.caret)
+	assert.Equal(t, 2, line)
+	assert.Equal(t, 10, col, "The goal column survives short lines")
+
+	b.Down(false)
+	assert.Equal(t, len(b.text), b.caret, "Down on the last line goes to the end")
+
+	b.Up(true)
+	b.Up(true)
+	assert.Equal(t, " line\nab\nthird line", b.SelectedText())
+
+	b.LineStart(false)
+	assert.Equal(t, 0, b.caret)
+	b.LineEnd(false)
+	assert.Equal(t, 15, b.caret)
+}
+
+// FuzzTextBufferEditing drives the buffer with random keys over text full of
+// multi-rune clusters; the caret and anchor must never split one.
+func FuzzTextBufferEditing(f *testing.F) {
+	f.Add("héllo", []byte{0, 1, 2, 3, 4, 5, 6, 7})
+	f.Add("👩\u200d👩\u200d👧 🇯🇵", []byte{3, 3, 1, 8, 9, 2, 0})
+	f.Add("e\u0301\u0301\nx", []byte{10, 11, 1, 1, 1, 0, 4})
+
+	inserts := []string{"é", "e\u0301", "\u0301", "👍🏽", "🇫🇷", "\n", "x"}
+	f.Fuzz(func(t *testing.T, text string, keys []byte) {
+		if !utf8.ValidString(text) {
+			t.Skip()
+		}
+		b := newTextBuffer(text)
+		for i, key := range keys {
+			switch key % 12 {
+			case 0:
+				b.Insert(inserts[i%len(inserts)])
+			case 1:
+				b.DeleteBackward()
+			case 2:
+				b.DeleteForward()
+			case 3:
+				b.Left(key&0x80 != 0)
+			case 4:
+				b.Right(key&0x80 != 0)
+			case 5:
+				b.Up(key&0x80 != 0)
+			case 6:
+				b.Down(key&0x80 != 0)
+			case 7:
+				b.WordLeft(key&0x80 != 0)
+			case 8:
+				b.WordRight(key&0x80 != 0)
+			case 9:
+				b.LineEnd(key&0x80 != 0)
+			case 10:
+				b.MoveTo(int(key), key&0x80 != 0)
+			case 11:
+				b.SelectAll()
+			}
+
+			if !utf8.ValidString(b.String()) {
+				t.Fatalf("invalid UTF-8 %q after key %d", b.String(), key)
+			}
+			bounds := graphemeBounds(b.text)
+			for _, pos := range []int{b.caret, b.anchor} {
+				if b.snapBack(pos) != pos {
+					t.Fatalf("position %d splits a cluster of %q (bounds %v)", pos, b.String(), bounds)
+				}
+			}
+		}
+	})
+}
+
+func TestCleanText(t *testing.T) {
+	assert.Equal(t, "a\nb\nc\td", cleanText("a\r\nb\rc\td\x07"))
+	assert.Equal(t, "ok", cleanText("o\xffk"), "Invalid UTF-8 is dropped")
+}
+
+func TestParseLine(t *testing.T) {
+	todo := parseLine("  [] buy milk")
+	assert.Equal(t, lineTodo, todo.kind)
+	assert.Equal(t, "buy milk", todo.shown)
+	assert.Equal(t, 5, todo.start)
+
+	done := parseLine("[x] done")
+	assert.Equal(t, lineDone, done.kind)
+
+	bullet := parseLine("- item")
+	assert.Equal(t, lineBullet, bullet.kind)
+	assert.Equal(t, "• item", bullet.shown)
+	assert.Equal(t, 2, bullet.start)
+
+	assert.Equal(t, " ", parseLine("").shown)
+	assert.Equal(t, lineText, parseLine("a | b").kind)
+}
+
+func TestMosuWidgetKeyboardEditing(t *testing.T) {
+	card := NewMosuWidget("card-1", theme.CardBg, 0)
+	edits := 0
+	card.SetOnTextCommitted(func(before, after string) { edits++ })
+
+	for _, r := range "ab cd" {
+		card.TypedRune(r)
+	}
+	card.TypedKey(&fyne.KeyEvent{Name: fyne.KeyHome})
+	card.KeyDown(&fyne.KeyEvent{Name: desktop.KeyShiftLeft})
+	card.TypedShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyRight, Modifier: fyne.KeyModifierControl | fyne.KeyModifierShift})
+	card.KeyUp(&fyne.KeyEvent{Name: desktop.KeyShiftLeft})
+	card.TypedRune('X')
+	assert.Equal(t, "X cd", card.GetText())
+
+	card.TypedKey(&fyne.KeyEvent{Name: fyne.KeyReturn})
+	card.TypedKey(&fyne.KeyEvent{Name: fyne.KeyUp})
+	card.TypedKey(&fyne.KeyEvent{Name: fyne.KeyDelete})
+	assert.Equal(t, "\n cd", card.GetText())
+
+	// Caret moves and a Backspace at the start are not edits
+	card.TypedKey(&fyne.KeyEvent{Name: fyne.KeyBackspace})
+	assert.Equal(t, 8, edits)
+}
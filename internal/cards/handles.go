@@ -0,0 +1,99 @@
+package cards
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+
+	"github.com/F4tal1t/Mosugo/internal/theme"
+)
+
+// handleSize is the on-screen size of a resize handle square.
+const handleSize float32 = 8
+
+// ResizeHandle identifies a resize handle by the card edges it moves.
+type ResizeHandle int
+
+const (
+	HandleLeft ResizeHandle = 1 << iota
+	HandleTop
+	HandleRight
+	HandleBottom
+)
+
+// HandleNone means no handle.
+const HandleNone ResizeHandle = 0
+
+// ResizeHandles lists the handles drawn around a selected card.
+// Corners come first so they win where handles overlap on small cards.
+var ResizeHandles = []ResizeHandle{
+	HandleLeft | HandleTop,
+	HandleRight | HandleTop,
+	HandleRight | HandleBottom,
+	HandleLeft | HandleBottom,
+	HandleTop,
+	HandleRight,
+	HandleBottom,
+	HandleLeft,
+}
+
+// HandlePosition returns the center of handle h on a card of the given size,
+// relative to the card's top-left corner.
+func HandlePosition(h ResizeHandle, size fyne.Size) fyne.Position {
+	pos := fyne.NewPos(size.Width/2, size.Height/2)
+	if h&HandleLeft != 0 {
+		pos.X = 0
+	}
+	if h&HandleRight != 0 {
+		pos.X = size.Width
+	}
+	if h&HandleTop != 0 {
+		pos.Y = 0
+	}
+	if h&HandleBottom != 0 {
+		pos.Y = size.Height
+	}
+	return pos
+}
+
+// HandleAt returns the handle within tolerance of pos on a card of the given
+// size, or HandleNone. pos is relative to the card's top-left corner.
+func HandleAt(size fyne.Size, pos fyne.Position, tolerance float32) ResizeHandle {
+	for _, h := range ResizeHandles {
+		center := HandlePosition(h, size)
+		dx, dy := pos.X-center.X, pos.Y-center.Y
+		if dx >= -tolerance && dx <= tolerance && dy >= -tolerance && dy <= tolerance {
+			return h
+		}
+	}
+	return HandleNone
+}
+
+// handleLayout centers one handle square on each entry of ResizeHandles.
+type handleLayout struct{}
+
+func (l *handleLayout) Layout(objects []fyne.CanvasObject, size fyne.Size) {
+	for i, obj := range objects {
+		if i >= len(ResizeHandles) {
+			break
+		}
+		center := HandlePosition(ResizeHandles[i], size)
+		obj.Resize(fyne.NewSize(handleSize, handleSize))
+		obj.Move(center.SubtractXY(handleSize/2, handleSize/2))
+	}
+}
+
+func (l *handleLayout) MinSize(objects []fyne.CanvasObject) fyne.Size {
+	return fyne.NewSize(0, 0)
+}
+
+func newHandleSquares() []fyne.CanvasObject {
+	squares := make([]fyne.CanvasObject, len(ResizeHandles))
+	for i := range squares {
+		square := canvas.NewRectangle(theme.InkWhite)
+		square.StrokeColor = theme.SelectionBlue
+		square.StrokeWidth = 1.5
+		square.CornerRadius = 2
+		squares[i] = square
+	}
+	return squares
+}
@@ -7,10 +7,13 @@ import (
 	"image/color"
 	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/driver/desktop"
 	"fyne.io/fyne/v2/widget"
 
 	"github.com/F4tal1t/Mosugo/internal/theme"
@@ -35,7 +38,7 @@ func newColoredLabel(text string, col color.Color) *coloredLabel {
 func (l *coloredLabel) CreateRenderer() fyne.WidgetRenderer {
 	l.ExtendBaseWidget(l)
 	text := canvas.NewText(l.Text, l.TextColor)
-	text.TextSize = 14
+	text.TextSize = cardTextSize
 	text.Alignment = fyne.TextAlignLeading
 
 	return &coloredLabelRenderer{
@@ -153,22 +156,29 @@ type MosuWidget struct {
 	WorldSize fyne.Size
 
 	bg          *canvas.Rectangle
+	ink         color.Color // text color that reads on bg
 	contentVBox *fyne.Container
+	handles     *fyne.Container // resize handles, shown while the only selection
 	container   *fyne.Container
 
-	isSelected  bool
-	rawText     string
-	cursorPos   int
-	cursorLine  int // cached line index where cursor currently is
+	scroll  *container.Scroll
+	overlay *fyne.Container // caret and selection, stacked over contentVBox
+	caret   *canvas.Rectangle
+
+	isSelected bool
+	buf        *textBuffer
+	shiftDown  bool // extends the text selection on caret moves
 
 	cursorVisible   bool
 	cursorTicker    *time.Ticker
-	cursorLabel     *coloredLabel // label on the cursor line, for direct text toggle
 	hasFocus        bool
 	uiReady         bool
 	onDirty         func()
 	onTextCommitted func(before, after string)
+	onFocusChanged  func(focused bool)
 	onShortcut      func(shortcut fyne.Shortcut)
+	onContextMenu   func(e *fyne.PointEvent)
+	onTapped        func(e *fyne.PointEvent)
 }
 
 func NewMosuWidget(id string, c color.Color, colorIndex int) *MosuWidget {
@@ -176,6 +186,7 @@ func NewMosuWidget(id string, c color.Color, colorIndex int) *MosuWidget {
 		ID:         id,
 		CreatedAt:  time.Now(),
 		ColorIndex: colorIndex,
+		ink:        theme.CardInk(colorIndex),
 	}
 	m.ExtendBaseWidget(m)
 
@@ -185,20 +196,23 @@ func NewMosuWidget(id string, c color.Color, colorIndex int) *MosuWidget {
 	m.bg.StrokeWidth = 0
 	m.bg.FillColor = c
 
-	m.rawText = ""
-	m.cursorPos = 0
-	m.cursorLine = 0
+	m.buf = newTextBuffer("")
 	m.contentVBox = container.NewVBox()
 	m.contentVBox.Layout = &compactVBoxLayout{spacing: 2}
 
-	scrollContent := container.NewVScroll(m.contentVBox)
+	m.caret = canvas.NewRectangle(m.ink)
+	m.overlay = container.New(&caretLayout{card: m})
+	m.scroll = container.NewVScroll(container.NewStack(m.contentVBox, m.overlay))
 
 	paddedContent := container.New(
-		&paddedLayout{padding: 16},
-		scrollContent,
+		&paddedLayout{padding: cardPadding},
+		m.scroll,
 	)
 
-	m.container = container.NewStack(m.bg, paddedContent)
+	m.handles = container.New(&handleLayout{}, newHandleSquares()...)
+	m.handles.Hide()
+
+	m.container = container.NewStack(m.bg, paddedContent, m.handles)
 
 	return m
 }
@@ -220,43 +234,19 @@ func (m *MosuWidget) startCursorTicker() {
 					return
 				}
 				m.cursorVisible = !m.cursorVisible
-				m.toggleCursorChar()
+				m.blinkCaret()
 			})
 		}
 	}()
 }
 
-// toggleCursorChar adds or removes the cursor character from the cursor line label,
-// without rebuilding the entire content VBox.
-func (m *MosuWidget) toggleCursorChar() {
-	if m.cursorLabel == nil || m.cursorLine < 0 {
-		return
-	}
-
-	lines := strings.Split(m.rawText, "\n")
-	if m.cursorLine >= len(lines) {
-		return
-	}
-
-	originalLine := lines[m.cursorLine]
-
-	// Remove any existing cursor marker to get the clean line
-	cleanLine := strings.ReplaceAll(originalLine, "|", "")
-
-	if m.cursorVisible {
-		// Insert cursor at the correct column position
-		// Calculate the column on this line
-		textBefore := m.rawText[:m.cursorPos]
-		preLines := strings.Split(textBefore, "\n")
-		col := len(preLines[len(preLines)-1])
-		if col > len(cleanLine) {
-			col = len(cleanLine)
-		}
-		cleanLine = cleanLine[:col] + "|" + cleanLine[col:]
+// blinkCaret shows or hides the caret without laying the card out again.
+func (m *MosuWidget) blinkCaret() {
+	if m.cursorVisible && m.hasFocus {
+		m.caret.Show()
+	} else {
+		m.caret.Hide()
 	}
-
-	m.cursorLabel.Text = cleanLine
-	m.cursorLabel.Refresh()
 }
 
 // customCheck is a circular checkbox widget
@@ -264,13 +254,15 @@ type customCheck struct {
 	widget.BaseWidget
 	Label        string
 	Checked      bool
+	Ink          color.Color
 	OnTappedFunc func(bool)
 }
 
-func newCustomCheck(label string, checked bool, cb func(bool)) *customCheck {
+func newCustomCheck(label string, checked bool, ink color.Color, cb func(bool)) *customCheck {
 	c := &customCheck{
 		Label:        label,
 		Checked:      checked,
+		Ink:          ink,
 		OnTappedFunc: cb,
 	}
 	c.ExtendBaseWidget(c)
@@ -279,16 +271,16 @@ func newCustomCheck(label string, checked bool, cb func(bool)) *customCheck {
 
 func (c *customCheck) CreateRenderer() fyne.WidgetRenderer {
 	ring := canvas.NewCircle(color.Transparent)
-	ring.StrokeColor = theme.InkWhite
+	ring.StrokeColor = c.Ink
 	ring.StrokeWidth = 2
 
-	dot := canvas.NewCircle(theme.InkWhite)
+	dot := canvas.NewCircle(c.Ink)
 	if !c.Checked {
 		dot.Hide()
 	}
 
 	// Use colored label for text wrapping
-	label := newColoredLabel(c.Label, theme.InkWhite)
+	label := newColoredLabel(c.Label, c.Ink)
 
 	return &customCheckRenderer{
 		check: c,
@@ -330,7 +322,6 @@ func (r *customCheckRenderer) Refresh() {
 
 func (r *customCheckRenderer) Layout(s fyne.Size) {
 	iconSize := float32(16)
-	padding := float32(8)
 
 	r.ring.Resize(fyne.NewSize(iconSize, iconSize))
 	r.ring.Move(fyne.NewPos(0, 4)) //
@@ -340,13 +331,13 @@ func (r *customCheckRenderer) Layout(s fyne.Size) {
 	r.dot.Move(fyne.NewPos(3, 7)) //
 
 	// Label positioning with wrapping
-	labelWidth := s.Width - (iconSize + padding)
+	labelWidth := s.Width - checkLabelIndent
 	if labelWidth < 10 {
 		labelWidth = 10
 	}
 	labelMinSize := r.label.MinSize()
 	r.label.Resize(fyne.NewSize(labelWidth, labelMinSize.Height))
-	r.label.Move(fyne.NewPos(iconSize+padding, 0))
+	r.label.Move(fyne.NewPos(checkLabelIndent, 0))
 }
 
 func (r *customCheckRenderer) MinSize() fyne.Size {
@@ -360,80 +351,33 @@ func (m *MosuWidget) RefreshContent() {
 	}
 
 	m.contentVBox.Objects = nil
-	m.cursorLabel = nil
 
-	textToRender := m.rawText
-
-	lines := strings.Split(textToRender, "\n")
+	// One object per line, so line indexes match contentVBox.Objects
+	lines := strings.Split(m.buf.String(), "\n")
 	for i, line := range lines {
-		trimLine := strings.TrimSpace(line)
 		lineIdx := i
+		view := parseLine(line)
 
-		if strings.HasPrefix(trimLine, "[] ") || strings.HasPrefix(trimLine, "[ ] ") {
-			label := strings.TrimPrefix(trimLine, "[] ")
-			label = strings.TrimPrefix(label, "[ ] ")
-
-			chk := newCustomCheck(label, false, func(b bool) {
-				m.toggleLineState(lineIdx, b)
-			})
-			m.contentVBox.Add(chk)
-
-		} else if strings.HasPrefix(trimLine, "[x] ") || strings.HasPrefix(trimLine, "[X] ") {
-			label := strings.TrimPrefix(trimLine, "[x] ")
-			label = strings.TrimPrefix(label, "[X] ")
-
-			chk := newCustomCheck(label, true, func(b bool) {
+		switch view.kind {
+		case lineTodo, lineDone:
+			chk := newCustomCheck(view.shown, view.kind == lineDone, m.ink, func(b bool) {
 				m.toggleLineState(lineIdx, b)
 			})
 			m.contentVBox.Add(chk)
-
-		} else if strings.HasPrefix(trimLine, "- ") {
-			labelTxt := strings.TrimPrefix(trimLine, "- ")
-			label := newColoredLabel("• "+labelTxt, theme.InkWhite)
-			m.contentVBox.Add(label)
-		} else if trimLine != "" {
-			// Regular text — store reference if this is the cursor line
-			label := newColoredLabel(line, theme.InkWhite)
-			if i == m.cursorLine {
-				m.cursorLabel = label
-			}
-			m.contentVBox.Add(label)
-		} else {
-			// Empty line - add small spacer
-			spacer := newColoredLabel(" ", theme.InkWhite)
-			if i == m.cursorLine {
-				m.cursorLabel = spacer
-			}
-			m.contentVBox.Add(spacer)
+		default:
+			m.contentVBox.Add(newColoredLabel(view.shown, m.ink))
 		}
 	}
 	m.contentVBox.Refresh()
-
-	// If focused and cursor should be visible, inject it into the cursor label
-	if m.hasFocus && m.cursorVisible && m.cursorLabel != nil {
-		cleanLine := strings.ReplaceAll(m.cursorLabel.Text, "|", "")
-		textBefore := m.rawText[:m.cursorPos]
-		preLines := strings.Split(textBefore, "\n")
-		col := len(preLines[len(preLines)-1])
-		if col > len(cleanLine) {
-			col = len(cleanLine)
-		}
-		m.cursorLabel.Text = cleanLine[:col] + "|" + cleanLine[col:]
-	}
-}
-
-func (m *MosuWidget) updateCursorLine() {
-	textBefore := m.rawText[:m.cursorPos]
-	lines := strings.Split(textBefore, "\n")
-	m.cursorLine = len(lines) - 1
+	m.layoutCaret()
 }
 
 func (m *MosuWidget) toggleLineState(lineIdx int, checked bool) {
-	lines := strings.Split(m.rawText, "\n")
+	lines := strings.Split(m.buf.String(), "\n")
 	if lineIdx < 0 || lineIdx >= len(lines) {
 		return
 	}
-	before := m.rawText
+	before := m.buf.String()
 
 	line := lines[lineIdx]
 	trimLine := strings.TrimSpace(line)
@@ -462,15 +406,23 @@ func (m *MosuWidget) toggleLineState(lineIdx int, checked bool) {
 		newPrefix = "[x] "
 	}
 	lines[lineIdx] = newPrefix + content
-	m.rawText = strings.Join(lines, "\n")
+	m.buf.ReplaceText(strings.Join(lines, "\n"))
 	if m.onTextCommitted != nil {
-		m.onTextCommitted(before, m.rawText)
+		m.onTextCommitted(before, m.buf.String())
 	}
 	m.markDirty()
 	m.RefreshContent()
 }
 
-func (m *MosuWidget) Tapped(_ *fyne.PointEvent) {
+func (m *MosuWidget) Tapped(e *fyne.PointEvent) {
+	if m.onTapped != nil {
+		m.onTapped(e)
+	}
+	// Place the caret where the card was clicked
+	if m.uiReady {
+		m.buf.MoveTo(m.caretAt(e.Position), false)
+		m.caretMoved()
+	}
 	// Focus this card for keyboard input
 	c := fyne.CurrentApp().Driver().CanvasForObject(m)
 	if c != nil {
@@ -478,101 +430,178 @@ func (m *MosuWidget) Tapped(_ *fyne.PointEvent) {
 	}
 }
 
+// TappedSecondary hands right-clicks to the context menu callback.
+func (m *MosuWidget) TappedSecondary(e *fyne.PointEvent) {
+	if m.onContextMenu != nil {
+		m.onContextMenu(e)
+	}
+}
+
 func (m *MosuWidget) FocusGained() {
 	m.hasFocus = true
-	m.cursorVisible = true
-	m.updateCursorLine()
-	m.RefreshContent()
-	m.toggleCursorChar() // eagerly show cursor without waiting for ticker
+	m.cursorVisible = true // eagerly show cursor without waiting for ticker
+	m.layoutCaret()
+	if m.onFocusChanged != nil {
+		m.onFocusChanged(true)
+	}
 }
 
 func (m *MosuWidget) FocusLost() {
 	m.hasFocus = false
 	m.cursorVisible = false
-	m.cursorLabel = nil
-	m.RefreshContent()
+	m.shiftDown = false
+	m.buf.collapse(m.buf.caret)
+	m.layoutCaret()
+	if m.onFocusChanged != nil {
+		m.onFocusChanged(false)
+	}
 }
 
-func (m *MosuWidget) TypedRune(r rune) {
-	before := m.rawText
-
-	if m.cursorPos < 0 {
-		m.cursorPos = 0
-	}
-	if m.cursorPos > len(m.rawText) {
-		m.cursorPos = len(m.rawText)
+// KeyDown tracks Shift, which turns caret moves into selections.
+func (m *MosuWidget) KeyDown(key *fyne.KeyEvent) {
+	if key.Name == desktop.KeyShiftLeft || key.Name == desktop.KeyShiftRight {
+		m.shiftDown = true
 	}
+}
 
-	m.rawText = m.rawText[:m.cursorPos] + string(r) + m.rawText[m.cursorPos:]
-	m.cursorPos++
-	m.cursorVisible = true // Keep cursor visible while typing
-	m.updateCursorLine()
-	if m.onTextCommitted != nil {
-		m.onTextCommitted(before, m.rawText)
+func (m *MosuWidget) KeyUp(key *fyne.KeyEvent) {
+	if key.Name == desktop.KeyShiftLeft || key.Name == desktop.KeyShiftRight {
+		m.shiftDown = false
 	}
-	m.markDirty()
+}
 
-	m.RefreshContent()
+func (m *MosuWidget) TypedRune(r rune) {
+	// Line breaks come in through TypedKey; other control characters and
+	// runes that cannot be encoded are not text
+	if !utf8.ValidRune(r) || unicode.IsControl(r) {
+		return
+	}
+	m.edit(func() bool {
+		m.buf.Insert(string(r))
+		return true
+	})
 }
 
 func (m *MosuWidget) TypedKey(key *fyne.KeyEvent) {
-	updated := false
+	extend := m.shiftDown
 	switch key.Name {
 	case fyne.KeyBackspace:
-		if m.cursorPos > 0 {
-			before := m.rawText
-			m.rawText = m.rawText[:m.cursorPos-1] + m.rawText[m.cursorPos:]
-			m.cursorPos--
-			updated = true
-			if m.onTextCommitted != nil {
-				m.onTextCommitted(before, m.rawText)
-			}
-			m.markDirty()
-		}
+		m.edit(m.buf.DeleteBackward)
+		return
 	case fyne.KeyDelete:
-		if m.cursorPos < len(m.rawText) {
-			before := m.rawText
-			m.rawText = m.rawText[:m.cursorPos] + m.rawText[m.cursorPos+1:]
-			updated = true
-			if m.onTextCommitted != nil {
-				m.onTextCommitted(before, m.rawText)
-			}
-			m.markDirty()
-		}
-	case fyne.KeyReturn:
-		before := m.rawText
-		m.rawText = m.rawText[:m.cursorPos] + "\n" + m.rawText[m.cursorPos:]
-		m.cursorPos++
-		updated = true
-		if m.onTextCommitted != nil {
-			m.onTextCommitted(before, m.rawText)
-		}
-		m.markDirty()
-	case fyne.KeyLeft:
-		if m.cursorPos > 0 {
-			m.cursorPos--
-		}
-		m.updateCursorLine()
-		m.RefreshContent()
+		m.edit(m.buf.DeleteForward)
+		return
+	case fyne.KeyReturn, fyne.KeyEnter:
+		m.edit(func() bool {
+			m.buf.Insert("\n")
+			return true
+		})
 		return
+	case fyne.KeyLeft:
+		m.buf.Left(extend)
 	case fyne.KeyRight:
-		if m.cursorPos < len(m.rawText) {
-			m.cursorPos++
-		}
-		m.updateCursorLine()
-		m.RefreshContent()
+		m.buf.Right(extend)
+	case fyne.KeyUp:
+		m.buf.Up(extend)
+	case fyne.KeyDown:
+		m.buf.Down(extend)
+	case fyne.KeyHome:
+		m.buf.LineStart(extend)
+	case fyne.KeyEnd:
+		m.buf.LineEnd(extend)
+	default:
 		return
-	case fyne.KeyUp, fyne.KeyDown, fyne.KeyHome, fyne.KeyEnd:
+	}
+	m.caretMoved()
+}
+
+// edit applies a change to the text and reports it, if fn made one.
+func (m *MosuWidget) edit(fn func() bool) {
+	before := m.buf.String()
+	if !fn() {
 		return
 	}
+	m.cursorVisible = true // Keep cursor visible while typing
+	if m.onTextCommitted != nil {
+		m.onTextCommitted(before, m.buf.String())
+	}
+	m.markDirty()
+	m.RefreshContent()
+}
+
+// caretMoved redraws the caret after a move that left the text alone.
+func (m *MosuWidget) caretMoved() {
+	m.cursorVisible = true
+	m.layoutCaret()
+}
 
-	if updated {
-		m.updateCursorLine()
-		m.RefreshContent()
+// navigate handles the caret shortcuts: word jumps on Ctrl (or Alt) with the
+// arrows, text start and end on Ctrl with Home and End, and select all.
+func (m *MosuWidget) navigate(shortcut fyne.Shortcut) bool {
+	if _, ok := shortcut.(*fyne.ShortcutSelectAll); ok {
+		m.buf.SelectAll()
+		return true
+	}
+	custom, ok := shortcut.(*desktop.CustomShortcut)
+	if !ok || custom.Modifier&(fyne.KeyModifierControl|fyne.KeyModifierAlt) == 0 {
+		return false
+	}
+	extend := custom.Modifier&fyne.KeyModifierShift != 0
+	switch custom.KeyName {
+	case fyne.KeyLeft:
+		m.buf.WordLeft(extend)
+	case fyne.KeyRight:
+		m.buf.WordRight(extend)
+	case fyne.KeyHome:
+		m.buf.MoveTo(0, extend)
+	case fyne.KeyEnd:
+		m.buf.MoveTo(len(m.buf.text), extend)
+	default:
+		return false
+	}
+	return true
+}
+
+// clipboard handles cut, copy and paste. A paste is one edit however many
+// lines it brings, so it undoes in one step.
+func (m *MosuWidget) clipboard(shortcut fyne.Shortcut) bool {
+	switch sc := shortcut.(type) {
+	case *fyne.ShortcutCopy:
+		if sc.Clipboard != nil && m.buf.HasSelection() {
+			sc.Clipboard.SetContent(m.buf.SelectedText())
+		}
+	case *fyne.ShortcutCut:
+		if sc.Clipboard != nil && m.buf.HasSelection() {
+			sc.Clipboard.SetContent(m.buf.SelectedText())
+			m.edit(m.buf.DeleteBackward)
+		}
+	case *fyne.ShortcutPaste:
+		if sc.Clipboard == nil {
+			return true
+		}
+		text := cleanText(sc.Clipboard.Content())
+		m.edit(func() bool {
+			if text == "" && !m.buf.HasSelection() {
+				return false
+			}
+			m.buf.Insert(text)
+			return true
+		})
+	default:
+		return false
 	}
+	return true
 }
 
 func (m *MosuWidget) TypedShortcut(shortcut fyne.Shortcut) {
+	if m.navigate(shortcut) {
+		m.caretMoved()
+		return
+	}
+	if m.clipboard(shortcut) {
+		m.caretMoved()
+		return
+	}
 	if m.onShortcut != nil {
 		m.onShortcut(shortcut)
 	}
@@ -591,6 +620,25 @@ func (m *MosuWidget) SetSelected(selected bool) {
 	m.bg.Refresh()
 }
 
+// ShowHandles shows or hides the resize handles around the card.
+func (m *MosuWidget) ShowHandles(show bool) {
+	if show {
+		m.handles.Show()
+	} else {
+		m.handles.Hide()
+	}
+}
+
+// SetColorIndex switches the card to color i of the card palette.
+func (m *MosuWidget) SetColorIndex(i int) {
+	m.ColorIndex = i
+	m.ink = theme.CardInk(i)
+	m.bg.FillColor = theme.CardColor(i)
+	m.caret.FillColor = m.ink
+	m.bg.Refresh()
+	m.RefreshContent()
+}
+
 func (m *MosuWidget) CreateRenderer() fyne.WidgetRenderer {
 	m.uiReady = true
 	m.startCursorTicker()
@@ -610,14 +658,12 @@ func (m *MosuWidget) RefreshLayout(zoom float32) {
 
 // GetText returns the raw text content of the card
 func (m *MosuWidget) GetText() string {
-	return m.rawText
+	return m.buf.String()
 }
 
-// SetText sets the text content of the card and refreshes the display
+// SetText sets the text content of the card and puts the caret at its end
 func (m *MosuWidget) SetText(text string) {
-	m.rawText = text
-	m.cursorPos = len(text)
-	m.updateCursorLine()
+	m.buf.SetText(text)
 }
 
 // SetOnDirty registers a callback that runs whenever the card content changes.
@@ -630,13 +676,28 @@ func (m *MosuWidget) SetOnTextCommitted(callback func(before, after string)) {
 	m.onTextCommitted = callback
 }
 
+// SetOnFocusChanged registers a callback fired when the card gains or loses focus.
+func (m *MosuWidget) SetOnFocusChanged(callback func(focused bool)) {
+	m.onFocusChanged = callback
+}
+
 // SetOnShortcut registers a callback for focused keyboard shortcuts.
 func (m *MosuWidget) SetOnShortcut(callback func(shortcut fyne.Shortcut)) {
 	m.onShortcut = callback
 }
 
+// SetOnContextMenu registers a callback for right-clicks on the card.
+func (m *MosuWidget) SetOnContextMenu(callback func(e *fyne.PointEvent)) {
+	m.onContextMenu = callback
+}
+
+// SetOnTapped registers a callback for left-clicks on the card.
+func (m *MosuWidget) SetOnTapped(callback func(e *fyne.PointEvent)) {
+	m.onTapped = callback
+}
+
 func (m *MosuWidget) markDirty() {
 	if m.onDirty != nil {
 		m.onDirty()
 	}
-}
\ No newline at end of file
+}
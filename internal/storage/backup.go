@@ -0,0 +1,110 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// BackupCount is how many earlier versions of a workspace file are kept,
+// as YYYY-MM-DD.mosugo.1.bak (the newest) to YYYY-MM-DD.mosugo.N.bak.
+const BackupCount = 5
+
+// backupPath returns the path of the n-th newest backup of a file
+func backupPath(filePath string, n int) string {
+	return fmt.Sprintf("%s.%d.bak", filePath, n)
+}
+
+// writeFileAtomic replaces the contents of filePath with data so that a
+// crash leaves either the old file or the new one, never a partial write:
+// data goes to a temporary file in the same directory, is synced to disk,
+// and is then renamed over filePath.
+func writeFileAtomic(filePath string, data []byte) error {
+	dir := filepath.Dir(filePath)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(filePath)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmpPath, 0644)
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, filePath)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	// Sync the directory so the rename itself survives a power loss. Not
+	// every platform can open a directory for syncing, so this is best effort.
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+	return nil
+}
+
+// rotateBackups keeps the current contents of filePath as its newest backup
+// before it is replaced by data, shifting older backups down and dropping
+// the oldest. Nothing rotates when the file is missing, already holds data,
+// or is not valid JSON: a broken file must not push out good backups.
+func rotateBackups(filePath string, data []byte) error {
+	current, err := os.ReadFile(filePath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(current, data) || !json.Valid(current) {
+		return nil
+	}
+
+	for n := BackupCount - 1; n >= 1; n-- {
+		err := os.Rename(backupPath(filePath, n), backupPath(filePath, n+1))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return writeFileAtomic(backupPath(filePath, 1), current)
+}
+
+// loadNewestBackup returns the newest backup of filePath that parses, and
+// its path.
+func loadNewestBackup(filePath string) (WorkspaceState, string, bool) {
+	for n := 1; n <= BackupCount; n++ {
+		path := backupPath(filePath, n)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		state, err := decodeWorkspace(data)
+		if err != nil {
+			continue
+		}
+		return state, path, true
+	}
+	return WorkspaceState{}, "", false
+}
+
+// removeBackups deletes every backup of filePath
+func removeBackups(filePath string) error {
+	for n := 1; n <= BackupCount; n++ {
+		if err := os.Remove(backupPath(filePath, n)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func workspaceWithCard(content string) WorkspaceState {
+	return WorkspaceState{
+		Scale: 1.0,
+		Cards: []MosuData{{ID: "card-1", Content: content, Width: 120, Height: 90}},
+	}
+}
+
+// TestSaveWorkspaceKeepsBackups tests backup rotation
+func TestSaveWorkspaceKeepsBackups(t *testing.T) {
+	s := newTestStore(t)
+	testDate := getTestDate(22)
+
+	filePath := s.workspacePath(testDate)
+
+	for i := 0; i <= BackupCount+1; i++ {
+		require.NoError(t, s.SaveWorkspace(testDate, workspaceWithCard(fmt.Sprint("version ", i))))
+	}
+	// Saving the same state again rotates nothing
+	require.NoError(t, s.SaveWorkspace(testDate, workspaceWithCard(fmt.Sprint("version ", BackupCount+1))))
+
+	for n := 1; n <= BackupCount; n++ {
+		data, err := os.ReadFile(backupPath(filePath, n))
+		require.NoError(t, err)
+		assert.Contains(t, string(data), fmt.Sprint("version ", BackupCount+1-n), "Backup %d", n)
+	}
+	_, err := os.Stat(backupPath(filePath, BackupCount+1))
+	assert.True(t, os.IsNotExist(err), "Only BackupCount backups are kept")
+
+	// No temporary files are left behind
+	files, err := os.ReadDir(s.Root())
+	require.NoError(t, err)
+	for _, f := range files {
+		assert.False(t, strings.HasSuffix(f.Name(), ".tmp"), "Leftover %s", f.Name())
+	}
+
+	require.NoError(t, s.DeleteWorkspace(testDate))
+	_, err = os.Stat(backupPath(filePath, 1))
+	assert.True(t, os.IsNotExist(err), "Deleting a workspace deletes its backups")
+}
+
+// TestLoadWorkspaceFallsBackToBackup tests recovery from a corrupt workspace file
+func TestLoadWorkspaceFallsBackToBackup(t *testing.T) {
+	s := newTestStore(t)
+	testDate := getTestDate(23)
+
+	filePath := s.workspacePath(testDate)
+
+	require.NoError(t, s.SaveWorkspace(testDate, workspaceWithCard("first")))
+	require.NoError(t, s.SaveWorkspace(testDate, workspaceWithCard("second")))
+	require.NoError(t, s.SaveWorkspace(testDate, workspaceWithCard("third")))
+
+	// A truncated workspace file and a damaged newest backup
+	require.NoError(t, os.WriteFile(filePath, []byte(`{"scale": 1.0, "cards": [{"id": "ca`), 0644))
+	require.NoError(t, os.WriteFile(backupPath(filePath, 1), []byte("{ invalid json }"), 0644))
+
+	loaded, err := s.LoadWorkspace(testDate)
+	require.NoError(t, err)
+	require.Len(t, loaded.Cards, 1)
+	assert.Equal(t, "first", loaded.Cards[0].Content)
+	require.NotNil(t, loaded.Recovery)
+	assert.Equal(t, filepath.Base(backupPath(filePath, 2)), filepath.Base(loaded.Recovery.RestoredFrom))
+
+	// Saving over a broken file keeps the good backups
+	require.NoError(t, s.SaveWorkspace(testDate, loaded))
+	data, err := os.ReadFile(backupPath(filePath, 2))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "first")
+
+	loaded, err = s.LoadWorkspace(testDate)
+	require.NoError(t, err)
+	assert.Nil(t, loaded.Recovery)
+}
@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+const (
+	// ClipFormat marks clipboard text as copied from Mosugo
+	ClipFormat = "mosugo/clip"
+	// ClipVersion is the newest clip layout this build reads and writes
+	ClipVersion = 1
+)
+
+// ErrNotClip is returned by DecodeClip for text that is not a Mosugo clip.
+var ErrNotClip = errors.New("clipboard does not hold Mosugo content")
+
+// Clip is the envelope copied cards and strokes travel in through the system
+// clipboard. Positions are world coordinates of the workspace they were
+// copied from, so a clip can be pasted into any day.
+type Clip struct {
+	Format  string       `json:"format"`
+	Version int          `json:"version"`
+	Cards   []MosuData   `json:"cards"`
+	Strokes []StrokeData `json:"strokes"`
+}
+
+// EncodeClip wraps cards and strokes in a clip envelope.
+func EncodeClip(cards []MosuData, strokes []StrokeData) (string, error) {
+	if cards == nil {
+		cards = []MosuData{}
+	}
+	if strokes == nil {
+		strokes = []StrokeData{}
+	}
+	data, err := json.Marshal(Clip{Format: ClipFormat, Version: ClipVersion, Cards: cards, Strokes: strokes})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal clip: %w", err)
+	}
+	return string(data), nil
+}
+
+// DecodeClip reads a clip from clipboard text. Text from other applications,
+// and clips written by a newer Mosugo, give ErrNotClip.
+func DecodeClip(text string) (Clip, error) {
+	var clip Clip
+	if err := json.Unmarshal([]byte(text), &clip); err != nil {
+		return Clip{}, ErrNotClip
+	}
+	if clip.Format != ClipFormat {
+		return Clip{}, ErrNotClip
+	}
+	if clip.Version < 1 || clip.Version > ClipVersion {
+		return Clip{}, fmt.Errorf("%w: unsupported clip version %d", ErrNotClip, clip.Version)
+	}
+	return clip, nil
+}
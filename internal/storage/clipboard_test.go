@@ -0,0 +1,36 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestClipRoundtrip tests that cards and strokes survive the clipboard envelope
+func TestClipRoundtrip(t *testing.T) {
+	cards := []MosuData{{ID: "card_1", Content: "héllo 👋", PosX: 30, PosY: 60, Width: 120, Height: 90, ColorIdx: 2}}
+	strokes := []StrokeData{{P1X: 1, P1Y: 2, P2X: 3, P2Y: 4, ColorIdx: 1, Width: 2.5, StrokeID: 7}}
+
+	text, err := EncodeClip(cards, strokes)
+	require.NoError(t, err)
+
+	clip, err := DecodeClip(text)
+	require.NoError(t, err)
+	assert.Equal(t, ClipVersion, clip.Version)
+	assert.Equal(t, cards[0].Content, clip.Cards[0].Content)
+	assert.Equal(t, cards[0].PosX, clip.Cards[0].PosX)
+	assert.Equal(t, strokes, clip.Strokes)
+}
+
+// TestDecodeClipRejectsForeignText tests that only Mosugo clips are accepted
+func TestDecodeClipRejectsForeignText(t *testing.T) {
+	for _, text := range []string{
+		"plain text",
+		`{"cards": []}`,
+		`{"format": "mosugo/clip", "version": 99}`,
+	} {
+		_, err := DecodeClip(text)
+		assert.ErrorIs(t, err, ErrNotClip, text)
+	}
+}
@@ -0,0 +1,120 @@
+package storage
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// addrFileName, in the instance directory, holds the address the instance
+// holding the lock listens on for forwarded requests, and the token they
+// must carry.
+const addrFileName = "mosugo.addr"
+
+// forwardTimeout bounds each side of a forwarded request, so a hung
+// instance cannot keep a new one from starting.
+const forwardTimeout = 2 * time.Second
+
+// Listen accepts requests to open a day, forwarded by later instances with
+// ForwardOpen, and calls onOpen with their dates on its own goroutine. It
+// listens on the loopback interface only.
+func (l *DirLock) Listen(onOpen func(date time.Time)) error {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return fmt.Errorf("failed to listen for forwarded requests: %w", err)
+	}
+
+	secret := make([]byte, 16)
+	if _, err := rand.Read(secret); err != nil {
+		listener.Close()
+		return fmt.Errorf("failed to create forwarding token: %w", err)
+	}
+	token := hex.EncodeToString(secret)
+
+	addr := listener.Addr().String() + "\n" + token + "\n"
+	if err := writeFileAtomic(filepath.Join(l.dir, addrFileName), []byte(addr)); err != nil {
+		listener.Close()
+		return fmt.Errorf("failed to write forwarding address: %w", err)
+	}
+
+	l.listener = listener
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return // closed by Unlock
+			}
+			go serveForwarded(conn, token, onOpen)
+		}
+	}()
+	return nil
+}
+
+// serveForwarded answers one request, a line of "<token> open YYYY-MM-DD",
+// with "ok" or "error <reason>"
+func serveForwarded(conn net.Conn, token string, onOpen func(date time.Time)) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(forwardTimeout))
+
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return
+	}
+	fields := strings.Fields(line)
+	if len(fields) != 3 || fields[0] != token || fields[1] != "open" {
+		fmt.Fprintln(conn, "error bad request")
+		return
+	}
+	date, err := time.Parse("2006-01-02", fields[2])
+	if err != nil {
+		fmt.Fprintln(conn, "error bad date")
+		return
+	}
+
+	fmt.Fprintln(conn, "ok")
+	onOpen(date)
+}
+
+// ForwardOpen asks the instance holding the store's directory to open date.
+// It fails when that instance does not answer, such as one that has not
+// started listening yet.
+func (s *FileStore) ForwardOpen(date time.Time) error {
+	dir, err := s.instanceDir()
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(filepath.Join(dir, addrFileName))
+	if err != nil {
+		return fmt.Errorf("failed to read forwarding address: %w", err)
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) != 2 {
+		return errors.New("forwarding address file is malformed")
+	}
+
+	conn, err := net.DialTimeout("tcp", fields[0], forwardTimeout)
+	if err != nil {
+		return fmt.Errorf("failed to reach the running instance: %w", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(forwardTimeout))
+
+	if _, err := fmt.Fprintf(conn, "%s open %s\n", fields[1], date.Format("2006-01-02")); err != nil {
+		return fmt.Errorf("failed to forward request: %w", err)
+	}
+	reply, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return fmt.Errorf("no answer from the running instance: %w", err)
+	}
+	if reply = strings.TrimSpace(reply); reply != "ok" {
+		return fmt.Errorf("running instance refused the request: %s", reply)
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// HistoryState is the undo and redo history of one day's workspace, saved
+// next to its .mosugo file. Both stacks are oldest first, so the last entry
+// of Undo is the step Ctrl+Z reverts next. How many steps there are is up to
+// the canvas's undo limit.
+type HistoryState struct {
+	Date string         `json:"date"` // YYYY-MM-DD format
+	Undo []HistoryEntry `json:"undo"`
+	Redo []HistoryEntry `json:"redo"`
+
+	// Workspace is the SHA-256 of the workspace the history was saved with.
+	// A history whose workspace has since changed, through a sync, an edit
+	// by hand or a restored backup, is not loaded, since its steps would
+	// damage the new contents.
+	Workspace string `json:"workspace,omitempty"`
+}
+
+// HistoryEntry is the stored form of one history step. Kind names the change;
+// the other fields hold what that kind needs, and Steps the parts of a step
+// that changed several objects at once. Only whole steps carry At.
+type HistoryEntry struct {
+	Kind     string         `json:"kind"`
+	CardID   string         `json:"card_id,omitempty"`
+	StrokeID int            `json:"stroke_id,omitempty"`
+	Card     *MosuData      `json:"card,omitempty"`
+	Segments []StrokeData   `json:"segments,omitempty"`
+	Before   *HistoryValue  `json:"before,omitempty"`
+	After    *HistoryValue  `json:"after,omitempty"`
+	Steps    []HistoryEntry `json:"steps,omitempty"`
+	At       *time.Time     `json:"at,omitempty"` // when the step was committed
+}
+
+// HistoryValue is one side of a change to a single object.
+type HistoryValue struct {
+	Text     string    `json:"text,omitempty"`
+	ColorIdx int       `json:"color_index,omitempty"`
+	PosX     float32   `json:"pos_x,omitempty"`
+	PosY     float32   `json:"pos_y,omitempty"`
+	Width    float32   `json:"width,omitempty"`
+	Height   float32   `json:"height,omitempty"`
+	Points   []float32 `json:"points,omitempty"` // x, y pairs
+}
+
+// historyPath returns the full path to the history file for a given date
+func (s *FileStore) historyPath(date time.Time) string {
+	// Format: YYYY-MM-DD.history
+	filename := date.Format("2006-01-02") + ".history"
+	return filepath.Join(s.root, filename)
+}
+
+// SaveHistory saves the undo history of a day, for the workspace the store
+// holds for it now.
+func (s *FileStore) SaveHistory(date time.Time, state HistoryState) error {
+	if s.readOnly {
+		return ErrReadOnly
+	}
+	state.Date = date.Format("2006-01-02")
+	state.Undo = nonNilEntries(state.Undo)
+	state.Redo = nonNilEntries(state.Redo)
+	state.Workspace = s.workspaceHash(date)
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to marshal history: %w", err)
+	}
+	if err := writeFileAtomic(s.historyPath(date), data); err != nil {
+		return fmt.Errorf("failed to write history file: %w", err)
+	}
+	return nil
+}
+
+// LoadHistory loads the undo history of a day. A day without a history file,
+// or whose workspace changed since its history was saved, has an empty
+// history.
+func (s *FileStore) LoadHistory(date time.Time) (HistoryState, error) {
+	data, err := os.ReadFile(s.historyPath(date))
+	if os.IsNotExist(err) {
+		return emptyHistory(date), nil
+	}
+	if err != nil {
+		return HistoryState{}, fmt.Errorf("failed to read history file: %w", err)
+	}
+
+	var state HistoryState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return HistoryState{}, fmt.Errorf("failed to unmarshal history: %w", err)
+	}
+	if state.Workspace != s.workspaceHash(date) {
+		return emptyHistory(date), nil
+	}
+	state.Undo = nonNilEntries(state.Undo)
+	state.Redo = nonNilEntries(state.Redo)
+	return state, nil
+}
+
+// workspaceHash identifies the contents of the workspace file of date; it is
+// empty when there is none
+func (s *FileStore) workspaceHash(date time.Time) string {
+	data, err := os.ReadFile(s.workspacePath(date))
+	if err != nil {
+		return ""
+	}
+	return contentHash(data)
+}
+
+func contentHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// emptyHistory is the history of a day that has none saved
+func emptyHistory(date time.Time) HistoryState {
+	return HistoryState{Date: date.Format("2006-01-02"), Undo: []HistoryEntry{}, Redo: []HistoryEntry{}}
+}
+
+// deleteHistory removes the history file of a day, if there is one.
+func (s *FileStore) deleteHistory(date time.Time) error {
+	if err := os.Remove(s.historyPath(date)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete history file: %w", err)
+	}
+	return nil
+}
+
+func nonNilEntries(entries []HistoryEntry) []HistoryEntry {
+	if entries == nil {
+		return []HistoryEntry{}
+	}
+	return entries
+}
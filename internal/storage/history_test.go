@@ -0,0 +1,69 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSaveAndLoadHistory tests history roundtrip next to a workspace
+func TestSaveAndLoadHistory(t *testing.T) {
+	s := newTestStore(t)
+	testDate := getTestDate(20)
+
+	empty, err := s.LoadHistory(testDate)
+	require.NoError(t, err, "A day without history loads empty")
+	assert.Empty(t, empty.Undo)
+	assert.Empty(t, empty.Redo)
+
+	card := MosuData{ID: "card_1", Content: "hi", Width: 120, Height: 90}
+	state := HistoryState{
+		Undo: []HistoryEntry{
+			{Kind: "card_create", Card: &card},
+			{Kind: "card_text", CardID: "card_1", Before: &HistoryValue{Text: "h"}, After: &HistoryValue{Text: "hi"}},
+		},
+		Redo: []HistoryEntry{
+			{Kind: "compound", Steps: []HistoryEntry{{Kind: "stroke_move", StrokeID: 3, After: &HistoryValue{PosX: 30}}}},
+		},
+	}
+	require.NoError(t, s.SaveHistory(testDate, state))
+
+	loaded, err := s.LoadHistory(testDate)
+	require.NoError(t, err)
+	assert.Equal(t, "2099-01-20", loaded.Date)
+	assert.Equal(t, state.Undo, loaded.Undo)
+	assert.Equal(t, state.Redo, loaded.Redo)
+
+	// Deleting the workspace takes its history with it
+	require.NoError(t, s.DeleteWorkspace(testDate))
+	loaded, err = s.LoadHistory(testDate)
+	require.NoError(t, err)
+	assert.Empty(t, loaded.Undo)
+}
+
+// TestHistoryFollowsItsWorkspace tests that history is dropped once its workspace changes elsewhere
+func TestHistoryFollowsItsWorkspace(t *testing.T) {
+	stores := map[string]Store{"file": newTestStore(t), "memory": NewMemoryStore()}
+	for name, s := range stores {
+		t.Run(name, func(t *testing.T) {
+			testDate := getTestDate(21)
+			require.NoError(t, s.SaveWorkspace(testDate, workspaceWithCard("first")))
+
+			state := HistoryState{Undo: []HistoryEntry{{Kind: "card_text", CardID: "card_1",
+				Before: &HistoryValue{Text: ""}, After: &HistoryValue{Text: "first"}}}}
+			require.NoError(t, s.SaveHistory(testDate, state))
+
+			loaded, err := s.LoadHistory(testDate)
+			require.NoError(t, err)
+			assert.Len(t, loaded.Undo, 1, "History saved with the workspace loads")
+			assert.NotNil(t, loaded.Redo)
+
+			// Another device syncs in a different workspace
+			require.NoError(t, s.SaveWorkspace(testDate, workspaceWithCard("second")))
+			loaded, err = s.LoadHistory(testDate)
+			require.NoError(t, err)
+			assert.Empty(t, loaded.Undo, "History of other contents is not restored")
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+)
+
+// lockFileName is the file in the instance directory that the instance
+// writing to the storage directory holds locked.
+const lockFileName = "mosugo.lock"
+
+// ErrLocked is returned by Lock while another instance holds the directory.
+var ErrLocked = errors.New("storage directory is in use by another Mosugo")
+
+// ErrReadOnly is returned by the saves and deletes of a read-only FileStore.
+var ErrReadOnly = errors.New("storage directory is open read-only")
+
+// DirLock is held by the one Mosugo instance allowed to write to a storage
+// directory. It is advisory: it keeps other instances out, not other
+// programs, which the store's watcher is there for.
+type DirLock struct {
+	dir      string // the store's instance directory
+	file     *os.File
+	listener net.Listener // set by Listen
+}
+
+// instanceDir is where the instances of this machine that use the store's
+// directory meet: its lock and forwarding address. It is kept in the user's
+// cache directory, named after the storage directory, rather than in the
+// storage directory itself, which may be synced to other machines whose
+// instances could neither share the lock nor reach the address.
+func (s *FileStore) instanceDir() (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get cache directory: %w", err)
+	}
+
+	// Two spellings of one directory must meet in the same place
+	root, err := filepath.Abs(s.root)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve storage directory: %w", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(root); err == nil {
+		root = resolved
+	}
+
+	dir := filepath.Join(cacheDir, "Mosugo", "instances", contentHash([]byte(root))[:16])
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create instance directory: %w", err)
+	}
+	return dir, nil
+}
+
+// Lock takes the store's directory for this process, failing with
+// ErrLocked while another process on this machine has it. The operating
+// system releases the lock when the process exits, so a crashed instance
+// never leaves it behind.
+func (s *FileStore) Lock() (*DirLock, error) {
+	dir, err := s.instanceDir()
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(filepath.Join(dir, lockFileName), os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+	if err := lockFile(file); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	// The process ID is only there to help tell which instance has it
+	if err := file.Truncate(0); err == nil {
+		file.WriteAt([]byte(fmt.Sprintf("%d\n", os.Getpid())), 0)
+	}
+	return &DirLock{dir: dir, file: file}, nil
+}
+
+// Unlock stops listening for forwarded requests and releases the directory.
+func (l *DirLock) Unlock() error {
+	if l.listener != nil {
+		l.listener.Close()
+		os.Remove(filepath.Join(l.dir, addrFileName))
+	}
+	return l.file.Close()
+}
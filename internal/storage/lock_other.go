@@ -0,0 +1,10 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd || windows)
+
+package storage
+
+import "os"
+
+// lockFile does nothing where files cannot be locked; every instance writes
+func lockFile(file *os.File) error {
+	return nil
+}
@@ -0,0 +1,129 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// useTestCache points the user's cache directory, where instance
+// directories are kept, at a fresh temporary directory
+func useTestCache(t *testing.T) {
+	cache := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", cache)
+	t.Setenv("LocalAppData", cache)
+	t.Setenv("HOME", cache)
+}
+
+// TestLockIsExclusive tests that one instance at a time holds a directory
+func TestLockIsExclusive(t *testing.T) {
+	useTestCache(t)
+	s := newTestStore(t)
+	other, err := NewFileStore(s.Root())
+	require.NoError(t, err)
+
+	lock, err := s.Lock()
+	require.NoError(t, err)
+
+	_, err = other.Lock()
+	assert.ErrorIs(t, err, ErrLocked)
+
+	require.NoError(t, lock.Unlock())
+	lock, err = other.Lock()
+	require.NoError(t, err, "The lock is free once released")
+	lock.Unlock()
+}
+
+// TestForwardOpen tests that a request to open a day reaches the instance holding the lock
+func TestForwardOpen(t *testing.T) {
+	useTestCache(t)
+	s := newTestStore(t)
+	other, err := NewFileStore(s.Root())
+	require.NoError(t, err)
+
+	assert.Error(t, other.ForwardOpen(getTestDate(25)), "Nobody is listening yet")
+
+	lock, err := s.Lock()
+	require.NoError(t, err)
+	defer lock.Unlock()
+
+	opened := make(chan time.Time, 1)
+	require.NoError(t, lock.Listen(func(date time.Time) { opened <- date }))
+
+	require.NoError(t, other.ForwardOpen(getTestDate(25)))
+	select {
+	case date := <-opened:
+		assert.Equal(t, getTestDate(25), date)
+	case <-time.After(2 * time.Second):
+		t.Fatal("The forwarded request never arrived")
+	}
+
+	// A request without the token is refused
+	dir, err := s.instanceDir()
+	require.NoError(t, err)
+	addrPath := filepath.Join(dir, addrFileName)
+	data, err := os.ReadFile(addrPath)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(addrPath, append(data[:len(data)-5], "xxxx\n"...), 0644))
+	assert.ErrorContains(t, other.ForwardOpen(getTestDate(25)), "refused")
+}
+
+// TestInstanceFilesStayOnThisMachine tests that the lock and address are kept out of the storage directory
+func TestInstanceFilesStayOnThisMachine(t *testing.T) {
+	useTestCache(t)
+	s := newTestStore(t)
+	lock, err := s.Lock()
+	require.NoError(t, err)
+	defer lock.Unlock()
+	require.NoError(t, lock.Listen(func(time.Time) {}))
+
+	entries, err := os.ReadDir(s.Root())
+	require.NoError(t, err)
+	assert.Empty(t, entries, "Nothing is written to a directory that may be synced")
+
+	cacheDir, err := os.UserCacheDir()
+	require.NoError(t, err)
+	dir, err := s.instanceDir()
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(dir, cacheDir))
+	assert.FileExists(t, filepath.Join(dir, lockFileName))
+	assert.FileExists(t, filepath.Join(dir, addrFileName))
+
+	otherDir, err := newTestStore(t).instanceDir()
+	require.NoError(t, err)
+	assert.NotEqual(t, dir, otherDir, "Each storage directory has its own")
+
+	// A link to the same directory meets there too
+	link := filepath.Join(t.TempDir(), "link")
+	if err := os.Symlink(s.Root(), link); err != nil {
+		t.Skip("Cannot create symlinks here:", err)
+	}
+	same, err := NewFileStore(link)
+	require.NoError(t, err)
+	sameDir, err := same.instanceDir()
+	require.NoError(t, err)
+	assert.Equal(t, dir, sameDir)
+	_, err = same.Lock()
+	assert.ErrorIs(t, err, ErrLocked)
+}
+
+// TestReadOnlyStore tests that a read-only store loads but never writes
+func TestReadOnlyStore(t *testing.T) {
+	s := newTestStore(t)
+	date := getTestDate(25)
+	require.NoError(t, s.SaveWorkspace(date, WorkspaceState{Scale: 1.0, Cards: []MosuData{{ID: "card_1"}}}))
+
+	s.SetReadOnly(true)
+	assert.ErrorIs(t, s.SaveWorkspace(date, WorkspaceState{Scale: 1.0}), ErrReadOnly)
+	assert.ErrorIs(t, s.SaveHistory(date, HistoryState{}), ErrReadOnly)
+	assert.ErrorIs(t, s.DeleteWorkspace(date), ErrReadOnly)
+
+	state, err := s.LoadWorkspace(date)
+	require.NoError(t, err)
+	assert.Len(t, state.Cards, 1)
+}
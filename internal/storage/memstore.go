@@ -0,0 +1,137 @@
+package storage
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps workspaces and histories in memory, for tests and for
+// canvases that were never given a FileStore. It is safe for concurrent use.
+type MemoryStore struct {
+	mu         sync.Mutex
+	workspaces map[string]WorkspaceState
+	histories  map[string]HistoryState
+}
+
+// NewMemoryStore creates an empty in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		workspaces: make(map[string]WorkspaceState),
+		histories:  make(map[string]HistoryState),
+	}
+}
+
+func memoryKey(date time.Time) string {
+	return date.Format("2006-01-02")
+}
+
+// copyWorkspace copies the lists of a state, so the store never shares them
+// with its callers
+func copyWorkspace(state WorkspaceState) WorkspaceState {
+	state.Cards = append([]MosuData{}, state.Cards...)
+	state.Strokes = append([]StrokeData{}, state.Strokes...)
+	return state
+}
+
+// SaveWorkspace saves a copy of the workspace of a day
+func (s *MemoryStore) SaveWorkspace(date time.Time, state WorkspaceState) error {
+	state.Date = memoryKey(date)
+	state.Version = WorkspaceVersion
+	state.Recovery = nil
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.workspaces[state.Date] = copyWorkspace(state)
+	return nil
+}
+
+// LoadWorkspace returns a copy of the workspace of a day
+func (s *MemoryStore) LoadWorkspace(date time.Time) (WorkspaceState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state, ok := s.workspaces[memoryKey(date)]
+	if !ok {
+		return emptyWorkspace(date), nil
+	}
+	return copyWorkspace(state), nil
+}
+
+// ListSavedDates returns the dates of all saved workspaces, newest first
+func (s *MemoryStore) ListSavedDates() ([]time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	dates := make([]time.Time, 0, len(s.workspaces))
+	for key := range s.workspaces {
+		date, err := time.Parse("2006-01-02", key)
+		if err != nil {
+			continue
+		}
+		dates = append(dates, date)
+	}
+	sortNewestFirst(dates)
+	return dates, nil
+}
+
+// WorkspaceExists checks if a workspace was saved for a given date
+func (s *MemoryStore) WorkspaceExists(date time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.workspaces[memoryKey(date)]
+	return ok
+}
+
+// DeleteWorkspace deletes the workspace of a day and its history
+func (s *MemoryStore) DeleteWorkspace(date time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.workspaces, memoryKey(date))
+	delete(s.histories, memoryKey(date))
+	return nil
+}
+
+// SaveHistory saves the undo history of a day, for the workspace the store
+// holds for it now
+func (s *MemoryStore) SaveHistory(date time.Time, state HistoryState) error {
+	state.Date = memoryKey(date)
+	state.Undo = append([]HistoryEntry{}, state.Undo...)
+	state.Redo = append([]HistoryEntry{}, state.Redo...)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state.Workspace = s.workspaceHash(state.Date)
+	s.histories[state.Date] = state
+	return nil
+}
+
+// LoadHistory returns the undo history of a day, unless its workspace
+// changed since
+func (s *MemoryStore) LoadHistory(date time.Time) (HistoryState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state, ok := s.histories[memoryKey(date)]
+	if !ok || state.Workspace != s.workspaceHash(state.Date) {
+		return emptyHistory(date), nil
+	}
+	state.Undo = append([]HistoryEntry{}, state.Undo...)
+	state.Redo = append([]HistoryEntry{}, state.Redo...)
+	return state, nil
+}
+
+// workspaceHash identifies the workspace held for key; s.mu must be held
+func (s *MemoryStore) workspaceHash(key string) string {
+	state, ok := s.workspaces[key]
+	if !ok {
+		return ""
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		return ""
+	}
+	return contentHash(data)
+}
+
+var (
+	_ Store = (*FileStore)(nil)
+	_ Store = (*MemoryStore)(nil)
+)
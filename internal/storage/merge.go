@@ -0,0 +1,308 @@
+package storage
+
+import (
+	"fmt"
+	"slices"
+)
+
+// ConflictNote starts the text of a card added for the other side of a
+// conflicting text edit, so the user can tell the two versions apart.
+const ConflictNote = "Conflicting version:"
+
+// conflictGap is the space left between a card and its conflicting copy
+const conflictGap = 30
+
+// MergeResult is the outcome of a three-way merge.
+type MergeResult struct {
+	State     WorkspaceState
+	Conflicts []MergeConflict
+}
+
+// MergeConflict is a change made on both sides that could not be combined.
+// Exactly one of CardID and StrokeID is set.
+type MergeConflict struct {
+	CardID   string
+	StrokeID int
+	Reason   string
+}
+
+// Merge combines ours and theirs, two workspaces that both started from
+// base, per card ID and per stroke ID. Whatever only one side changed is
+// taken from that side, so moves, edits, additions and deletions on
+// different objects, or different properties of one object, combine.
+//
+// Where both sides changed the same thing differently ours is kept, and the
+// conflict is reported: a card whose text was edited on both sides gets a
+// copy beside it with their text under ConflictNote, and a stroke redrawn
+// on both sides gets their version as a new stroke. An object deleted on one
+// side and changed on the other is kept. Objects that both sides added
+// under the same ID are both kept, theirs under a fresh ID. The view is
+// always ours.
+func Merge(base, ours, theirs WorkspaceState) MergeResult {
+	m := merger{taken: map[string]bool{}}
+	for _, state := range []WorkspaceState{base, ours, theirs} {
+		for _, card := range state.Cards {
+			m.taken[card.ID] = true
+		}
+		for _, segment := range state.Strokes {
+			m.nextStrokeID = max(m.nextStrokeID, segment.StrokeID+1)
+		}
+	}
+
+	result := ours
+	result.Cards = m.mergeCards(base.Cards, ours.Cards, theirs.Cards)
+	result.Strokes = m.mergeStrokes(base.Strokes, ours.Strokes, theirs.Strokes)
+	return MergeResult{State: result, Conflicts: m.conflicts}
+}
+
+type merger struct {
+	taken        map[string]bool // card IDs in use on any side
+	nextStrokeID int
+	conflicts    []MergeConflict
+}
+
+func (m *merger) cardConflict(id, reason string) {
+	m.conflicts = append(m.conflicts, MergeConflict{CardID: id, Reason: reason})
+}
+
+func (m *merger) strokeConflict(id int, reason string) {
+	m.conflicts = append(m.conflicts, MergeConflict{StrokeID: id, Reason: reason})
+}
+
+// freshCardID returns an unused card ID made from id and suffix
+func (m *merger) freshCardID(id, suffix string) string {
+	candidate := id + suffix
+	for n := 2; m.taken[candidate]; n++ {
+		candidate = fmt.Sprintf("%s%s_%d", id, suffix, n)
+	}
+	m.taken[candidate] = true
+	return candidate
+}
+
+func (m *merger) freshStrokeID() int {
+	id := m.nextStrokeID
+	m.nextStrokeID++
+	return id
+}
+
+// pick merges one property: a side that left it as it was in base takes the
+// other side's value. Both changing it differently is a conflict, and ours
+// is kept.
+func pick[T comparable](base, ours, theirs T) (T, bool) {
+	switch {
+	case ours == theirs, theirs == base:
+		return ours, false
+	case ours == base:
+		return theirs, false
+	}
+	return ours, true
+}
+
+func (m *merger) mergeCards(base, ours, theirs []MosuData) []MosuData {
+	baseByID := cardsByID(base)
+	oursByID := cardsByID(ours)
+	theirsByID := cardsByID(theirs)
+	merged := []MosuData{}
+	copies := []MosuData{}
+
+	for _, o := range ours {
+		b, inBase := baseByID[o.ID]
+		t, inTheirs := theirsByID[o.ID]
+		switch {
+		case inBase && inTheirs:
+			card, textConflict, otherConflict := mergeCard(b, o, t)
+			merged = append(merged, card)
+			if textConflict {
+				m.cardConflict(o.ID, "text changed on both sides")
+				c := t
+				c.ID = m.freshCardID(o.ID, "_conflict")
+				c.Content = ConflictNote + "\n" + t.Content
+				c.PosX = card.PosX + card.Width + conflictGap
+				c.PosY = card.PosY
+				copies = append(copies, c)
+			} else if otherConflict {
+				m.cardConflict(o.ID, "moved, resized or recolored on both sides")
+			}
+		case inBase:
+			// Deleted there; keep it only if it changed here
+			if !sameCardData(b, o) {
+				m.cardConflict(o.ID, "deleted on the other side, changed here")
+				merged = append(merged, o)
+			}
+		case inTheirs && !sameCardData(o, t):
+			// Both added a card under the same ID
+			merged = append(merged, o)
+			t.ID = m.freshCardID(o.ID, "_theirs")
+			copies = append(copies, t)
+		default:
+			merged = append(merged, o)
+		}
+	}
+
+	for _, t := range theirs {
+		if _, inOurs := oursByID[t.ID]; inOurs {
+			continue
+		}
+		b, inBase := baseByID[t.ID]
+		switch {
+		case !inBase:
+			merged = append(merged, t)
+		case !sameCardData(b, t):
+			// Deleted here but changed there
+			m.cardConflict(t.ID, "deleted here, changed on the other side")
+			merged = append(merged, t)
+		}
+	}
+
+	return append(merged, copies...)
+}
+
+// mergeCard merges the properties of a card kept on both sides, reporting
+// whether its text conflicted, and whether anything else did
+func mergeCard(base, ours, theirs MosuData) (MosuData, bool, bool) {
+	card := ours
+	var textConflict, colorConflict bool
+
+	card.Content, textConflict = pick(base.Content, ours.Content, theirs.Content)
+	pos, posConflict := pick([2]float32{base.PosX, base.PosY}, [2]float32{ours.PosX, ours.PosY}, [2]float32{theirs.PosX, theirs.PosY})
+	card.PosX, card.PosY = pos[0], pos[1]
+	size, sizeConflict := pick([2]float32{base.Width, base.Height}, [2]float32{ours.Width, ours.Height}, [2]float32{theirs.Width, theirs.Height})
+	card.Width, card.Height = size[0], size[1]
+	card.ColorIdx, colorConflict = pick(base.ColorIdx, ours.ColorIdx, theirs.ColorIdx)
+
+	return card, textConflict, posConflict || sizeConflict || colorConflict
+}
+
+func cardsByID(cards []MosuData) map[string]MosuData {
+	byID := make(map[string]MosuData, len(cards))
+	for _, card := range cards {
+		byID[card.ID] = card
+	}
+	return byID
+}
+
+// sameCardData compares two cards; times compare as instants
+func sameCardData(a, b MosuData) bool {
+	return a.ID == b.ID && a.Content == b.Content &&
+		a.PosX == b.PosX && a.PosY == b.PosY &&
+		a.Width == b.Width && a.Height == b.Height &&
+		a.ColorIdx == b.ColorIdx && a.CreatedAt.Equal(b.CreatedAt)
+}
+
+// mergedStroke is a stroke as the merge sees it: its segments' geometry and
+// its style
+type mergedStroke struct {
+	points   []float32 // p1x, p1y, p2x, p2y of each segment
+	colorIdx int
+	width    float32
+}
+
+func newMergedStroke(segments []StrokeData) mergedStroke {
+	s := mergedStroke{points: make([]float32, 0, 4*len(segments))}
+	for _, segment := range segments {
+		s.points = append(s.points, segment.P1X, segment.P1Y, segment.P2X, segment.P2Y)
+	}
+	if len(segments) > 0 {
+		s.colorIdx, s.width = segments[0].ColorIdx, segments[0].Width
+	}
+	return s
+}
+
+func (s mergedStroke) equal(other mergedStroke) bool {
+	return slices.Equal(s.points, other.points) && s.colorIdx == other.colorIdx && s.width == other.width
+}
+
+func (s mergedStroke) segments(strokeID int) []StrokeData {
+	segments := make([]StrokeData, 0, len(s.points)/4)
+	for i := 0; i+3 < len(s.points); i += 4 {
+		segments = append(segments, StrokeData{
+			P1X: s.points[i], P1Y: s.points[i+1], P2X: s.points[i+2], P2Y: s.points[i+3],
+			ColorIdx: s.colorIdx, Width: s.width, StrokeID: strokeID,
+		})
+	}
+	return segments
+}
+
+func (m *merger) mergeStrokes(base, ours, theirs []StrokeData) []StrokeData {
+	_, baseByID := strokesByID(base)
+	oursOrder, oursByID := strokesByID(ours)
+	theirsOrder, theirsByID := strokesByID(theirs)
+	merged := []StrokeData{}
+	copies := []StrokeData{}
+
+	for _, id := range oursOrder {
+		o := oursByID[id]
+		b, inBase := baseByID[id]
+		t, inTheirs := theirsByID[id]
+		switch {
+		case inBase && inTheirs:
+			s := o
+			var shapeConflict bool
+			if !slices.Equal(o.points, t.points) {
+				switch {
+				case slices.Equal(o.points, b.points):
+					s.points = t.points
+				case !slices.Equal(t.points, b.points):
+					shapeConflict = true
+				}
+			}
+			var colorConflict, widthConflict bool
+			s.colorIdx, colorConflict = pick(b.colorIdx, o.colorIdx, t.colorIdx)
+			s.width, widthConflict = pick(b.width, o.width, t.width)
+			merged = append(merged, s.segments(id)...)
+
+			if shapeConflict {
+				m.strokeConflict(id, "redrawn or moved on both sides")
+				copies = append(copies, t.segments(m.freshStrokeID())...)
+			} else if colorConflict || widthConflict {
+				m.strokeConflict(id, "restyled on both sides")
+			}
+		case inBase:
+			if !b.equal(o) {
+				m.strokeConflict(id, "deleted on the other side, changed here")
+				merged = append(merged, o.segments(id)...)
+			}
+		case inTheirs && !o.equal(t):
+			// Both drew a stroke under the same ID
+			merged = append(merged, o.segments(id)...)
+			copies = append(copies, t.segments(m.freshStrokeID())...)
+		default:
+			merged = append(merged, o.segments(id)...)
+		}
+	}
+
+	for _, id := range theirsOrder {
+		if _, inOurs := oursByID[id]; inOurs {
+			continue
+		}
+		t := theirsByID[id]
+		b, inBase := baseByID[id]
+		switch {
+		case !inBase:
+			merged = append(merged, t.segments(id)...)
+		case !b.equal(t):
+			m.strokeConflict(id, "deleted here, changed on the other side")
+			merged = append(merged, t.segments(id)...)
+		}
+	}
+
+	return append(merged, copies...)
+}
+
+// strokesByID groups segments into strokes, returning the IDs in the order
+// they first appear
+func strokesByID(segments []StrokeData) ([]int, map[int]mergedStroke) {
+	order := []int{}
+	groups := map[int][]StrokeData{}
+	for _, segment := range segments {
+		if _, ok := groups[segment.StrokeID]; !ok {
+			order = append(order, segment.StrokeID)
+		}
+		groups[segment.StrokeID] = append(groups[segment.StrokeID], segment)
+	}
+	byID := make(map[int]mergedStroke, len(groups))
+	for id, group := range groups {
+		byID[id] = newMergedStroke(group)
+	}
+	return order, byID
+}
@@ -0,0 +1,209 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testMergeCard(id, content string, x, y float32) MosuData {
+	return MosuData{ID: id, Content: content, PosX: x, PosY: y, Width: 120, Height: 90}
+}
+
+// testMergeStroke builds a stroke of colorIdx through the given x, y pairs
+func testMergeStroke(id, colorIdx int, xy ...float32) []StrokeData {
+	segments := []StrokeData{}
+	for i := 0; i+3 < len(xy); i += 2 {
+		segments = append(segments, StrokeData{
+			P1X: xy[i], P1Y: xy[i+1], P2X: xy[i+2], P2Y: xy[i+3],
+			ColorIdx: colorIdx, Width: 2.5, StrokeID: id,
+		})
+	}
+	return segments
+}
+
+func strokesOf(strokes ...[]StrokeData) []StrokeData {
+	all := []StrokeData{}
+	for _, s := range strokes {
+		all = append(all, s...)
+	}
+	return all
+}
+
+func withColor(card MosuData, colorIdx int) MosuData {
+	card.ColorIdx = colorIdx
+	return card
+}
+
+// TestMerge tests three-way merges of cards and strokes
+func TestMerge(t *testing.T) {
+	a := testMergeCard("card_1", "apples", 0, 0)
+	b := testMergeCard("card_2", "bread", 300, 0)
+	line := testMergeStroke(1, 0, 0, 200, 60, 200, 120, 230)
+	curve := testMergeStroke(2, 3, 0, 300, 30, 330)
+
+	tests := []struct {
+		name          string
+		base          WorkspaceState
+		ours          WorkspaceState
+		theirs        WorkspaceState
+		wantCards     []MosuData
+		wantStrokes   []StrokeData
+		wantConflicts []MergeConflict
+	}{
+		{
+			name:        "nothing changed",
+			base:        WorkspaceState{Cards: []MosuData{a, b}, Strokes: line},
+			ours:        WorkspaceState{Cards: []MosuData{a, b}, Strokes: line},
+			theirs:      WorkspaceState{Cards: []MosuData{a, b}, Strokes: line},
+			wantCards:   []MosuData{a, b},
+			wantStrokes: line,
+		},
+		{
+			name:      "move here and edit there combine",
+			base:      WorkspaceState{Cards: []MosuData{a}},
+			ours:      WorkspaceState{Cards: []MosuData{testMergeCard("card_1", "apples", 90, 30)}},
+			theirs:    WorkspaceState{Cards: []MosuData{withColor(testMergeCard("card_1", "apples\npears", 0, 0), 4)}},
+			wantCards: []MosuData{withColor(testMergeCard("card_1", "apples\npears", 90, 30), 4)},
+		},
+		{
+			name:      "edits to different cards combine",
+			base:      WorkspaceState{Cards: []MosuData{a, b}},
+			ours:      WorkspaceState{Cards: []MosuData{testMergeCard("card_1", "green apples", 0, 0), b}},
+			theirs:    WorkspaceState{Cards: []MosuData{a, testMergeCard("card_2", "rye bread", 300, 0)}},
+			wantCards: []MosuData{testMergeCard("card_1", "green apples", 0, 0), testMergeCard("card_2", "rye bread", 300, 0)},
+		},
+		{
+			name:      "additions on both sides combine",
+			base:      WorkspaceState{Cards: []MosuData{a}},
+			ours:      WorkspaceState{Cards: []MosuData{a, testMergeCard("card_5", "mine", 0, 300)}},
+			theirs:    WorkspaceState{Cards: []MosuData{a, testMergeCard("card_6", "theirs", 300, 300)}},
+			wantCards: []MosuData{a, testMergeCard("card_5", "mine", 0, 300), testMergeCard("card_6", "theirs", 300, 300)},
+		},
+		{
+			name:      "additions under the same ID are both kept",
+			base:      WorkspaceState{Cards: []MosuData{}},
+			ours:      WorkspaceState{Cards: []MosuData{testMergeCard("card_1", "mine", 0, 0)}},
+			theirs:    WorkspaceState{Cards: []MosuData{testMergeCard("card_1", "theirs", 300, 0)}},
+			wantCards: []MosuData{testMergeCard("card_1", "mine", 0, 0), testMergeCard("card_1_theirs", "theirs", 300, 0)},
+		},
+		{
+			name:      "the same addition on both sides is kept once",
+			base:      WorkspaceState{Cards: []MosuData{}},
+			ours:      WorkspaceState{Cards: []MosuData{a}},
+			theirs:    WorkspaceState{Cards: []MosuData{a}},
+			wantCards: []MosuData{a},
+		},
+		{
+			name:      "deletions on either side apply",
+			base:      WorkspaceState{Cards: []MosuData{a, b}},
+			ours:      WorkspaceState{Cards: []MosuData{b}},
+			theirs:    WorkspaceState{Cards: []MosuData{a}},
+			wantCards: []MosuData{},
+		},
+		{
+			name:          "a card deleted here but edited there is kept",
+			base:          WorkspaceState{Cards: []MosuData{a}},
+			ours:          WorkspaceState{Cards: []MosuData{}},
+			theirs:        WorkspaceState{Cards: []MosuData{testMergeCard("card_1", "apples!", 0, 0)}},
+			wantCards:     []MosuData{testMergeCard("card_1", "apples!", 0, 0)},
+			wantConflicts: []MergeConflict{{CardID: "card_1", Reason: "deleted here, changed on the other side"}},
+		},
+		{
+			name:          "a card edited here but deleted there is kept",
+			base:          WorkspaceState{Cards: []MosuData{a}},
+			ours:          WorkspaceState{Cards: []MosuData{testMergeCard("card_1", "apples", 60, 0)}},
+			theirs:        WorkspaceState{Cards: []MosuData{}},
+			wantCards:     []MosuData{testMergeCard("card_1", "apples", 60, 0)},
+			wantConflicts: []MergeConflict{{CardID: "card_1", Reason: "deleted on the other side, changed here"}},
+		},
+		{
+			name:   "text edited on both sides gets a conflict card",
+			base:   WorkspaceState{Cards: []MosuData{a}},
+			ours:   WorkspaceState{Cards: []MosuData{testMergeCard("card_1", "red apples", 0, 0)}},
+			theirs: WorkspaceState{Cards: []MosuData{testMergeCard("card_1", "green apples", 0, 60)}},
+			wantCards: []MosuData{
+				testMergeCard("card_1", "red apples", 0, 60),
+				testMergeCard("card_1_conflict", ConflictNote+"\ngreen apples", 150, 60),
+			},
+			wantConflicts: []MergeConflict{{CardID: "card_1", Reason: "text changed on both sides"}},
+		},
+		{
+			name:          "moves on both sides keep ours",
+			base:          WorkspaceState{Cards: []MosuData{a}},
+			ours:          WorkspaceState{Cards: []MosuData{testMergeCard("card_1", "apples", 30, 0)}},
+			theirs:        WorkspaceState{Cards: []MosuData{testMergeCard("card_1", "apples", 0, 30)}},
+			wantCards:     []MosuData{testMergeCard("card_1", "apples", 30, 0)},
+			wantConflicts: []MergeConflict{{CardID: "card_1", Reason: "moved, resized or recolored on both sides"}},
+		},
+		{
+			name:      "the same edit on both sides is no conflict",
+			base:      WorkspaceState{Cards: []MosuData{a}},
+			ours:      WorkspaceState{Cards: []MosuData{testMergeCard("card_1", "pears", 30, 0)}},
+			theirs:    WorkspaceState{Cards: []MosuData{testMergeCard("card_1", "pears", 30, 0)}},
+			wantCards: []MosuData{testMergeCard("card_1", "pears", 30, 0)},
+		},
+		{
+			name:        "a stroke moved here and recolored there combines",
+			base:        WorkspaceState{Strokes: strokesOf(line, curve)},
+			ours:        WorkspaceState{Strokes: strokesOf(testMergeStroke(1, 0, 30, 200, 90, 200, 150, 230), curve)},
+			theirs:      WorkspaceState{Strokes: strokesOf(testMergeStroke(1, 5, 0, 200, 60, 200, 120, 230), curve)},
+			wantStrokes: strokesOf(testMergeStroke(1, 5, 30, 200, 90, 200, 150, 230), curve),
+		},
+		{
+			name:          "a stroke redrawn on both sides keeps both",
+			base:          WorkspaceState{Strokes: line},
+			ours:          WorkspaceState{Strokes: testMergeStroke(1, 0, 0, 210, 60, 210)},
+			theirs:        WorkspaceState{Strokes: testMergeStroke(1, 0, 0, 190, 60, 190)},
+			wantStrokes:   strokesOf(testMergeStroke(1, 0, 0, 210, 60, 210), testMergeStroke(2, 0, 0, 190, 60, 190)),
+			wantConflicts: []MergeConflict{{StrokeID: 1, Reason: "redrawn or moved on both sides"}},
+		},
+		{
+			name:          "strokes deleted on one side follow the other side's changes",
+			base:          WorkspaceState{Strokes: strokesOf(line, curve)},
+			ours:          WorkspaceState{Strokes: testMergeStroke(2, 1, 0, 300, 30, 330)},
+			theirs:        WorkspaceState{Strokes: line},
+			wantStrokes:   testMergeStroke(2, 1, 0, 300, 30, 330),
+			wantConflicts: []MergeConflict{{StrokeID: 2, Reason: "deleted on the other side, changed here"}},
+		},
+		{
+			name:        "strokes added under the same ID are both kept",
+			base:        WorkspaceState{Strokes: line},
+			ours:        WorkspaceState{Strokes: strokesOf(line, curve)},
+			theirs:      WorkspaceState{Strokes: strokesOf(line, testMergeStroke(2, 0, 90, 90, 120, 90))},
+			wantStrokes: strokesOf(line, curve, testMergeStroke(3, 0, 90, 90, 120, 90)),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.ours.Scale, tt.theirs.Scale = 2, 0.5
+			result := Merge(tt.base, tt.ours, tt.theirs)
+
+			wantCards, wantStrokes := tt.wantCards, tt.wantStrokes
+			if wantCards == nil {
+				wantCards = []MosuData{}
+			}
+			if wantStrokes == nil {
+				wantStrokes = []StrokeData{}
+			}
+			assert.Equal(t, wantCards, result.State.Cards)
+			assert.Equal(t, wantStrokes, result.State.Strokes)
+			assert.Equal(t, tt.wantConflicts, result.Conflicts)
+			assert.Equal(t, float32(2), result.State.Scale, "The view is ours")
+		})
+	}
+}
+
+// TestMergeIsSymmetricWithoutConflicts tests that sides can swap when nothing conflicts
+func TestMergeIsSymmetricWithoutConflicts(t *testing.T) {
+	base := WorkspaceState{Cards: []MosuData{testMergeCard("card_1", "a", 0, 0), testMergeCard("card_2", "b", 300, 0)}}
+	ours := WorkspaceState{Cards: []MosuData{testMergeCard("card_1", "a", 60, 0), testMergeCard("card_2", "b", 300, 0)}}
+	theirs := WorkspaceState{Cards: []MosuData{testMergeCard("card_1", "a", 0, 0), testMergeCard("card_2", "bb", 300, 0)}}
+
+	one := Merge(base, ours, theirs)
+	other := Merge(base, theirs, ours)
+	assert.Empty(t, one.Conflicts)
+	assert.Empty(t, other.Conflicts)
+	assert.Equal(t, one.State.Cards, other.State.Cards)
+}
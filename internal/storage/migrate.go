@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// WorkspaceVersion is the workspace file format this build writes. Files
+// saved before versioning have no version field and count as version 0.
+const WorkspaceVersion = 1
+
+// ErrNewerWorkspace is returned for workspace files written by a newer
+// Mosugo, which this build cannot read without losing data.
+var ErrNewerWorkspace = errors.New("workspace file is from a newer version of Mosugo")
+
+// migration upgrades the raw JSON object of a workspace file by one version.
+// It works on the generic form so that it keeps reading the old shape after
+// WorkspaceState has moved on.
+type migration func(doc map[string]any) error
+
+// migrations[v] upgrades a version v workspace to version v+1, so there is
+// one per version before WorkspaceVersion.
+var migrations = []migration{
+	migrateV0ToV1,
+}
+
+// migrateV0ToV1 upgrades unversioned files: strokes are one entry per line
+// segment, grouped by stroke_id, and some early files left out the scale or
+// wrote null lists.
+func migrateV0ToV1(doc map[string]any) error {
+	if _, ok := doc["scale"]; !ok {
+		doc["scale"] = json.Number("1")
+	}
+	for _, key := range []string{"cards", "strokes"} {
+		switch doc[key].(type) {
+		case []any:
+		case nil:
+			doc[key] = []any{}
+		default:
+			return fmt.Errorf("%s is not a list", key)
+		}
+	}
+	return nil
+}
+
+// decodeWorkspace parses a workspace file of any known version.
+func decodeWorkspace(data []byte) (WorkspaceState, error) {
+	data, err := migrateWorkspace(data)
+	if err != nil {
+		return WorkspaceState{}, err
+	}
+	var state WorkspaceState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return WorkspaceState{}, fmt.Errorf("failed to unmarshal workspace state: %w", err)
+	}
+	return state, nil
+}
+
+// migrateWorkspace upgrades the JSON of a workspace file to WorkspaceVersion.
+// Current files are returned as they are.
+func migrateWorkspace(data []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber() // keep coordinates exactly as written
+	var doc map[string]any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal workspace state: %w", err)
+	}
+	if doc == nil {
+		return nil, fmt.Errorf("failed to unmarshal workspace state: not an object")
+	}
+
+	version := 0
+	if raw, ok := doc["version"]; ok {
+		n, ok := raw.(json.Number)
+		v, err := n.Int64()
+		if !ok || err != nil || v < 0 {
+			return nil, fmt.Errorf("invalid workspace version %v", raw)
+		}
+		if v > WorkspaceVersion {
+			return nil, fmt.Errorf("%w (version %d)", ErrNewerWorkspace, v)
+		}
+		version = int(v)
+	}
+	if version == WorkspaceVersion {
+		return data, nil
+	}
+
+	for v := version; v < WorkspaceVersion; v++ {
+		if err := migrations[v](doc); err != nil {
+			return nil, fmt.Errorf("failed to migrate workspace from version %d: %w", v, err)
+		}
+	}
+	doc["version"] = WorkspaceVersion
+	return json.Marshal(doc)
+}
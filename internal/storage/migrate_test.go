@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the .golden.json files in testdata")
+
+// TestMigrationsCoverEveryVersion tests that each older version has a migration
+func TestMigrationsCoverEveryVersion(t *testing.T) {
+	assert.Len(t, migrations, WorkspaceVersion)
+}
+
+// TestWorkspaceGoldenFiles loads every historical workspace shape in
+// testdata/workspaces and compares the result with its .golden.json file
+func TestWorkspaceGoldenFiles(t *testing.T) {
+	inputs, err := filepath.Glob(filepath.Join("testdata", "workspaces", "*.mosugo"))
+	require.NoError(t, err)
+	require.NotEmpty(t, inputs)
+
+	versions := map[string]bool{}
+	for _, input := range inputs {
+		name := strings.TrimSuffix(filepath.Base(input), ".mosugo")
+		versions[strings.SplitN(name, "_", 2)[0]] = true
+
+		t.Run(name, func(t *testing.T) {
+			data, err := os.ReadFile(input)
+			require.NoError(t, err)
+
+			state, err := decodeWorkspace(data)
+			require.NoError(t, err)
+			assert.Equal(t, WorkspaceVersion, state.Version)
+
+			got, err := json.MarshalIndent(state, "", "  ")
+			require.NoError(t, err)
+			got = append(got, '\n')
+
+			golden := strings.TrimSuffix(input, ".mosugo") + ".golden.json"
+			if *updateGolden {
+				require.NoError(t, os.WriteFile(golden, got, 0644))
+			}
+			want, err := os.ReadFile(golden)
+			require.NoError(t, err, "Run go test ./internal/storage -update to create it")
+			assert.Equal(t, string(want), string(got))
+
+			// Migrated states read back as they are
+			again, err := decodeWorkspace(got)
+			require.NoError(t, err)
+			assert.Equal(t, state, again)
+		})
+	}
+
+	for v := 0; v <= WorkspaceVersion; v++ {
+		assert.True(t, versions[fmt.Sprintf("v%d", v)], "No golden file for version %d", v)
+	}
+}
+
+// TestDecodeWorkspaceRejects tests files that cannot be migrated
+func TestDecodeWorkspaceRejects(t *testing.T) {
+	_, err := decodeWorkspace([]byte(`{"version": 99, "scale": 1}`))
+	assert.ErrorIs(t, err, ErrNewerWorkspace)
+
+	for _, data := range []string{
+		`{"version": "one"}`,
+		`{"version": -1}`,
+		`{"cards": {"id": "card_1"}}`,
+		`[]`,
+		`null`,
+	} {
+		_, err := decodeWorkspace([]byte(data))
+		assert.Error(t, err, data)
+	}
+}
+
+// TestLoadWorkspaceNewerVersion tests that newer files are never replaced by a backup
+func TestLoadWorkspaceNewerVersion(t *testing.T) {
+	s := newTestStore(t)
+	testDate := getTestDate(24)
+
+	require.NoError(t, s.SaveWorkspace(testDate, WorkspaceState{Scale: 1.0}))
+	require.NoError(t, s.SaveWorkspace(testDate, WorkspaceState{Scale: 2.0}))
+
+	filePath := s.workspacePath(testDate)
+	require.NoError(t, os.WriteFile(filePath, []byte(`{"version": 99, "scale": 1}`), 0644))
+
+	_, err := s.LoadWorkspace(testDate)
+	assert.ErrorIs(t, err, ErrNewerWorkspace)
+}
@@ -0,0 +1,150 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+)
+
+// Recovery describes how the workspace of a day whose file could not be
+// loaded was put back together.
+type Recovery struct {
+	Err error // why the file could not be loaded
+
+	// QuarantinedTo is where the damaged file was moved, so saving the
+	// recovered workspace cannot destroy it; empty when it was left alone
+	QuarantinedTo string
+
+	SalvagedCards   int // cards still readable in the damaged file
+	SalvagedStrokes int // strokes still readable in the damaged file
+
+	// RestoredFrom is the backup that filled in what could not be
+	// salvaged; empty when there was none
+	RestoredFrom string
+}
+
+// quarantinePath is where a damaged workspace file is moved to
+func quarantinePath(filePath string, now time.Time) string {
+	return filePath + ".corrupt-" + now.Format("20060102-150405")
+}
+
+// salvageWorkspace reads what it can out of a damaged workspace file: the
+// view, if it comes before the damage, and every card and stroke that is
+// still a complete object, wherever it is. The scale is 0 when the view
+// could not be read.
+func salvageWorkspace(data []byte) WorkspaceState {
+	state := WorkspaceState{Cards: []MosuData{}, Strokes: []StrokeData{}}
+	salvageView(data, &state)
+
+	seenCards := map[string]bool{}
+	for offset := 0; ; {
+		i := bytes.IndexByte(data[offset:], '{')
+		if i < 0 {
+			break
+		}
+		start := offset + i
+		offset = start + 1
+
+		dec := json.NewDecoder(bytes.NewReader(data[start:]))
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err == nil && salvageObject(raw, &state, seenCards) {
+			// Carry on after it, so nothing inside it is read twice
+			offset = start + int(dec.InputOffset())
+		}
+	}
+	return state
+}
+
+// salvageView reads the top-level fields of data up to the first one that
+// cannot be read, keeping the view among them
+func salvageView(data []byte, state *WorkspaceState) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		key, ok := tok.(string)
+		if err != nil || !ok {
+			return
+		}
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return
+		}
+		switch key {
+		case "scale":
+			json.Unmarshal(value, &state.Scale)
+		case "offset_x":
+			json.Unmarshal(value, &state.OffsetX)
+		case "offset_y":
+			json.Unmarshal(value, &state.OffsetY)
+		}
+	}
+}
+
+// salvageObject adds raw to state if it is a card or a stroke segment,
+// reporting whether it was
+func salvageObject(raw json.RawMessage, state *WorkspaceState, seenCards map[string]bool) bool {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return false
+	}
+
+	if _, ok := fields["stroke_id"]; ok {
+		var segment StrokeData
+		if err := json.Unmarshal(raw, &segment); err != nil {
+			return false
+		}
+		state.Strokes = append(state.Strokes, segment)
+		return true
+	}
+	if _, ok := fields["id"]; ok {
+		var card MosuData
+		if err := json.Unmarshal(raw, &card); err != nil || card.ID == "" || seenCards[card.ID] {
+			return false
+		}
+		seenCards[card.ID] = true
+		state.Cards = append(state.Cards, card)
+		return true
+	}
+	return false
+}
+
+// fillFromBackup adds the cards and strokes of backup that state lacks, and
+// its view if state has none. Something deleted since the backup may come
+// back, but nothing saved in it is lost.
+func fillFromBackup(state *WorkspaceState, backup WorkspaceState) {
+	if state.Scale == 0 {
+		state.Scale, state.OffsetX, state.OffsetY = backup.Scale, backup.OffsetX, backup.OffsetY
+	}
+
+	haveCards := map[string]bool{}
+	for _, card := range state.Cards {
+		haveCards[card.ID] = true
+	}
+	for _, card := range backup.Cards {
+		if !haveCards[card.ID] {
+			state.Cards = append(state.Cards, card)
+		}
+	}
+
+	haveStrokes := map[int]bool{}
+	for _, segment := range state.Strokes {
+		haveStrokes[segment.StrokeID] = true
+	}
+	for _, segment := range backup.Strokes {
+		if !haveStrokes[segment.StrokeID] {
+			state.Strokes = append(state.Strokes, segment)
+		}
+	}
+}
+
+// countStrokes counts the distinct strokes among segments
+func countStrokes(segments []StrokeData) int {
+	ids := map[int]bool{}
+	for _, segment := range segments {
+		ids[segment.StrokeID] = true
+	}
+	return len(ids)
+}
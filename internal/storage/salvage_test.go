@@ -0,0 +1,128 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSalvageWorkspace tests reading what is left of damaged workspace files
+func TestSalvageWorkspace(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        string
+		wantScale   float32
+		wantCards   []string
+		wantStrokes int
+	}{
+		{
+			name:        "truncated in the middle of a card",
+			data:        `{"scale": 2, "offset_x": 5, "cards": [{"id": "card_1", "content": "a"}, {"id": "card_2", "cont`,
+			wantScale:   2,
+			wantCards:   []string{"card_1"},
+			wantStrokes: 0,
+		},
+		{
+			name: "garbage between cards and strokes",
+			data: `{"scale": 1.5, "cards": [{"id": "card_1"}, @@@ {"id": "card_2"}], ` +
+				`"strokes": [{"p1_x": 1, "stroke_id": 1}, {"p1_x": 2, "stroke_id": 1}, {"stroke_id": 2}]}`,
+			wantScale:   1.5,
+			wantCards:   []string{"card_1", "card_2"},
+			wantStrokes: 3,
+		},
+		{
+			name:        "a card of the wrong shape is skipped",
+			data:        `{"cards": [{"id": "card_1", "pos_x": "left"}, {"id": "card_2"}, {"id": "card_2"}]}`,
+			wantCards:   []string{"card_2"},
+			wantStrokes: 0,
+		},
+		{
+			name:        "braces in card text are not cards",
+			data:        `{"cards": [{"id": "card_1", "content": "{\"id\": \"card_9\"}"}], "strokes": 7`,
+			wantCards:   []string{"card_1"},
+			wantStrokes: 0,
+		},
+		{
+			name:      "nothing readable",
+			data:      "\x00\x00\x00",
+			wantCards: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := salvageWorkspace([]byte(tt.data))
+			ids := []string{}
+			for _, card := range state.Cards {
+				ids = append(ids, card.ID)
+			}
+			assert.Equal(t, tt.wantCards, ids)
+			assert.Len(t, state.Strokes, tt.wantStrokes)
+			assert.Equal(t, tt.wantScale, state.Scale)
+		})
+	}
+}
+
+// TestLoadWorkspaceQuarantinesCorruptFile tests that a damaged file is moved aside and merged with its backup
+func TestLoadWorkspaceQuarantinesCorruptFile(t *testing.T) {
+	s := newTestStore(t)
+	testDate := getTestDate(26)
+	filePath := s.workspacePath(testDate)
+
+	backup := WorkspaceState{Scale: 1.0, Cards: []MosuData{{ID: "card_1", Content: "old"}, {ID: "card_2", Content: "kept"}}}
+	require.NoError(t, s.SaveWorkspace(testDate, backup))
+	require.NoError(t, s.SaveWorkspace(testDate, workspaceWithCard("first")))
+
+	damaged := `{"scale": 3, "cards": [{"id": "card_1", "content": "new"}, {"id": "card_3", "content": "tr`
+	require.NoError(t, os.WriteFile(filePath, []byte(damaged), 0644))
+
+	loaded, err := s.LoadWorkspace(testDate)
+	require.NoError(t, err)
+	require.NotNil(t, loaded.Recovery)
+	assert.Equal(t, 1, loaded.Recovery.SalvagedCards)
+	assert.Equal(t, filepath.Base(backupPath(filePath, 1)), filepath.Base(loaded.Recovery.RestoredFrom))
+	assert.Equal(t, float32(3), loaded.Scale, "The salvaged view is kept")
+
+	contents := map[string]string{}
+	for _, card := range loaded.Cards {
+		contents[card.ID] = card.Content
+	}
+	assert.Equal(t, map[string]string{"card_1": "new", "card_2": "kept"}, contents,
+		"Salvaged cards win over the backup, which fills in the rest")
+
+	// The damaged file is kept aside, untouched
+	require.True(t, strings.HasPrefix(loaded.Recovery.QuarantinedTo, filePath+".corrupt-"))
+	data, err := os.ReadFile(loaded.Recovery.QuarantinedTo)
+	require.NoError(t, err)
+	assert.Equal(t, damaged, string(data))
+	assert.False(t, s.WorkspaceExists(testDate))
+
+	// Saving the recovered workspace leaves the quarantined file alone
+	require.NoError(t, s.SaveWorkspace(testDate, loaded))
+	_, err = os.Stat(loaded.Recovery.QuarantinedTo)
+	assert.NoError(t, err)
+
+	reloaded, err := s.LoadWorkspace(testDate)
+	require.NoError(t, err)
+	assert.Nil(t, reloaded.Recovery)
+	assert.Len(t, reloaded.Cards, 2)
+}
+
+// TestLoadWorkspaceReadOnlyLeavesCorruptFile tests that a read-only store salvages without moving the file
+func TestLoadWorkspaceReadOnlyLeavesCorruptFile(t *testing.T) {
+	s := newTestStore(t)
+	testDate := getTestDate(26)
+	require.NoError(t, os.WriteFile(s.workspacePath(testDate), []byte(`{"cards": [{"id": "card_1"}, {`), 0644))
+
+	s.SetReadOnly(true)
+	loaded, err := s.LoadWorkspace(testDate)
+	require.NoError(t, err)
+	require.NotNil(t, loaded.Recovery)
+	assert.Empty(t, loaded.Recovery.QuarantinedTo)
+	assert.Len(t, loaded.Cards, 1)
+	assert.True(t, s.WorkspaceExists(testDate))
+}
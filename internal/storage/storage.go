@@ -6,6 +6,7 @@ package storage
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -43,15 +44,20 @@ type StrokeData struct {
 
 // WorkspaceState represents the complete state of a workspace for a specific date.
 type WorkspaceState struct {
+	Version int          `json:"version"` // see WorkspaceVersion
 	Scale   float32      `json:"scale"`
 	OffsetX float32      `json:"offset_x"`
 	OffsetY float32      `json:"offset_y"`
 	Cards   []MosuData   `json:"cards"`
 	Strokes []StrokeData `json:"strokes"`
 	Date    string       `json:"date"` // YYYY-MM-DD format
+
+	// Recovery is set when the workspace file itself could not be loaded
+	// and this state was recovered from what was left of it and its backups.
+	Recovery *Recovery `json:"-"`
 }
 
-// GetStoragePath returns the platform-specific storage directory for Mosugo workspaces.
+// GetStoragePath returns the default, platform-specific storage directory for Mosugo workspaces.
 // On Windows: %APPDATA%\Roaming\Mosugo\
 // On Linux: ~/.config/Mosugo/
 // The directory is created if it doesn't exist.
@@ -73,27 +79,36 @@ func GetStoragePath() (string, error) {
 	return storagePath, nil
 }
 
-// getWorkspaceFilePath returns the full path to a workspace file for a given date
-func getWorkspaceFilePath(date time.Time) (string, error) {
-	storagePath, err := GetStoragePath()
-	if err != nil {
-		return "", err
-	}
-
+// workspacePath returns the full path to the workspace file for a given date
+func (s *FileStore) workspacePath(date time.Time) string {
 	// Format: YYYY-MM-DD.mosugo
 	filename := date.Format("2006-01-02") + ".mosugo"
-	return filepath.Join(storagePath, filename), nil
+	return filepath.Join(s.root, filename)
 }
 
-// SaveWorkspace saves the current workspace state to a dated file
-func SaveWorkspace(date time.Time, state WorkspaceState) error {
-	// Ensure date field matches the file date
-	state.Date = date.Format("2006-01-02")
+// emptyWorkspace is the workspace of a day that was never saved
+func emptyWorkspace(date time.Time) WorkspaceState {
+	return WorkspaceState{
+		Version: WorkspaceVersion,
+		Scale:   1.0,
+		OffsetX: 0,
+		OffsetY: 0,
+		Cards:   []MosuData{},
+		Strokes: []StrokeData{},
+		Date:    date.Format("2006-01-02"),
+	}
+}
 
-	filePath, err := getWorkspaceFilePath(date)
-	if err != nil {
-		return err
+// SaveWorkspace saves the current workspace state to a dated file. The write
+// is atomic, and the version it replaces is kept as a backup.
+func (s *FileStore) SaveWorkspace(date time.Time, state WorkspaceState) error {
+	if s.readOnly {
+		return ErrReadOnly
 	}
+	// Ensure date field matches the file date
+	state.Date = date.Format("2006-01-02")
+	state.Version = WorkspaceVersion
+	filePath := s.workspacePath(date)
 
 	// Marshal to JSON with indentation for readability
 	data, err := json.MarshalIndent(state, "", "  ")
@@ -101,56 +116,90 @@ func SaveWorkspace(date time.Time, state WorkspaceState) error {
 		return fmt.Errorf("failed to marshal workspace state: %w", err)
 	}
 
-	// Write to file
-	if err := os.WriteFile(filePath, data, 0644); err != nil {
+	if err := rotateBackups(filePath, data); err != nil {
+		return fmt.Errorf("failed to back up workspace file: %w", err)
+	}
+	if err := writeFileAtomic(filePath, data); err != nil {
 		return fmt.Errorf("failed to write workspace file: %w", err)
 	}
+	s.noteContents(filePath, data)
 
 	return nil
 }
 
-// LoadWorkspace loads a workspace state from a dated file
-func LoadWorkspace(date time.Time) (WorkspaceState, error) {
-	filePath, err := getWorkspaceFilePath(date)
-	if err != nil {
-		return WorkspaceState{}, err
-	}
+// LoadWorkspace loads a workspace state from a dated file. Files of older
+// versions are migrated as they load.
+//
+// A file that cannot be parsed is moved aside to a .corrupt-<timestamp>
+// file, unless the store is read-only. What can still be read of it is
+// salvaged, and the newest backup that can be read fills in the rest; the
+// state returned says so in Recovery. A file that cannot be read at all is
+// replaced by the newest backup alone.
+func (s *FileStore) LoadWorkspace(date time.Time) (WorkspaceState, error) {
+	filePath := s.workspacePath(date)
 
 	// Check if file exists
 	if _, err := os.Stat(filePath); os.IsNotExist(err) {
 		// Return empty workspace with default values
-		return WorkspaceState{
-			Scale:   1.0,
-			OffsetX: 0,
-			OffsetY: 0,
-			Cards:   []MosuData{},
-			Strokes: []StrokeData{},
-			Date:    date.Format("2006-01-02"),
-		}, nil
+		return emptyWorkspace(date), nil
 	}
 
 	// Read file
 	data, err := os.ReadFile(filePath)
 	if err != nil {
-		return WorkspaceState{}, fmt.Errorf("failed to read workspace file: %w", err)
+		err = fmt.Errorf("failed to read workspace file: %w", err)
+		backup, path, ok := loadNewestBackup(filePath)
+		if !ok {
+			return WorkspaceState{}, err
+		}
+		backup.Recovery = &Recovery{Err: err, RestoredFrom: path}
+		return backup, nil
 	}
+	s.noteContents(filePath, data)
 
-	// Unmarshal JSON
-	var state WorkspaceState
-	if err := json.Unmarshal(data, &state); err != nil {
-		return WorkspaceState{}, fmt.Errorf("failed to unmarshal workspace state: %w", err)
+	state, err := decodeWorkspace(data)
+	if err == nil {
+		return state, nil
 	}
-
-	return state, nil
+	if errors.Is(err, ErrNewerWorkspace) {
+		// Nothing is damaged, and recovering would overwrite the newer file
+		return WorkspaceState{}, err
+	}
+	return s.recoverWorkspace(date, filePath, data, err)
 }
 
-func ListSavedDates() ([]time.Time, error) {
-	storagePath, err := GetStoragePath()
-	if err != nil {
-		return nil, err
+// recoverWorkspace rebuilds the workspace of a file that failed to parse
+// with err
+func (s *FileStore) recoverWorkspace(date time.Time, filePath string, data []byte, err error) (WorkspaceState, error) {
+	recovery := &Recovery{Err: err}
+	if !s.readOnly {
+		// Saving the recovered workspace must not destroy what is left of the file
+		quarantined := quarantinePath(filePath, time.Now())
+		if err := os.Rename(filePath, quarantined); err != nil {
+			return WorkspaceState{}, fmt.Errorf("failed to move damaged workspace file aside: %w", err)
+		}
+		recovery.QuarantinedTo = quarantined
+	}
+
+	state := salvageWorkspace(data)
+	recovery.SalvagedCards = len(state.Cards)
+	recovery.SalvagedStrokes = countStrokes(state.Strokes)
+
+	if backup, path, ok := loadNewestBackup(filePath); ok {
+		fillFromBackup(&state, backup)
+		recovery.RestoredFrom = path
+	}
+	if state.Scale == 0 {
+		state.Scale = 1.0
 	}
+	state.Date = date.Format("2006-01-02")
+	state.Recovery = recovery
+	return state, nil
+}
 
-	files, err := os.ReadDir(storagePath)
+// ListSavedDates returns the dates of all workspace files, newest first
+func (s *FileStore) ListSavedDates() ([]time.Time, error) {
+	files, err := os.ReadDir(s.root)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read storage directory: %w", err)
 	}
@@ -180,36 +229,39 @@ func ListSavedDates() ([]time.Time, error) {
 	}
 
 	// Sort dates in descending order (newest first)
+	sortNewestFirst(dates)
+
+	return dates, nil
+}
+
+func sortNewestFirst(dates []time.Time) {
 	sort.Slice(dates, func(i, j int) bool {
 		return dates[i].After(dates[j])
 	})
-
-	return dates, nil
 }
 
 // WorkspaceExists checks if a workspace file exists for a given date
-func WorkspaceExists(date time.Time) bool {
-	filePath, err := getWorkspaceFilePath(date)
-	if err != nil {
-		return false
-	}
-
-	_, err = os.Stat(filePath)
+func (s *FileStore) WorkspaceExists(date time.Time) bool {
+	_, err := os.Stat(s.workspacePath(date))
 	return !os.IsNotExist(err)
 }
 
-// DeleteWorkspace deletes the workspace file for a given date
-func DeleteWorkspace(date time.Time) error {
-	filePath, err := getWorkspaceFilePath(date)
-	if err != nil {
-		return err
+// DeleteWorkspace deletes the workspace file for a given date, its backups
+// and its history
+func (s *FileStore) DeleteWorkspace(date time.Time) error {
+	if s.readOnly {
+		return ErrReadOnly
 	}
+	filePath := s.workspacePath(date)
 
 	if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
 		return fmt.Errorf("failed to delete workspace file: %w", err)
 	}
+	if err := removeBackups(filePath); err != nil {
+		return fmt.Errorf("failed to delete workspace backups: %w", err)
+	}
 
-	return nil
+	return s.deleteHistory(date)
 }
 
 // ConvertPositionToStorage converts a fyne.Position to separate X and Y floats
@@ -2,7 +2,6 @@ package storage
 
 import (
 	"os"
-	"path/filepath"
 	"testing"
 	"time"
 
@@ -18,9 +17,8 @@ func getTestDate(day int) time.Time {
 
 // TestSaveAndLoadWorkspaceEmpty tests empty workspace roundtrip
 func TestSaveAndLoadWorkspaceEmpty(t *testing.T) {
+	s := newTestStore(t)
 	testDate := getTestDate(1)
-	defer DeleteWorkspace(testDate)
-	DeleteWorkspace(testDate)
 
 	empty := WorkspaceState{
 		Scale:   1.0,
@@ -30,10 +28,10 @@ func TestSaveAndLoadWorkspaceEmpty(t *testing.T) {
 		Strokes: []StrokeData{},
 	}
 
-	err := SaveWorkspace(testDate, empty)
+	err := s.SaveWorkspace(testDate, empty)
 	require.NoError(t, err, "Should save empty workspace")
 
-	loaded, err := LoadWorkspace(testDate)
+	loaded, err := s.LoadWorkspace(testDate)
 	require.NoError(t, err, "Should load empty workspace")
 
 	assert.Equal(t, empty.Scale, loaded.Scale)
@@ -45,9 +43,8 @@ func TestSaveAndLoadWorkspaceEmpty(t *testing.T) {
 
 // TestSaveAndLoadWorkspaceSmall tests small workspace with cards and strokes
 func TestSaveAndLoadWorkspaceSmall(t *testing.T) {
+	s := newTestStore(t)
 	testDate := getTestDate(2)
-	defer DeleteWorkspace(testDate)
-	DeleteWorkspace(testDate)
 
 	small := WorkspaceState{
 		Scale:   1.5,
@@ -70,10 +67,10 @@ func TestSaveAndLoadWorkspaceSmall(t *testing.T) {
 		},
 	}
 
-	err := SaveWorkspace(testDate, small)
+	err := s.SaveWorkspace(testDate, small)
 	require.NoError(t, err)
 
-	loaded, err := LoadWorkspace(testDate)
+	loaded, err := s.LoadWorkspace(testDate)
 	require.NoError(t, err)
 
 	assert.Equal(t, small.Scale, loaded.Scale)
@@ -85,9 +82,8 @@ func TestSaveAndLoadWorkspaceSmall(t *testing.T) {
 
 // TestSaveWorkspaceWithUnicode tests unicode content in cards
 func TestSaveWorkspaceWithUnicode(t *testing.T) {
+	s := newTestStore(t)
 	testDate := getTestDate(4)
-	defer DeleteWorkspace(testDate)
-	DeleteWorkspace(testDate)
 
 	ws := WorkspaceState{
 		Scale: 1.0,
@@ -105,10 +101,10 @@ func TestSaveWorkspaceWithUnicode(t *testing.T) {
 		},
 	}
 
-	err := SaveWorkspace(testDate, ws)
+	err := s.SaveWorkspace(testDate, ws)
 	require.NoError(t, err)
 
-	loaded, err := LoadWorkspace(testDate)
+	loaded, err := s.LoadWorkspace(testDate)
 	require.NoError(t, err)
 
 	assert.Equal(t, ws.Cards[0].Content, loaded.Cards[0].Content)
@@ -116,10 +112,10 @@ func TestSaveWorkspaceWithUnicode(t *testing.T) {
 
 // TestLoadWorkspaceNotExists tests loading non-existent workspace returns empty
 func TestLoadWorkspaceNotExists(t *testing.T) {
+	s := newTestStore(t)
 	testDate := getTestDate(99)
-	DeleteWorkspace(testDate)
 
-	loaded, err := LoadWorkspace(testDate)
+	loaded, err := s.LoadWorkspace(testDate)
 	require.NoError(t, err, "Should return empty workspace, not error")
 	assert.Len(t, loaded.Cards, 0)
 	assert.Len(t, loaded.Strokes, 0)
@@ -128,25 +124,26 @@ func TestLoadWorkspaceNotExists(t *testing.T) {
 
 // TestLoadWorkspaceCorruptJSON tests loading corrupted JSON file
 func TestLoadWorkspaceCorruptJSON(t *testing.T) {
+	s := newTestStore(t)
 	testDate := getTestDate(5)
-	defer DeleteWorkspace(testDate)
 
-	storagePath, err := GetStoragePath()
+	filePath := s.workspacePath(testDate)
+	err := os.WriteFile(filePath, []byte("{ invalid json }"), 0644)
 	require.NoError(t, err)
 
-	filePath := filepath.Join(storagePath, testDate.Format("2006-01-02")+".mosugo")
-	err = os.WriteFile(filePath, []byte("{ invalid json }"), 0644)
-	require.NoError(t, err)
-
-	_, err = LoadWorkspace(testDate)
-	assert.Error(t, err, "Should error on corrupted JSON")
+	loaded, err := s.LoadWorkspace(testDate)
+	require.NoError(t, err, "Corrupted JSON is recovered, not an error")
+	require.NotNil(t, loaded.Recovery)
+	assert.Error(t, loaded.Recovery.Err)
+	assert.Empty(t, loaded.Cards)
+	assert.Equal(t, float32(1.0), loaded.Scale)
+	assert.False(t, s.WorkspaceExists(testDate), "The corrupted file is moved aside")
 }
 
 // TestLoadWorkspaceLargeCoordinates tests extreme float32 values
 func TestLoadWorkspaceLargeCoordinates(t *testing.T) {
+	s := newTestStore(t)
 	testDate := getTestDate(6)
-	defer DeleteWorkspace(testDate)
-	DeleteWorkspace(testDate)
 
 	ws := WorkspaceState{
 		Scale:   1.0,
@@ -166,10 +163,10 @@ func TestLoadWorkspaceLargeCoordinates(t *testing.T) {
 		},
 	}
 
-	err := SaveWorkspace(testDate, ws)
+	err := s.SaveWorkspace(testDate, ws)
 	require.NoError(t, err)
 
-	loaded, err := LoadWorkspace(testDate)
+	loaded, err := s.LoadWorkspace(testDate)
 	require.NoError(t, err)
 
 	assert.Equal(t, ws.OffsetX, loaded.OffsetX)
@@ -179,17 +176,15 @@ func TestLoadWorkspaceLargeCoordinates(t *testing.T) {
 
 // TestListSavedDates tests listing saved workspaces
 func TestListSavedDates(t *testing.T) {
+	s := newTestStore(t)
 	dates := []time.Time{getTestDate(10), getTestDate(11), getTestDate(12)}
 	for _, date := range dates {
-		defer DeleteWorkspace(date)
-		DeleteWorkspace(date)
-
 		ws := WorkspaceState{Scale: 1.0}
-		err := SaveWorkspace(date, ws)
+		err := s.SaveWorkspace(date, ws)
 		require.NoError(t, err)
 	}
 
-	listed, err := ListSavedDates()
+	listed, err := s.ListSavedDates()
 	require.NoError(t, err)
 
 	// Check that our test dates are in the list
@@ -202,52 +197,59 @@ func TestListSavedDates(t *testing.T) {
 		}
 	}
 	assert.Equal(t, 3, foundCount, "Should find all 3 test dates")
+	require.Len(t, listed, 3, "The store holds nothing else")
+	assert.Equal(t, dates[2], listed[0], "Newest first")
 }
 
 // TestWorkspaceExists tests file existence checking
 func TestWorkspaceExists(t *testing.T) {
+	s := newTestStore(t)
 	testDate := getTestDate(15)
-	defer DeleteWorkspace(testDate)
-	DeleteWorkspace(testDate)
 
-	exists := WorkspaceExists(testDate)
+	exists := s.WorkspaceExists(testDate)
 	assert.False(t, exists)
 
 	ws := WorkspaceState{Scale: 1.0}
-	err := SaveWorkspace(testDate, ws)
+	err := s.SaveWorkspace(testDate, ws)
 	require.NoError(t, err)
 
-	exists = WorkspaceExists(testDate)
+	exists = s.WorkspaceExists(testDate)
 	assert.True(t, exists)
 }
 
 // TestDeleteWorkspace tests workspace deletion
 func TestDeleteWorkspace(t *testing.T) {
+	s := newTestStore(t)
 	testDate := getTestDate(16)
 
 	ws := WorkspaceState{Scale: 1.0}
-	err := SaveWorkspace(testDate, ws)
+	err := s.SaveWorkspace(testDate, ws)
 	require.NoError(t, err)
 
-	assert.True(t, WorkspaceExists(testDate))
+	assert.True(t, s.WorkspaceExists(testDate))
 
-	err = DeleteWorkspace(testDate)
+	err = s.DeleteWorkspace(testDate)
 	require.NoError(t, err)
 
-	assert.False(t, WorkspaceExists(testDate))
+	assert.False(t, s.WorkspaceExists(testDate))
 }
 
 // TestDeleteWorkspaceNotExists tests deleting non-existent workspace
 func TestDeleteWorkspaceNotExists(t *testing.T) {
+	s := newTestStore(t)
 	testDate := getTestDate(98)
-	DeleteWorkspace(testDate)
 
-	err := DeleteWorkspace(testDate)
+	err := s.DeleteWorkspace(testDate)
 	assert.NoError(t, err, "Deleting non-existent workspace should not error")
 }
 
 // TestGetStoragePath tests storage path retrieval
 func TestGetStoragePath(t *testing.T) {
+	config := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", config)
+	t.Setenv("APPDATA", config)
+	t.Setenv("HOME", config)
+
 	path, err := GetStoragePath()
 	require.NoError(t, err)
 	assert.NotEmpty(t, path)
@@ -278,3 +280,10 @@ func TestConversionFunctions(t *testing.T) {
 	assert.Equal(t, float32(300), w)
 	assert.Equal(t, float32(200), h)
 }
+
+// newTestStore creates a store in a fresh temporary directory
+func newTestStore(t *testing.T) *FileStore {
+	s, err := NewFileStore(t.TempDir())
+	require.NoError(t, err)
+	return s
+}
@@ -0,0 +1,146 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Store keeps one workspace per day, with its undo history. FileStore keeps
+// them in a directory, MemoryStore in memory.
+type Store interface {
+	// SaveWorkspace saves the workspace of a day
+	SaveWorkspace(date time.Time, state WorkspaceState) error
+	// LoadWorkspace loads the workspace of a day; a day never saved has an
+	// empty workspace
+	LoadWorkspace(date time.Time) (WorkspaceState, error)
+	// ListSavedDates returns every day with a saved workspace, newest first
+	ListSavedDates() ([]time.Time, error)
+	// WorkspaceExists reports whether a day has a saved workspace
+	WorkspaceExists(date time.Time) bool
+	// DeleteWorkspace deletes the workspace of a day and its history
+	DeleteWorkspace(date time.Time) error
+
+	// SaveHistory saves the undo history of a day
+	SaveHistory(date time.Time, state HistoryState) error
+	// LoadHistory loads the undo history of a day; a day without one, or
+	// whose workspace changed since it was saved, has an empty history
+	LoadHistory(date time.Time) (HistoryState, error)
+}
+
+// StorageDirEnv is the environment variable that sets the storage directory
+// when no -dir flag is given.
+const StorageDirEnv = "MOSUGO_DIR"
+
+// settingsFileName is the settings file in the default storage directory.
+// It can move the journal elsewhere: {"storage_dir": "~/Sync/Mosugo"}
+const settingsFileName = "settings.json"
+
+// Settings holds the options read from settings.json.
+type Settings struct {
+	StorageDir string `json:"storage_dir,omitempty"`
+
+	// UndoLimit is how many steps Undo can go back, and so how many are
+	// saved; 0 keeps the default and a negative limit keeps every step
+	UndoLimit int `json:"undo_limit,omitempty"`
+}
+
+// LoadSettings reads settings.json from the default storage directory; a
+// missing file means default settings.
+func LoadSettings() (Settings, error) {
+	defaultPath, err := GetStoragePath()
+	if err != nil {
+		return Settings{}, err
+	}
+	return loadSettings(filepath.Join(defaultPath, settingsFileName))
+}
+
+// ResolveStoragePath picks the directory workspaces are kept in: dirFlag
+// when set, then $MOSUGO_DIR, then storage_dir from settings.json, and
+// otherwise the default GetStoragePath directory. A leading ~ stands for the
+// home directory.
+func ResolveStoragePath(dirFlag string) (string, error) {
+	if dirFlag != "" {
+		return expandPath(dirFlag)
+	}
+	if dir := os.Getenv(StorageDirEnv); dir != "" {
+		return expandPath(dir)
+	}
+
+	settings, err := LoadSettings()
+	if err != nil {
+		return "", err
+	}
+	if settings.StorageDir != "" {
+		return expandPath(settings.StorageDir)
+	}
+	return GetStoragePath()
+}
+
+// loadSettings reads a settings file; a missing file means default settings
+func loadSettings(filePath string) (Settings, error) {
+	var settings Settings
+	data, err := os.ReadFile(filePath)
+	if os.IsNotExist(err) {
+		return settings, nil
+	}
+	if err != nil {
+		return settings, fmt.Errorf("failed to read settings file: %w", err)
+	}
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return settings, fmt.Errorf("failed to unmarshal settings: %w", err)
+	}
+	return settings, nil
+}
+
+// expandPath makes a directory setting absolute, expanding a leading ~
+func expandPath(path string) (string, error) {
+	if path == "~" || strings.HasPrefix(path, "~/") || strings.HasPrefix(path, `~\`) {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to get home directory: %w", err)
+		}
+		path = filepath.Join(home, path[1:])
+	}
+	return filepath.Abs(path)
+}
+
+// FileStore keeps each day's workspace in a YYYY-MM-DD.mosugo file, and its
+// history in YYYY-MM-DD.history, inside one directory.
+type FileStore struct {
+	root     string
+	readOnly bool // set when another instance holds the directory's lock
+
+	mu    sync.Mutex
+	known map[string][sha256.Size]byte // what each file held when last saved or loaded
+}
+
+// NewFileStore creates a store in the directory root, creating the
+// directory if it doesn't exist.
+func NewFileStore(root string) (*FileStore, error) {
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %w", err)
+	}
+	return &FileStore{root: root, known: make(map[string][sha256.Size]byte)}, nil
+}
+
+// Root returns the directory the store keeps its files in.
+func (s *FileStore) Root() string {
+	return s.root
+}
+
+// SetReadOnly makes every later save or delete fail with ErrReadOnly, for a
+// store whose directory another instance is writing to.
+func (s *FileStore) SetReadOnly(readOnly bool) {
+	s.readOnly = readOnly
+}
+
+// ReadOnly reports whether the store refuses to write.
+func (s *FileStore) ReadOnly() bool {
+	return s.readOnly
+}
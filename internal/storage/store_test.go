@@ -0,0 +1,125 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestStores runs the same checks against every Store implementation
+func TestStores(t *testing.T) {
+	stores := map[string]func(t *testing.T) Store{
+		"file":   func(t *testing.T) Store { return newTestStore(t) },
+		"memory": func(t *testing.T) Store { return NewMemoryStore() },
+	}
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			s := newStore(t)
+			day1, day2 := getTestDate(1), getTestDate(2)
+
+			loaded, err := s.LoadWorkspace(day1)
+			require.NoError(t, err)
+			assert.Equal(t, float32(1.0), loaded.Scale, "A day never saved is empty")
+			assert.False(t, s.WorkspaceExists(day1))
+
+			state := WorkspaceState{
+				Scale:   2,
+				Cards:   []MosuData{{ID: "card_1", Content: "Hello 世界", Width: 120, Height: 90}},
+				Strokes: []StrokeData{{P2X: 30, P2Y: 30, Width: 2.5, StrokeID: 1}},
+			}
+			require.NoError(t, s.SaveWorkspace(day1, state))
+			require.NoError(t, s.SaveWorkspace(day2, WorkspaceState{Scale: 1}))
+			state.Cards[0].Content = "changed after saving"
+
+			loaded, err = s.LoadWorkspace(day1)
+			require.NoError(t, err)
+			assert.Equal(t, WorkspaceVersion, loaded.Version)
+			assert.Equal(t, "2099-01-01", loaded.Date)
+			assert.Equal(t, "Hello 世界", loaded.Cards[0].Content)
+			assert.Equal(t, state.Strokes, loaded.Strokes)
+			assert.True(t, s.WorkspaceExists(day1))
+
+			dates, err := s.ListSavedDates()
+			require.NoError(t, err)
+			assert.Equal(t, []string{"2099-01-02", "2099-01-01"}, []string{dates[0].Format("2006-01-02"), dates[1].Format("2006-01-02")})
+
+			history := HistoryState{Undo: []HistoryEntry{{Kind: "stroke_color", StrokeID: 1}}}
+			require.NoError(t, s.SaveHistory(day1, history))
+			loadedHistory, err := s.LoadHistory(day1)
+			require.NoError(t, err)
+			assert.Equal(t, history.Undo, loadedHistory.Undo)
+			assert.NotNil(t, loadedHistory.Redo)
+
+			require.NoError(t, s.DeleteWorkspace(day1))
+			assert.False(t, s.WorkspaceExists(day1))
+			loadedHistory, err = s.LoadHistory(day1)
+			require.NoError(t, err)
+			assert.Empty(t, loadedHistory.Undo, "Deleting a workspace deletes its history")
+			dates, err = s.ListSavedDates()
+			require.NoError(t, err)
+			assert.Len(t, dates, 1)
+		})
+	}
+}
+
+// TestResolveStoragePath tests the order of the storage directory settings
+func TestResolveStoragePath(t *testing.T) {
+	config := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", config)
+	t.Setenv("APPDATA", config)
+	t.Setenv("HOME", config)
+	t.Setenv(StorageDirEnv, "")
+
+	defaultPath, err := GetStoragePath()
+	require.NoError(t, err)
+	path, err := ResolveStoragePath("")
+	require.NoError(t, err)
+	assert.Equal(t, defaultPath, path, "Nothing set means the default directory")
+
+	settings := filepath.Join(defaultPath, settingsFileName)
+	require.NoError(t, os.WriteFile(settings, []byte(`{"storage_dir": "~/Sync/Mosugo"}`), 0644))
+	path, err = ResolveStoragePath("")
+	require.NoError(t, err)
+	home, err := os.UserHomeDir()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(home, "Sync", "Mosugo"), path, "The settings file moves the journal")
+
+	envDir := filepath.Join(config, "env")
+	t.Setenv(StorageDirEnv, envDir)
+	path, err = ResolveStoragePath("")
+	require.NoError(t, err)
+	assert.Equal(t, envDir, path, "The environment beats the settings file")
+
+	flagDir := filepath.Join(config, "flag")
+	path, err = ResolveStoragePath(flagDir)
+	require.NoError(t, err)
+	assert.Equal(t, flagDir, path, "The flag beats everything")
+
+	t.Setenv(StorageDirEnv, "")
+	require.NoError(t, os.WriteFile(settings, []byte("{ invalid json }"), 0644))
+	_, err = ResolveStoragePath("")
+	assert.Error(t, err, "A broken settings file is reported, not ignored")
+}
+
+// TestLoadSettings tests reading the options of settings.json
+func TestLoadSettings(t *testing.T) {
+	config := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", config)
+	t.Setenv("APPDATA", config)
+	t.Setenv("HOME", config)
+
+	settings, err := LoadSettings()
+	require.NoError(t, err)
+	assert.Equal(t, Settings{}, settings, "No file means default settings")
+
+	defaultPath, err := GetStoragePath()
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(defaultPath, settingsFileName),
+		[]byte(`{"storage_dir": "~/Sync/Mosugo", "undo_limit": 50}`), 0644))
+	settings, err = LoadSettings()
+	require.NoError(t, err)
+	assert.Equal(t, Settings{StorageDir: "~/Sync/Mosugo", UndoLimit: 50}, settings)
+}
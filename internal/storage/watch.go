@@ -0,0 +1,138 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchSettle is how long a workspace file must stay quiet before a change
+// is reported, since sync tools and editors often write a file in steps.
+var watchSettle = 300 * time.Millisecond
+
+// WorkspaceWatcher reports workspace files changed in a FileStore's
+// directory by anything other than the store itself.
+type WorkspaceWatcher struct {
+	store   *FileStore
+	watcher *fsnotify.Watcher
+
+	mu      sync.Mutex
+	pending map[string]*time.Timer // settling changes, by file path
+	closed  bool
+}
+
+// Watch starts watching the store's directory. onChange is called, on the
+// watcher's own goroutine, with the date of every workspace file whose
+// contents changed other than through this store.
+func (s *FileStore) Watch(onChange func(date time.Time)) (*WorkspaceWatcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	if err := watcher.Add(s.root); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+
+	w := &WorkspaceWatcher{store: s, watcher: watcher, pending: make(map[string]*time.Timer)}
+	go w.run(onChange)
+	return w, nil
+}
+
+// Close stops watching.
+func (w *WorkspaceWatcher) Close() error {
+	w.mu.Lock()
+	w.closed = true
+	for _, timer := range w.pending {
+		timer.Stop()
+	}
+	w.mu.Unlock()
+	return w.watcher.Close()
+}
+
+func (w *WorkspaceWatcher) run(onChange func(date time.Time)) {
+	for {
+		select {
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			// Atomic saves arrive as a create (the rename), edits in place as writes
+			if !event.Has(fsnotify.Create) && !event.Has(fsnotify.Write) {
+				continue
+			}
+			if date, ok := workspaceFileDate(event.Name); ok {
+				w.settle(event.Name, date, onChange)
+			}
+		case _, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+		}
+	}
+}
+
+// settle reports a change once filePath has been quiet for watchSettle
+func (w *WorkspaceWatcher) settle(filePath string, date time.Time, onChange func(date time.Time)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return
+	}
+	if timer, ok := w.pending[filePath]; ok {
+		timer.Reset(watchSettle)
+		return
+	}
+	w.pending[filePath] = time.AfterFunc(watchSettle, func() {
+		w.mu.Lock()
+		delete(w.pending, filePath)
+		closed := w.closed
+		w.mu.Unlock()
+
+		if !closed && w.store.changedOnDisk(filePath) {
+			onChange(date)
+		}
+	})
+}
+
+// workspaceFileDate returns the date of a YYYY-MM-DD.mosugo path; backups,
+// temporary files and histories have none
+func workspaceFileDate(filePath string) (time.Time, bool) {
+	name := filepath.Base(filePath)
+	if !strings.HasSuffix(name, ".mosugo") {
+		return time.Time{}, false
+	}
+	date, err := time.Parse("2006-01-02", strings.TrimSuffix(name, ".mosugo"))
+	return date, err == nil
+}
+
+// noteContents records data as what filePath holds as far as the store knows
+func (s *FileStore) noteContents(filePath string, data []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.known[filePath] = sha256.Sum256(data)
+}
+
+// changedOnDisk reports whether filePath holds something the store did not
+// write or read last, and takes note of it so it is reported only once
+func (s *FileStore) changedOnDisk(filePath string) bool {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		// Gone, or still being replaced; a create follows if it comes back
+		return false
+	}
+	sum := sha256.Sum256(data)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if known, ok := s.known[filePath]; ok && known == sum {
+		return false
+	}
+	s.known[filePath] = sum
+	return true
+}
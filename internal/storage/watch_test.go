@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestWatchReportsExternalChanges tests that only changes made outside the store are reported
+func TestWatchReportsExternalChanges(t *testing.T) {
+	s := newTestStore(t)
+	testDate := getTestDate(3)
+
+	changes := make(chan time.Time, 10)
+	w, err := s.Watch(func(date time.Time) { changes <- date })
+	require.NoError(t, err)
+	defer w.Close()
+
+	expectNone := func(msg string) {
+		select {
+		case date := <-changes:
+			t.Fatalf("%s: unexpected change of %s", msg, date.Format("2006-01-02"))
+		case <-time.After(3 * watchSettle):
+		}
+	}
+	expectChange := func(msg string) {
+		select {
+		case date := <-changes:
+			assert.Equal(t, "2099-01-03", date.Format("2006-01-02"), msg)
+		case <-time.After(10 * watchSettle):
+			t.Fatalf("%s: no change reported", msg)
+		}
+	}
+
+	require.NoError(t, s.SaveWorkspace(testDate, workspaceWithCard("mine")))
+	require.NoError(t, s.SaveHistory(testDate, HistoryState{}))
+	expectNone("The store's own saves")
+
+	// Another machine replaces the file, as a sync tool would
+	other, err := NewFileStore(t.TempDir())
+	require.NoError(t, err)
+	require.NoError(t, other.SaveWorkspace(testDate, workspaceWithCard("theirs")))
+	data, err := os.ReadFile(other.workspacePath(testDate))
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(s.workspacePath(testDate), data, 0644))
+	expectChange("A file written from outside")
+
+	// Touching it without changing it again is not a change
+	require.NoError(t, os.WriteFile(s.workspacePath(testDate), data, 0644))
+	expectNone("Unchanged contents")
+
+	loaded, err := s.LoadWorkspace(testDate)
+	require.NoError(t, err)
+	assert.Equal(t, "theirs", loaded.Cards[0].Content)
+
+	// Backups and other files are not workspaces
+	require.NoError(t, os.WriteFile(filepath.Join(s.Root(), "notes.txt"), []byte("hi"), 0644))
+	require.NoError(t, os.WriteFile(backupPath(s.workspacePath(testDate), 1), []byte("{}"), 0644))
+	expectNone("Other files")
+}
@@ -0,0 +1,276 @@
+// Package strokes implements the Stroke widget used for freehand drawing on the canvas.
+// A stroke owns a whole polyline in world coordinates and renders it, with its
+// halo, as a single antialiased raster with round joins and caps.
+package strokes
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/F4tal1t/Mosugo/internal/theme"
+)
+
+// maxStampLength splits long segments while rasterizing, so the pixels visited
+// per segment stay close to its true footprint instead of its bounding box.
+const maxStampLength = 32
+
+// Stroke is a freehand polyline on the canvas.
+// Points and Width are in world units; the canvas positions the widget on
+// screen and tells it which part of the world it covers through SetView.
+type Stroke struct {
+	widget.BaseWidget
+	ID         int
+	ColorIndex int
+	Points     []fyne.Position
+	Width      float32
+	Color      color.Color
+	Halo       color.Color
+
+	viewOrigin fyne.Position // world point at the widget's top-left corner
+	viewScale  float32       // screen pixels per world unit
+	stale      bool          // points changed since the raster was drawn
+
+	raster *canvas.Raster
+}
+
+// NewStroke creates an empty stroke with the given ID, ink color and width.
+// colorIndex is the palette entry col was taken from, kept for saving.
+func NewStroke(id int, col color.Color, colorIndex int, width float32) *Stroke {
+	s := &Stroke{
+		ID:         id,
+		ColorIndex: colorIndex,
+		Width:      width,
+		Color:      col,
+		Halo:       theme.GridBg,
+		viewScale:  1,
+	}
+	s.ExtendBaseWidget(s)
+	return s
+}
+
+// Append adds a point to the end of the polyline.
+func (s *Stroke) Append(p fyne.Position) {
+	s.Points = append(s.Points, p)
+}
+
+// Segments returns the polyline as consecutive point pairs.
+// A single-point stroke yields one zero-length segment.
+func (s *Stroke) Segments() [][2]fyne.Position {
+	switch len(s.Points) {
+	case 0:
+		return nil
+	case 1:
+		return [][2]fyne.Position{{s.Points[0], s.Points[0]}}
+	}
+	segments := make([][2]fyne.Position, 0, len(s.Points)-1)
+	for i := 1; i < len(s.Points); i++ {
+		segments = append(segments, [2]fyne.Position{s.Points[i-1], s.Points[i]})
+	}
+	return segments
+}
+
+// HaloWidth is the full width of the halo drawn under the ink, in world units.
+func (s *Stroke) HaloWidth() float32 {
+	return s.Width * 2
+}
+
+// Bounds returns the world-space rectangle covered by the stroke and its halo.
+func (s *Stroke) Bounds() (fyne.Position, fyne.Position) {
+	if len(s.Points) == 0 {
+		return fyne.Position{}, fyne.Position{}
+	}
+	lo, hi := s.Points[0], s.Points[0]
+	for _, p := range s.Points[1:] {
+		lo = fyne.NewPos(fyne.Min(lo.X, p.X), fyne.Min(lo.Y, p.Y))
+		hi = fyne.NewPos(fyne.Max(hi.X, p.X), fyne.Max(hi.Y, p.Y))
+	}
+	pad := s.HaloWidth() / 2
+	return lo.SubtractXY(pad, pad), hi.AddXY(pad, pad)
+}
+
+// SetSelected switches the halo to the selection color and back.
+func (s *Stroke) SetSelected(selected bool) {
+	if selected {
+		s.Halo = theme.SelectionHalo
+	} else {
+		s.Halo = theme.GridBg
+	}
+	s.Invalidate()
+}
+
+// Translate moves every point of the stroke by delta.
+func (s *Stroke) Translate(delta fyne.Position) {
+	for i := range s.Points {
+		s.Points[i] = s.Points[i].Add(delta)
+	}
+}
+
+// Invalidate marks the raster out of date after Points, Width or colors changed.
+// It is redrawn on the next SetView.
+func (s *Stroke) Invalidate() {
+	s.stale = true
+}
+
+// SetView draws the world point origin at the widget's top-left corner, at the
+// given zoom, on a widget of the given screen size. The raster is regenerated
+// at most once, and only when the view or the stroke changed.
+func (s *Stroke) SetView(origin fyne.Position, scale float32, size fyne.Size) {
+	changed := s.stale || origin != s.viewOrigin || scale != s.viewScale
+	s.viewOrigin = origin
+	s.viewScale = scale
+	s.stale = false
+
+	if size != s.Size() {
+		// resizing the raster regenerates it
+		s.Resize(size)
+		return
+	}
+	if changed && s.raster != nil {
+		s.raster.Refresh()
+	}
+}
+
+func (s *Stroke) CreateRenderer() fyne.WidgetRenderer {
+	s.raster = canvas.NewRaster(s.render)
+	return widget.NewSimpleRenderer(s.raster)
+}
+
+// render draws the halo and the ink in one pass over a w x h pixel image.
+// Coverage is computed from the distance to each segment, which gives round
+// caps and joins and one pixel of antialiasing at every edge.
+func (s *Stroke) render(w, h int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	size := s.Size()
+	if w <= 0 || h <= 0 || size.Width <= 0 || len(s.Points) == 0 {
+		return img
+	}
+
+	// device pixels per world unit
+	k := s.viewScale * float32(w) / size.Width
+	inkRadius, inkAlpha := hairline(s.Width * k / 2)
+	haloRadius, haloAlpha := hairline(s.HaloWidth() * k / 2)
+
+	ink := make([]float32, w*h)
+	halo := make([]float32, w*h)
+
+	toPixels := func(p fyne.Position) fyne.Position {
+		return fyne.NewPos((p.X-s.viewOrigin.X)*k, (p.Y-s.viewOrigin.Y)*k)
+	}
+	for _, seg := range s.Segments() {
+		a, b := toPixels(seg[0]), toPixels(seg[1])
+		pieces := int(math.Ceil(float64(distance(a, b) / maxStampLength)))
+		if pieces < 1 {
+			pieces = 1
+		}
+		for i := 0; i < pieces; i++ {
+			p1 := lerp(a, b, float32(i)/float32(pieces))
+			p2 := lerp(a, b, float32(i+1)/float32(pieces))
+			stampCapsule(ink, halo, w, h, p1, p2, inkRadius, haloRadius)
+		}
+	}
+
+	inkR, inkG, inkB, inkA := s.Color.RGBA()
+	haloR, haloG, haloB, haloA := s.Halo.RGBA()
+	for i := range ink {
+		ic, hc := ink[i]*inkAlpha, halo[i]*haloAlpha
+		if ic == 0 && hc == 0 {
+			continue
+		}
+		// premultiplied "ink over halo"
+		under := hc * (1 - ic)
+		o := i * 4
+		img.Pix[o+0] = channel(inkR, haloR, ic, under)
+		img.Pix[o+1] = channel(inkG, haloG, ic, under)
+		img.Pix[o+2] = channel(inkB, haloB, ic, under)
+		img.Pix[o+3] = channel(inkA, haloA, ic, under)
+	}
+	return img
+}
+
+// hairline keeps lines thinner than a pixel one pixel wide and fades them
+// instead, so strokes of different widths keep their relative weight when
+// zoomed far out.
+func hairline(radius float32) (float32, float32) {
+	if radius >= 0.5 {
+		return radius, 1
+	}
+	return 0.5, radius / 0.5
+}
+
+// stampCapsule raises the coverage of every pixel near the segment a-b.
+func stampCapsule(ink, halo []float32, w, h int, a, b fyne.Position, inkRadius, haloRadius float32) {
+	reach := haloRadius + 1
+	x0 := clampInt(int(math.Floor(float64(fyne.Min(a.X, b.X)-reach))), 0, w)
+	x1 := clampInt(int(math.Ceil(float64(fyne.Max(a.X, b.X)+reach))), 0, w)
+	y0 := clampInt(int(math.Floor(float64(fyne.Min(a.Y, b.Y)-reach))), 0, h)
+	y1 := clampInt(int(math.Ceil(float64(fyne.Max(a.Y, b.Y)+reach))), 0, h)
+
+	for y := y0; y < y1; y++ {
+		for x := x0; x < x1; x++ {
+			d := segmentDistance(fyne.NewPos(float32(x)+0.5, float32(y)+0.5), a, b)
+			if d >= haloRadius+0.5 {
+				continue
+			}
+			i := y*w + x
+			if c := coverage(haloRadius, d); c > halo[i] {
+				halo[i] = c
+			}
+			if c := coverage(inkRadius, d); c > ink[i] {
+				ink[i] = c
+			}
+		}
+	}
+}
+
+// coverage is the share of a pixel at distance d covered by a disc of radius r.
+func coverage(r, d float32) float32 {
+	c := r + 0.5 - d
+	if c <= 0 {
+		return 0
+	}
+	if c >= 1 {
+		return 1
+	}
+	return c
+}
+
+func channel(top, bottom uint32, topCover, bottomCover float32) uint8 {
+	v := float32(top)*topCover + float32(bottom)*bottomCover
+	return uint8(fyne.Min(v/257, 255))
+}
+
+func segmentDistance(p, a, b fyne.Position) float32 {
+	dx, dy := b.X-a.X, b.Y-a.Y
+	lengthSq := dx*dx + dy*dy
+	if lengthSq < 0.0001 {
+		return distance(p, a)
+	}
+	t := ((p.X-a.X)*dx + (p.Y-a.Y)*dy) / lengthSq
+	t = fyne.Max(0, fyne.Min(1, t))
+	return distance(p, fyne.NewPos(a.X+t*dx, a.Y+t*dy))
+}
+
+func distance(p1, p2 fyne.Position) float32 {
+	dx := p2.X - p1.X
+	dy := p2.Y - p1.Y
+	return float32(math.Sqrt(float64(dx*dx + dy*dy)))
+}
+
+func lerp(a, b fyne.Position, t float32) fyne.Position {
+	return fyne.NewPos(a.X+(b.X-a.X)*t, a.Y+(b.Y-a.Y)*t)
+}
+
+func clampInt(v, lo, hi int) int {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
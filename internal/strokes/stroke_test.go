@@ -0,0 +1,117 @@
+package strokes
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	testInk  = color.NRGBA{R: 200, A: 255}
+	testHalo = color.NRGBA{B: 200, A: 255}
+)
+
+// newTestStroke draws a horizontal stroke 10 world units wide at 1:1 zoom
+// on a 100x40 pixel widget.
+func newTestStroke() *Stroke {
+	s := NewStroke(1, testInk, 0, 10)
+	s.Halo = testHalo
+	s.Points = []fyne.Position{fyne.NewPos(20, 20), fyne.NewPos(80, 20)}
+	s.SetView(fyne.NewPos(0, 0), 1, fyne.NewSize(100, 40))
+	return s
+}
+
+func pixel(img image.Image, x, y int) color.NRGBA {
+	return color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+}
+
+// TestStrokeBoundsIncludeHalo tests the world bounds used for indexing and layout
+func TestStrokeBoundsIncludeHalo(t *testing.T) {
+	s := NewStroke(1, testInk, 0, 4)
+	s.Points = []fyne.Position{fyne.NewPos(10, 50), fyne.NewPos(30, 20), fyne.NewPos(60, 40)}
+
+	lo, hi := s.Bounds()
+	assert.Equal(t, fyne.NewPos(6, 16), lo)
+	assert.Equal(t, fyne.NewPos(64, 54), hi)
+}
+
+// TestStrokeSegments tests polyline to segment conversion
+func TestStrokeSegments(t *testing.T) {
+	s := NewStroke(1, testInk, 0, 4)
+	assert.Empty(t, s.Segments())
+
+	s.Append(fyne.NewPos(5, 5))
+	assert.Equal(t, [][2]fyne.Position{{fyne.NewPos(5, 5), fyne.NewPos(5, 5)}}, s.Segments(), "A dot is a zero-length segment")
+
+	s.Append(fyne.NewPos(10, 5))
+	s.Append(fyne.NewPos(10, 15))
+	assert.Equal(t, [][2]fyne.Position{
+		{fyne.NewPos(5, 5), fyne.NewPos(10, 5)},
+		{fyne.NewPos(10, 5), fyne.NewPos(10, 15)},
+	}, s.Segments())
+}
+
+// TestStrokeRenderInkAndHalo tests that one raster carries both the ink and its halo
+func TestStrokeRenderInkAndHalo(t *testing.T) {
+	s := newTestStroke()
+	img := s.render(100, 40)
+
+	// On the center line: pure ink
+	assert.Equal(t, color.NRGBA{R: 200, A: 255}, pixel(img, 50, 20))
+	// Between the ink radius (5) and the halo radius (10): pure halo
+	assert.Equal(t, color.NRGBA{B: 200, A: 255}, pixel(img, 50, 27))
+	// Past the halo: transparent
+	assert.Zero(t, pixel(img, 50, 35).A)
+
+	// Round caps extend past the end points by the halo radius
+	assert.Equal(t, uint8(255), pixel(img, 14, 20).A)
+	assert.Zero(t, pixel(img, 8, 20).A)
+	// ...but stay round rather than square
+	assert.Zero(t, pixel(img, 12, 12).A)
+}
+
+// TestStrokeRenderAntialiasedEdge tests partial coverage on the halo edge
+func TestStrokeRenderAntialiasedEdge(t *testing.T) {
+	s := newTestStroke()
+	s.Points = []fyne.Position{fyne.NewPos(20, 20.3), fyne.NewPos(80, 20.3)}
+	img := s.render(100, 40)
+
+	// Pixel centre 10.2 from the center line, just past the halo radius of 10
+	edge := pixel(img, 50, 30)
+	assert.Greater(t, edge.A, uint8(0))
+	assert.Less(t, edge.A, uint8(255))
+}
+
+// TestStrokeRenderFollowsView tests world to pixel mapping with zoom and device scale
+func TestStrokeRenderFollowsView(t *testing.T) {
+	s := newTestStroke()
+	s.SetView(fyne.NewPos(10, 10), 2, fyne.NewSize(100, 40))
+
+	// Zoom 2 and a 2x device scale: (50,20) world -> (160,40) device pixels
+	img := s.render(200, 80)
+	require.Equal(t, image.Rect(0, 0, 200, 80), img.Bounds())
+	assert.Equal(t, color.NRGBA{R: 200, A: 255}, pixel(img, 160, 40))
+	// Ink radius is 5 world units, 20 device pixels
+	assert.Equal(t, color.NRGBA{R: 200, A: 255}, pixel(img, 160, 59))
+	assert.Equal(t, color.NRGBA{B: 200, A: 255}, pixel(img, 160, 61))
+}
+
+// TestStrokeRenderKeepsProportionsWhenZoomedOut tests that sub-pixel strokes fade rather than all clamping to one width
+func TestStrokeRenderKeepsProportionsWhenZoomedOut(t *testing.T) {
+	inkAt := func(width float32) float32 {
+		s := NewStroke(1, color.NRGBA{A: 255}, 0, width)
+		s.Halo = color.Transparent
+		s.Points = []fyne.Position{fyne.NewPos(0, 100), fyne.NewPos(1000, 100)}
+		s.SetView(fyne.NewPos(0, 0), 0.1, fyne.NewSize(100, 20))
+		return float32(pixel(s.render(100, 20), 50, 10).A)
+	}
+
+	// 2 and 4 world units are 0.2 and 0.4 pixels at 10% zoom
+	thin, thick := inkAt(2), inkAt(4)
+	assert.Greater(t, thin, float32(0))
+	assert.InDelta(t, 2, thick/thin, 0.1)
+}
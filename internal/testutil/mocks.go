@@ -1,40 +1,88 @@
 package testutil
 
 import (
+	"math"
+	"testing"
+
 	"github.com/F4tal1t/Mosugo/internal/cards"
 	"github.com/F4tal1t/Mosugo/internal/storage"
+	"github.com/F4tal1t/Mosugo/internal/strokes"
+	"github.com/F4tal1t/Mosugo/internal/tools"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/canvas"
 	"github.com/stretchr/testify/mock"
 )
 
-// MockCanvas is a mock implementation of the Canvas interface for testing
+// MockCanvas is a mock implementation of the Canvas interface for testing.
+// The coordinate helpers may be given a function of their argument to
+// return, in place of a fixed value.
 type MockCanvas struct {
 	mock.Mock
 }
 
+var _ tools.Canvas = (*MockCanvas)(nil)
+
+// MockGridSize is the grid NewMockCanvas snaps to, as the real canvas does
+const MockGridSize = 30
+
+// NewMockCanvas returns a MockCanvas showing the world at scale, with no
+// offset and a MockGridSize grid. Refresh may be called any number of times;
+// every other call has to be expected by the test, and is checked when it
+// ends.
+func NewMockCanvas(t *testing.T, scale float32) *MockCanvas {
+	m := &MockCanvas{}
+	m.On("GetScale").Return(scale).Maybe()
+	m.On("ScreenToWorld", mock.Anything).Return(func(pos fyne.Position) fyne.Position {
+		return fyne.NewPos(pos.X/scale, pos.Y/scale)
+	}).Maybe()
+	m.On("WorldToScreen", mock.Anything).Return(func(pos fyne.Position) fyne.Position {
+		return fyne.NewPos(pos.X*scale, pos.Y*scale)
+	}).Maybe()
+	m.On("Snap", mock.Anything).Return(func(v float32) float32 {
+		return float32(math.Floor(float64(v)/MockGridSize) * MockGridSize)
+	}).Maybe()
+	m.On("SnapUp", mock.Anything).Return(func(v float32) float32 {
+		return float32(math.Ceil(float64(v)/MockGridSize) * MockGridSize)
+	}).Maybe()
+	m.On("Refresh").Maybe()
+	t.Cleanup(func() { m.AssertExpectations(t) })
+	return m
+}
+
 func (m *MockCanvas) Refresh() {
 	m.Called()
 }
 
 func (m *MockCanvas) ScreenToWorld(pos fyne.Position) fyne.Position {
 	args := m.Called(pos)
+	if fn, ok := args.Get(0).(func(fyne.Position) fyne.Position); ok {
+		return fn(pos)
+	}
 	return args.Get(0).(fyne.Position)
 }
 
 func (m *MockCanvas) WorldToScreen(pos fyne.Position) fyne.Position {
 	args := m.Called(pos)
+	if fn, ok := args.Get(0).(func(fyne.Position) fyne.Position); ok {
+		return fn(pos)
+	}
 	return args.Get(0).(fyne.Position)
 }
 
 func (m *MockCanvas) Snap(v float32) float32 {
 	args := m.Called(v)
+	if fn, ok := args.Get(0).(func(float32) float32); ok {
+		return fn(v)
+	}
 	return args.Get(0).(float32)
 }
 
 func (m *MockCanvas) SnapUp(v float32) float32 {
 	args := m.Called(v)
+	if fn, ok := args.Get(0).(func(float32) float32); ok {
+		return fn(v)
+	}
 	return args.Get(0).(float32)
 }
 
@@ -100,6 +148,10 @@ func (m *MockCanvas) CommitCardMoved(card *cards.MosuWidget, before fyne.Positio
 	m.Called(card, before)
 }
 
+func (m *MockCanvas) CommitCardResized(card *cards.MosuWidget, beforePos fyne.Position, beforeSize fyne.Size) {
+	m.Called(card, beforePos, beforeSize)
+}
+
 func (m *MockCanvas) CommitStrokeCreated(segments []storage.StrokeData) {
 	m.Called(segments)
 }
@@ -120,6 +172,11 @@ func (m *MockCanvas) GhostRect() *canvas.Rectangle {
 	return args.Get(0).(*canvas.Rectangle)
 }
 
+func (m *MockCanvas) GenerateCardID() string {
+	args := m.Called()
+	return args.String(0)
+}
+
 func (m *MockCanvas) GenerateStrokeID() int {
 	args := m.Called()
 	return args.Int(0)
@@ -130,24 +187,49 @@ func (m *MockCanvas) ValidateStrokeID(strokeID int) bool {
 	return args.Bool(0)
 }
 
-func (m *MockCanvas) GetStrokeID(line *canvas.Line) (int, bool) {
-	args := m.Called(line)
-	return args.Int(0), args.Bool(1)
+func (m *MockCanvas) SimplifyStroke(points []fyne.Position, epsilon float32) []fyne.Position {
+	args := m.Called(points, epsilon)
+	return args.Get(0).([]fyne.Position)
 }
 
-func (m *MockCanvas) IsGlowLine(line *canvas.Line) bool {
-	args := m.Called(line)
-	return args.Bool(0)
+func (m *MockCanvas) StrokeByID(strokeID int) *strokes.Stroke {
+	args := m.Called(strokeID)
+	if args.Get(0) == nil {
+		return nil
+	}
+	return args.Get(0).(*strokes.Stroke)
 }
 
-func (m *MockCanvas) SimplifyStroke(points []fyne.Position, epsilon float32) []fyne.Position {
-	args := m.Called(points, epsilon)
-	return args.Get(0).([]fyne.Position)
+func (m *MockCanvas) StrokeAt(screenPos fyne.Position, tolerance float32) *strokes.Stroke {
+	args := m.Called(screenPos, tolerance)
+	if args.Get(0) == nil {
+		return nil
+	}
+	return args.Get(0).(*strokes.Stroke)
 }
 
-func (m *MockCanvas) GetStrokePoints(line *canvas.Line) (fyne.Position, fyne.Position, bool) {
-	args := m.Called(line)
-	return args.Get(0).(fyne.Position), args.Get(1).(fyne.Position), args.Bool(2)
+func (m *MockCanvas) UpdateStrokeBounds(stroke *strokes.Stroke) {
+	m.Called(stroke)
+}
+
+func (m *MockCanvas) CardAt(screenPos fyne.Position) *cards.MosuWidget {
+	args := m.Called(screenPos)
+	if args.Get(0) == nil {
+		return nil
+	}
+	return args.Get(0).(*cards.MosuWidget)
+}
+
+func (m *MockCanvas) ObjectsInRect(min, max fyne.Position) []fyne.CanvasObject {
+	args := m.Called(min, max)
+	if args.Get(0) == nil {
+		return nil
+	}
+	return args.Get(0).([]fyne.CanvasObject)
+}
+
+func (m *MockCanvas) UpdateCardBounds(card *cards.MosuWidget) {
+	m.Called(card)
 }
 
 func (m *MockCanvas) GetSelectedCard() *cards.MosuWidget {
@@ -161,3 +243,91 @@ func (m *MockCanvas) GetSelectedCard() *cards.MosuWidget {
 func (m *MockCanvas) SetSelectedCard(c *cards.MosuWidget) {
 	m.Called(c)
 }
+
+func (m *MockCanvas) Selection() []fyne.CanvasObject {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil
+	}
+	return args.Get(0).([]fyne.CanvasObject)
+}
+
+func (m *MockCanvas) IsSelected(obj fyne.CanvasObject) bool {
+	args := m.Called(obj)
+	return args.Bool(0)
+}
+
+func (m *MockCanvas) SetSelection(objs ...fyne.CanvasObject) {
+	m.Called(objs)
+}
+
+func (m *MockCanvas) AddToSelection(objs ...fyne.CanvasObject) {
+	m.Called(objs)
+}
+
+func (m *MockCanvas) ToggleSelected(obj fyne.CanvasObject) {
+	m.Called(obj)
+}
+
+func (m *MockCanvas) MoveSelection(delta fyne.Position) {
+	m.Called(delta)
+}
+
+func (m *MockCanvas) CommitSelectionMoved(delta fyne.Position) {
+	m.Called(delta)
+}
+
+func (m *MockCanvas) ShiftHeld() bool {
+	args := m.Called()
+	return args.Bool(0)
+}
+
+func (m *MockCanvas) Lasso() *strokes.Stroke {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil
+	}
+	return args.Get(0).(*strokes.Stroke)
+}
+
+func (m *MockCanvas) SelectionBounds() (min, max fyne.Position, ok bool) {
+	args := m.Called()
+	return args.Get(0).(fyne.Position), args.Get(1).(fyne.Position), args.Bool(2)
+}
+
+func (m *MockCanvas) BeginSelectionTransform() {
+	m.Called()
+}
+
+func (m *MockCanvas) TransformSelection(t tools.Transform) {
+	m.Called(t)
+}
+
+func (m *MockCanvas) CommitSelectionTransform() {
+	m.Called()
+}
+
+func (m *MockCanvas) BeginTransaction() {
+	m.Called()
+}
+
+func (m *MockCanvas) CommitTransaction() {
+	m.Called()
+}
+
+func (m *MockCanvas) RollbackTransaction() {
+	m.Called()
+}
+
+// MemoryClipboard is a fyne.Clipboard that keeps its content in memory
+type MemoryClipboard struct {
+	Text string
+}
+
+func (c *MemoryClipboard) Content() string {
+	return c.Text
+}
+
+func (c *MemoryClipboard) SetContent(content string) {
+	c.Text = content
+}
@@ -27,8 +27,57 @@ var (
 	GridLine      = color.RGBA{190, 190, 190, 255}
 	GridBg        = color.RGBA{220, 220, 220, 255}
 	SelectionBlue = color.RGBA{100, 150, 255, 255}
+	SelectionHalo = color.NRGBA{100, 150, 255, 120}
 )
 
+// CardPalette holds the card background colors, with CardColorNames naming
+// them for menus. Cards are saved by index, so new colors must only be appended.
+var (
+	CardPalette    = []color.Color{CardBg, CardYellow, CardTurquoise, CardPink}
+	CardColorNames = []string{"Default", "Yellow", "Turquoise", "Pink"}
+)
+
+// CardColor returns the card palette color at index i, falling back to the
+// first entry for indexes this version doesn't know.
+func CardColor(i int) color.Color {
+	if i < 0 || i >= len(CardPalette) {
+		return CardPalette[0]
+	}
+	return CardPalette[i]
+}
+
+// CardInk returns the text color that reads on card color i:
+// white on the dark default card, dark on the light ones.
+func CardInk(i int) color.Color {
+	if i <= 0 || i >= len(CardPalette) {
+		return InkWhite
+	}
+	return CardBg
+}
+
+// StrokePalette holds the ink colors offered by the Draw tool.
+// Strokes are saved by index, so new colors must only be appended.
+var StrokePalette = []color.Color{
+	InkGrey,
+	CardBg,
+	color.RGBA{214, 69, 65, 255},
+	color.RGBA{0, 113, 162, 255},
+	color.RGBA{46, 139, 87, 255},
+	color.RGBA{232, 145, 30, 255},
+}
+
+// StrokeWidths holds the pen widths offered by the Draw tool, in world units.
+var StrokeWidths = []float32{1.5, 2.5, 5}
+
+// StrokeColor returns the palette color at index i, falling back to the
+// first entry for indexes this version doesn't know.
+func StrokeColor(i int) color.Color {
+	if i < 0 || i >= len(StrokePalette) {
+		return StrokePalette[0]
+	}
+	return StrokePalette[i]
+}
+
 func NewMosugoTheme() fyne.Theme {
 	data, err := assets.FS.ReadFile("Comic.ttf")
 	if err != nil {
@@ -18,6 +18,12 @@ func BounceEasing(t float32) float32 {
 	}
 }
 
+// EaseOutCubic decelerates towards the end of the transition.
+func EaseOutCubic(t float32) float32 {
+	inv := 1 - t
+	return 1 - inv*inv*inv
+}
+
 func AnimateCardBounce(c Canvas, card *cards.MosuWidget) {
 	targetSize := card.WorldSize
 	targetPos := card.WorldPos
@@ -44,6 +50,7 @@ func AnimateCardBounce(c Canvas, card *cards.MosuWidget) {
 				fyne.DoAndWait(func() {
 					card.WorldSize = targetSize
 					card.WorldPos = targetPos
+					c.UpdateCardBounds(card)
 					c.Refresh()
 					c.MarkDirty()
 				})
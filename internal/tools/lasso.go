@@ -0,0 +1,251 @@
+package tools
+
+import (
+	"math"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/driver/desktop"
+
+	"github.com/F4tal1t/Mosugo/internal/cards"
+	"github.com/F4tal1t/Mosugo/internal/strokes"
+)
+
+const (
+	// RotateHandleOffset is how far above the transform box the rotate handle sits, in screen pixels
+	RotateHandleOffset = 24
+	// minTransformScale stops a scale drag from collapsing the selection to a point
+	minTransformScale = 0.05
+	// rotateStep is the angle Shift snaps rotation to
+	rotateStep = math.Pi / 12
+)
+
+// TransformHandle identifies a handle of the transform box drawn around a
+// lasso selection, in the order of TransformHandlePositions.
+type TransformHandle int
+
+const (
+	TransformNone TransformHandle = iota
+	TransformTopLeft
+	TransformTopRight
+	TransformBottomRight
+	TransformBottomLeft
+	TransformRotate
+)
+
+// TransformHandlePositions returns the screen positions of the transform box
+// handles for a box spanning lo-hi: the four corners, then the rotate handle.
+func TransformHandlePositions(lo, hi fyne.Position) []fyne.Position {
+	return []fyne.Position{
+		lo,
+		fyne.NewPos(hi.X, lo.Y),
+		hi,
+		fyne.NewPos(lo.X, hi.Y),
+		fyne.NewPos((lo.X+hi.X)/2, lo.Y-RotateHandleOffset),
+	}
+}
+
+// Transform maps world points by scaling and rotating them about Pivot,
+// then moving them by Offset.
+type Transform struct {
+	Pivot  fyne.Position
+	Scale  float32
+	Angle  float64 // radians, clockwise on screen
+	Offset fyne.Position
+}
+
+// Translation returns a Transform that only moves points by delta.
+func Translation(delta fyne.Position) Transform {
+	return Transform{Scale: 1, Offset: delta}
+}
+
+// Apply maps a world point through the transform.
+func (t Transform) Apply(p fyne.Position) fyne.Position {
+	sin, cos := math.Sincos(t.Angle)
+	dx := float64((p.X - t.Pivot.X) * t.Scale)
+	dy := float64((p.Y - t.Pivot.Y) * t.Scale)
+	return fyne.NewPos(
+		t.Pivot.X+float32(dx*cos-dy*sin)+t.Offset.X,
+		t.Pivot.Y+float32(dx*sin+dy*cos)+t.Offset.Y,
+	)
+}
+
+// LassoTool selects cards and strokes inside a freehand loop, then moves,
+// scales and rotates them through the handles of a box around the selection.
+type LassoTool struct {
+	isLassoing bool
+	handle     TransformHandle
+	isMoving   bool
+	start      fyne.Position // drag start in world space
+	pivot      fyne.Position // fixed point of a scale or rotation
+	rawDelta   fyne.Position // drag distance in world units
+}
+
+func (t *LassoTool) Name() string           { return "Lasso Tool" }
+func (t *LassoTool) Cursor() desktop.Cursor { return desktop.CrosshairCursor }
+
+func (t *LassoTool) OnTapped(c Canvas, e *fyne.PointEvent) {
+	// Clicking away from the selection drops it
+	if lo, hi, ok := c.SelectionBounds(); ok && within(c.ScreenToWorld(e.Position), lo, hi) {
+		return
+	}
+	c.SetSelection()
+	c.Refresh()
+}
+
+func (t *LassoTool) OnDragged(c Canvas, e *fyne.DragEvent) {
+	if !t.isLassoing && !t.isMoving && t.handle == TransformNone {
+		t.startDrag(c, e.Position.Subtract(e.Dragged))
+	}
+
+	scale := c.GetScale()
+	if scale <= 0 {
+		scale = 1.0
+	}
+	t.rawDelta.X += e.Dragged.DX / scale
+	t.rawDelta.Y += e.Dragged.DY / scale
+	current := t.start.Add(t.rawDelta)
+
+	switch {
+	case t.isLassoing:
+		lasso := c.Lasso()
+		lasso.Append(current)
+		lasso.Invalidate()
+	case t.isMoving:
+		c.TransformSelection(Translation(t.rawDelta))
+	case t.handle == TransformRotate:
+		angle := angleAround(t.pivot, current) - angleAround(t.pivot, t.start)
+		if c.ShiftHeld() {
+			angle = math.Round(angle/rotateStep) * rotateStep
+		}
+		c.TransformSelection(Transform{Pivot: t.pivot, Scale: 1, Angle: angle})
+	default:
+		// Corner handles scale uniformly about the opposite corner
+		factor := distance(t.pivot, current) / fyne.Max(distance(t.pivot, t.start), 1e-3)
+		c.TransformSelection(Transform{Pivot: t.pivot, Scale: fyne.Max(factor, minTransformScale)})
+	}
+	c.Refresh()
+}
+
+// startDrag decides what a drag starting at screen position start does.
+func (t *LassoTool) startDrag(c Canvas, start fyne.Position) {
+	t.start = c.ScreenToWorld(start)
+	t.rawDelta = fyne.Position{}
+
+	if lo, hi, ok := c.SelectionBounds(); ok {
+		// 1. A handle of the transform box scales or rotates the selection
+		if h := transformHandleAt(c, lo, hi, start); h != TransformNone {
+			t.handle = h
+			if h == TransformRotate {
+				t.pivot = fyne.NewPos((lo.X+hi.X)/2, (lo.Y+hi.Y)/2)
+			} else {
+				corners := TransformHandlePositions(lo, hi)
+				t.pivot = corners[(int(h-TransformTopLeft)+2)%4]
+			}
+			c.BeginSelectionTransform()
+			return
+		}
+		// 2. Inside the box moves the selection
+		if within(t.start, lo, hi) {
+			t.isMoving = true
+			c.BeginSelectionTransform()
+			return
+		}
+	}
+
+	// 3. Anywhere else draws a new lasso loop
+	t.isLassoing = true
+	lasso := c.Lasso()
+	lasso.Points = []fyne.Position{t.start}
+	lasso.Invalidate()
+	lasso.Show()
+}
+
+func (t *LassoTool) OnDragEnd(c Canvas) {
+	switch {
+	case t.isLassoing:
+		lasso := c.Lasso()
+		lasso.Hide()
+		inside := objectsInLoop(c, lasso.Points)
+		lasso.Points = nil
+
+		if c.ShiftHeld() {
+			c.AddToSelection(inside...)
+		} else {
+			c.SetSelection(inside...)
+		}
+	case t.isMoving || t.handle != TransformNone:
+		c.CommitSelectionTransform()
+	}
+	c.Refresh()
+	*t = LassoTool{}
+}
+
+// transformHandleAt returns the transform box handle under a screen position.
+// lo and hi are the selection bounds in world space.
+func transformHandleAt(c Canvas, lo, hi, screenPos fyne.Position) TransformHandle {
+	positions := TransformHandlePositions(c.WorldToScreen(lo), c.WorldToScreen(hi))
+	for i, p := range positions {
+		if distance(p, screenPos) <= handleTolerance {
+			return TransformTopLeft + TransformHandle(i)
+		}
+	}
+	return TransformNone
+}
+
+// objectsInLoop returns the cards and strokes lying entirely inside the
+// closed polygon loop, in world space.
+func objectsInLoop(c Canvas, loop []fyne.Position) []fyne.CanvasObject {
+	inside := []fyne.CanvasObject{}
+	if len(loop) < 3 {
+		return inside
+	}
+	lo, hi := loop[0], loop[0]
+	for _, p := range loop[1:] {
+		lo = fyne.NewPos(fyne.Min(lo.X, p.X), fyne.Min(lo.Y, p.Y))
+		hi = fyne.NewPos(fyne.Max(hi.X, p.X), fyne.Max(hi.Y, p.Y))
+	}
+
+	for _, obj := range c.ObjectsInRect(lo, hi) {
+		var points []fyne.Position
+		switch o := obj.(type) {
+		case *cards.MosuWidget:
+			end := o.WorldPos.Add(fyne.NewPos(o.WorldSize.Width, o.WorldSize.Height))
+			points = []fyne.Position{o.WorldPos, fyne.NewPos(end.X, o.WorldPos.Y), end, fyne.NewPos(o.WorldPos.X, end.Y)}
+		case *strokes.Stroke:
+			points = o.Points
+		}
+		if len(points) > 0 && allInPolygon(points, loop) {
+			inside = append(inside, obj)
+		}
+	}
+	return inside
+}
+
+func allInPolygon(points, polygon []fyne.Position) bool {
+	for _, p := range points {
+		if !inPolygon(p, polygon) {
+			return false
+		}
+	}
+	return true
+}
+
+// inPolygon reports whether p lies inside the closed polygon, by the even-odd rule.
+func inPolygon(p fyne.Position, polygon []fyne.Position) bool {
+	inside := false
+	for i, j := 0, len(polygon)-1; i < len(polygon); j, i = i, i+1 {
+		a, b := polygon[i], polygon[j]
+		if (a.Y > p.Y) != (b.Y > p.Y) && p.X < (b.X-a.X)*(p.Y-a.Y)/(b.Y-a.Y)+a.X {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+func angleAround(pivot, p fyne.Position) float64 {
+	return math.Atan2(float64(p.Y-pivot.Y), float64(p.X-pivot.X))
+}
+
+func distance(a, b fyne.Position) float32 {
+	return float32(math.Hypot(float64(b.X-a.X), float64(b.Y-a.Y)))
+}
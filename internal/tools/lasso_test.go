@@ -0,0 +1,182 @@
+package tools_test
+
+import (
+	"image/color"
+	"math"
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/F4tal1t/Mosugo/internal/cards"
+	"github.com/F4tal1t/Mosugo/internal/strokes"
+	"github.com/F4tal1t/Mosugo/internal/testutil"
+	"github.com/F4tal1t/Mosugo/internal/tools"
+)
+
+// dragThrough drags tool along screen points, one event per step, and ends the drag
+func dragThrough(tool tools.Tool, c tools.Canvas, points ...fyne.Position) {
+	for i := 1; i < len(points); i++ {
+		tool.OnDragged(c, &fyne.DragEvent{
+			PointEvent: fyne.PointEvent{Position: points[i]},
+			Dragged:    fyne.NewDelta(points[i].X-points[i-1].X, points[i].Y-points[i-1].Y),
+		})
+	}
+	tool.OnDragEnd(c)
+}
+
+func newTestCard(id string, x, y, w, h float32) *cards.MosuWidget {
+	card := cards.NewMosuWidget(id, color.White, 0)
+	card.WorldPos = fyne.NewPos(x, y)
+	card.WorldSize = fyne.NewSize(w, h)
+	return card
+}
+
+func newTestStroke(id int, points ...fyne.Position) *strokes.Stroke {
+	stroke := strokes.NewStroke(id, color.Black, 0, 2)
+	for _, p := range points {
+		stroke.Append(p)
+	}
+	return stroke
+}
+
+// cLoop is a C-shaped lasso loop: its notch, x 100-300 by y 100-200, lies
+// inside its bounds but outside the loop
+var cLoop = []fyne.Position{
+	{X: 0, Y: 0}, {X: 300, Y: 0}, {X: 300, Y: 100}, {X: 100, Y: 100},
+	{X: 100, Y: 200}, {X: 300, Y: 200}, {X: 300, Y: 300}, {X: 0, Y: 300},
+}
+
+// concaveFixture returns the objects a lasso along cLoop finds in its bounds,
+// and those of them inside the loop
+func concaveFixture() (candidates, inside []fyne.CanvasObject) {
+	inArm := newTestCard("card_arm", 20, 20, 60, 60)
+	inNotch := newTestCard("card_notch", 150, 120, 60, 60)
+	inTop := newTestStroke(1, fyne.NewPos(150, 30), fyne.NewPos(250, 60))
+	across := newTestStroke(2, fyne.NewPos(20, 250), fyne.NewPos(250, 150))
+	return []fyne.CanvasObject{inArm, inNotch, inTop, across}, []fyne.CanvasObject{inArm, inTop}
+}
+
+// TestLassoSelectsInsideConcaveLoop tests that only objects wholly inside the loop are selected
+func TestLassoSelectsInsideConcaveLoop(t *testing.T) {
+	tests := []struct {
+		name   string
+		shift  bool
+		method string
+	}{
+		{"replaces the selection", false, "SetSelection"},
+		{"shift adds to the selection", true, "AddToSelection"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := testutil.NewMockCanvas(t, 1)
+			lasso := strokes.NewStroke(0, color.Black, 0, 1)
+			candidates, inside := concaveFixture()
+			c.On("SelectionBounds").Return(fyne.Position{}, fyne.Position{}, false)
+			c.On("Lasso").Return(lasso)
+			c.On("ObjectsInRect", fyne.NewPos(0, 0), fyne.NewPos(300, 300)).Return(candidates)
+			c.On("ShiftHeld").Return(tt.shift)
+			c.On(tt.method, inside).Once()
+
+			dragThrough(&tools.LassoTool{}, c, append(cLoop, cLoop[0])...)
+			assert.False(t, lasso.Visible(), "The loop is hidden once drawn")
+			assert.Empty(t, lasso.Points)
+		})
+	}
+}
+
+// TestLassoIgnoresTinyLoop tests that a loop of fewer than three points selects nothing
+func TestLassoIgnoresTinyLoop(t *testing.T) {
+	c := testutil.NewMockCanvas(t, 1)
+	c.On("SelectionBounds").Return(fyne.Position{}, fyne.Position{}, false)
+	c.On("Lasso").Return(strokes.NewStroke(0, color.Black, 0, 1))
+	c.On("ShiftHeld").Return(false)
+	c.On("SetSelection", []fyne.CanvasObject{}).Once()
+
+	dragThrough(&tools.LassoTool{}, c, fyne.NewPos(0, 0), fyne.NewPos(50, 50))
+}
+
+// TestLassoDragPicksHandle tests what a drag does depending on where it starts around a selection
+func TestLassoDragPicksHandle(t *testing.T) {
+	// The selection spans world 100-200 on both axes
+	lo, hi := fyne.NewPos(100, 100), fyne.NewPos(200, 200)
+	transformed := func(want tools.Transform) interface{} {
+		return mock.MatchedBy(func(got tools.Transform) bool {
+			return got.Pivot == want.Pivot && got.Offset == want.Offset &&
+				math.Abs(float64(got.Scale-want.Scale)) < 1e-4 &&
+				math.Abs(got.Angle-want.Angle) < 1e-4
+		})
+	}
+
+	tests := []struct {
+		name     string
+		scale    float32
+		from, to fyne.Position // screen
+		want     tools.Transform
+	}{
+		{
+			name: "top-left corner scales about the opposite corner",
+			from: fyne.NewPos(100, 100), to: fyne.NewPos(50, 50),
+			want: tools.Transform{Pivot: hi, Scale: 1.5},
+		},
+		{
+			name: "a corner is grabbed within the tolerance",
+			from: fyne.NewPos(205, 95), to: fyne.NewPos(257.5, 42.5),
+			want: tools.Transform{Pivot: fyne.NewPos(100, 200), Scale: 1.5},
+		},
+		{
+			name: "the rotate handle rotates about the center",
+			from: fyne.NewPos(150, 100-tools.RotateHandleOffset), to: fyne.NewPos(224, 150),
+			want: tools.Transform{Pivot: fyne.NewPos(150, 150), Scale: 1, Angle: math.Pi / 2},
+		},
+		{
+			name: "inside the box moves",
+			from: fyne.NewPos(150, 150), to: fyne.NewPos(180, 170),
+			want: tools.Translation(fyne.NewPos(30, 20)),
+		},
+		{
+			name:  "handles are hit in screen pixels when zoomed",
+			scale: 2,
+			from:  fyne.NewPos(405, 405), to: fyne.NewPos(507.5, 507.5),
+			want: tools.Transform{Pivot: lo, Scale: 1.5},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scale := tt.scale
+			if scale == 0 {
+				scale = 1
+			}
+			c := testutil.NewMockCanvas(t, scale)
+			c.On("SelectionBounds").Return(lo, hi, true)
+			c.On("ShiftHeld").Return(false).Maybe()
+			c.On("BeginSelectionTransform").Once()
+			c.On("TransformSelection", transformed(tt.want)).Once()
+			c.On("CommitSelectionTransform").Once()
+
+			dragThrough(&tools.LassoTool{}, c, tt.from, tt.to)
+		})
+	}
+}
+
+// TestLassoDragOutsideSelectionDrawsLoop tests that a drag away from the selection starts a new loop
+func TestLassoDragOutsideSelectionDrawsLoop(t *testing.T) {
+	c := testutil.NewMockCanvas(t, 1)
+	lasso := strokes.NewStroke(0, color.Black, 0, 1)
+	c.On("SelectionBounds").Return(fyne.NewPos(100, 100), fyne.NewPos(200, 200), true)
+	c.On("Lasso").Return(lasso)
+	c.On("ObjectsInRect", mock.Anything, mock.Anything).Return([]fyne.CanvasObject{})
+	c.On("ShiftHeld").Return(false)
+	c.On("SetSelection", []fyne.CanvasObject{}).Once()
+
+	tool := &tools.LassoTool{}
+	tool.OnDragged(c, &fyne.DragEvent{PointEvent: fyne.PointEvent{Position: fyne.NewPos(320, 300)}, Dragged: fyne.NewDelta(20, 0)})
+	tool.OnDragged(c, &fyne.DragEvent{PointEvent: fyne.PointEvent{Position: fyne.NewPos(320, 340)}, Dragged: fyne.NewDelta(0, 40)})
+	assert.Equal(t, []fyne.Position{{X: 300, Y: 300}, {X: 320, Y: 300}, {X: 320, Y: 340}}, lasso.Points)
+	assert.True(t, lasso.Visible())
+	tool.OnDragEnd(c)
+	c.AssertNotCalled(t, "BeginSelectionTransform")
+}
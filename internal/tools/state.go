@@ -7,6 +7,7 @@ const (
 	ToolCard
 	ToolDraw
 	ToolErase
+	ToolLasso
 )
 
 func (t ToolType) String() string {
@@ -19,6 +20,8 @@ func (t ToolType) String() string {
 		return "Draw Mode"
 	case ToolErase:
 		return "Erase Mode"
+	case ToolLasso:
+		return "Lasso Mode"
 	default:
 		return "Unknown"
 	}
@@ -1,10 +1,10 @@
 // Package tools implements the tool state machine for Mosugo's drawing modes.
-// It provides four tool types (Select, Card, Draw, Erase) that handle mouse events
-// and translate them into canvas operations. Tools are selected via numpad keys 1-4.
+// It provides five tool types (Select, Card, Draw, Erase, Lasso) that handle mouse
+// events and translate them into canvas operations. Tools are selected via number keys.
 package tools
 
 import (
-	"fmt"
+	"image/color"
 	"math"
 
 	"fyne.io/fyne/v2"
@@ -13,6 +13,7 @@ import (
 
 	"github.com/F4tal1t/Mosugo/internal/cards"
 	"github.com/F4tal1t/Mosugo/internal/storage"
+	"github.com/F4tal1t/Mosugo/internal/strokes"
 	"github.com/F4tal1t/Mosugo/internal/theme"
 )
 
@@ -40,26 +41,55 @@ type Canvas interface {
 	CommitCardCreated(card *cards.MosuWidget)
 	CommitCardDeleted(data storage.MosuData)
 	CommitCardMoved(card *cards.MosuWidget, before fyne.Position)
+	CommitCardResized(card *cards.MosuWidget, beforePos fyne.Position, beforeSize fyne.Size)
 	CommitStrokeCreated(segments []storage.StrokeData)
 	CommitStrokeDeleted(segments []storage.StrokeData)
 
+	// History transactions group the commands of one gesture into one step
+	BeginTransaction()
+	CommitTransaction()
+	RollbackTransaction()
+
 	// Persistence
 	MarkDirty()
 
 	// Visual helpers
 	GhostRect() *canvas.Rectangle
 
+	// Card management
+	GenerateCardID() string
+
 	// Stroke management
 	GenerateStrokeID() int
 	ValidateStrokeID(strokeID int) bool
-	GetStrokeID(line *canvas.Line) (int, bool)
-	IsGlowLine(line *canvas.Line) bool
 	SimplifyStroke(points []fyne.Position, epsilon float32) []fyne.Position
-	GetStrokePoints(line *canvas.Line) (fyne.Position, fyne.Position, bool)
+	StrokeByID(strokeID int) *strokes.Stroke
 
-	// State needed for tools
+	// Hit testing (backed by the canvas spatial index)
+	CardAt(screenPos fyne.Position) *cards.MosuWidget
+	StrokeAt(screenPos fyne.Position, tolerance float32) *strokes.Stroke
+	ObjectsInRect(min, max fyne.Position) []fyne.CanvasObject
+	UpdateCardBounds(card *cards.MosuWidget)
+	UpdateStrokeBounds(stroke *strokes.Stroke)
+
+	// Selection of cards and strokes
 	GetSelectedCard() *cards.MosuWidget
 	SetSelectedCard(c *cards.MosuWidget)
+	Selection() []fyne.CanvasObject
+	IsSelected(obj fyne.CanvasObject) bool
+	SetSelection(objs ...fyne.CanvasObject)
+	AddToSelection(objs ...fyne.CanvasObject)
+	ToggleSelected(obj fyne.CanvasObject)
+	MoveSelection(delta fyne.Position)
+	CommitSelectionMoved(delta fyne.Position)
+	ShiftHeld() bool
+
+	// Lasso selection and transforms
+	Lasso() *strokes.Stroke
+	SelectionBounds() (min, max fyne.Position, ok bool)
+	BeginSelectionTransform()
+	TransformSelection(t Transform)
+	CommitSelectionTransform()
 }
 
 type Tool interface {
@@ -70,122 +100,260 @@ type Tool interface {
 	OnDragEnd(c Canvas)
 }
 
+const (
+	// handleTolerance is how close, in screen pixels, a drag must start to grab a resize handle
+	handleTolerance = 8
+	// pickTolerance is how close, in screen pixels, a click must land to pick a stroke
+	pickTolerance = 6
+	// minCardSize is the smallest width and height a resize can leave, in world units
+	minCardSize = 60
+)
+
 type SelectTool struct {
-	isMovingCard bool
-	rawPos       fyne.Position
-	dragStartPos fyne.Position
+	isMoving      bool
+	isMarquee     bool
+	resizeHandle  cards.ResizeHandle
+	dragStartPos  fyne.Position // resized card position, or marquee corner
+	dragStartSize fyne.Size
+	rawDelta      fyne.Position // unsnapped drag distance in world units
+	applied       fyne.Position // part of the drag already applied to the selection
+
+	ghostFill   color.Color // ghost rect style to restore after a marquee
+	ghostStroke color.Color
 }
 
 func (t *SelectTool) Name() string           { return "Select Tool" }
 func (t *SelectTool) Cursor() desktop.Cursor { return desktop.DefaultCursor }
 
 func (t *SelectTool) OnTapped(c Canvas, e *fyne.PointEvent) {
-	// Check if we tapped on a card to select it
-	objects := c.ContentContainer().Objects
-	// Iterate backwards to hit top-most first
-	for i := len(objects) - 1; i >= 0; i-- {
-		obj := objects[i]
-		if mosuW, ok := obj.(*cards.MosuWidget); ok {
-			screenPos := c.WorldToScreen(mosuW.WorldPos)
-			size := mosuW.Size() // Screen size
-
-			if e.Position.X >= screenPos.X && e.Position.X <= screenPos.X+size.Width &&
-				e.Position.Y >= screenPos.Y && e.Position.Y <= screenPos.Y+size.Height {
-
-				if c.GetSelectedCard() != nil && c.GetSelectedCard() != mosuW {
-					c.GetSelectedCard().SetSelected(false)
-				}
-				c.SetSelectedCard(mosuW)
-				mosuW.SetSelected(true)
-				c.Refresh()
-				return
-			}
+	obj := objectAt(c, e.Position)
+
+	// Shift-click adds to or removes from the selection
+	if c.ShiftHeld() {
+		if obj != nil {
+			c.ToggleSelected(obj)
 		}
+		c.Refresh()
+		return
 	}
 
-	// Deselect if clicked empty space
-	if c.GetSelectedCard() != nil {
-		c.GetSelectedCard().SetSelected(false)
-		c.SetSelectedCard(nil)
-		c.Refresh()
+	if obj != nil {
+		c.SetSelection(obj)
+	} else {
+		// Deselect if clicked empty space
+		c.SetSelection()
 	}
+	c.Refresh()
 }
 
 func (t *SelectTool) OnDragged(c Canvas, e *fyne.DragEvent) {
-	// 1. Identify where the drag started
-	dragStartScreen := e.Position.Subtract(e.Dragged)
-
-	// 2. If we aren't already moving a card, check if we clicked on one
-	if !t.isMovingCard {
-		objects := c.ContentContainer().Objects
-		// Iterate backwards (top-most first)
-		for i := len(objects) - 1; i >= 0; i-- {
-			obj := objects[i]
-			if mosuW, ok := obj.(*cards.MosuWidget); ok {
-				sPos := c.WorldToScreen(mosuW.WorldPos)
-				sSize := mosuW.Size()
-
-				// Hit Test on Drag Start Position
-				if dragStartScreen.X >= sPos.X && dragStartScreen.X <= sPos.X+sSize.Width &&
-					dragStartScreen.Y >= sPos.Y && dragStartScreen.Y <= sPos.Y+sSize.Height {
-
-					// Select this card immediately
-					if c.GetSelectedCard() != nil && c.GetSelectedCard() != mosuW {
-						c.GetSelectedCard().SetSelected(false)
-					}
-					c.SetSelectedCard(mosuW)
-					mosuW.SetSelected(true)
-					t.isMovingCard = true // Start moving mode
-					t.dragStartPos = mosuW.WorldPos
-					t.rawPos = mosuW.WorldPos
-					break // Found the target
-				}
+	if !t.isMoving && !t.isMarquee && t.resizeHandle == cards.HandleNone {
+		t.startDrag(c, e.Position.Subtract(e.Dragged))
+	}
+
+	scale := c.GetScale()
+	if scale <= 0 {
+		scale = 1.0
+	}
+	// Convert screen delta to world delta
+	t.rawDelta.X += e.Dragged.DX / scale
+	t.rawDelta.Y += e.Dragged.DY / scale
+
+	switch {
+	case t.resizeHandle != cards.HandleNone:
+		t.resizeCard(c)
+	case t.isMoving:
+		t.moveSelection(c)
+	case t.isMarquee:
+		t.updateMarquee(c)
+	}
+	c.Refresh()
+}
+
+// startDrag decides what a drag starting at screen position start does.
+func (t *SelectTool) startDrag(c Canvas, start fyne.Position) {
+	t.rawDelta = fyne.Position{}
+	t.applied = fyne.Position{}
+
+	// 1. A handle of the selected card resizes it
+	if card := c.GetSelectedCard(); card != nil {
+		if h := handleAt(c, card, start); h != cards.HandleNone {
+			t.resizeHandle = h
+			t.dragStartPos = card.WorldPos
+			t.dragStartSize = card.WorldSize
+			return
+		}
+	}
+
+	// 2. A card or stroke moves the selection, joining it first if needed
+	if obj := objectAt(c, start); obj != nil {
+		if !c.IsSelected(obj) {
+			if c.ShiftHeld() {
+				c.AddToSelection(obj)
+			} else {
+				c.SetSelection(obj)
 			}
 		}
+		t.isMoving = true
+		return
 	}
 
-	// 3. Move Logic
-	if t.isMovingCard && c.GetSelectedCard() != nil {
-		card := c.GetSelectedCard()
+	// 3. Empty space draws a selection marquee
+	t.isMarquee = true
+	t.dragStartPos = c.ScreenToWorld(start)
+
+	ghost := c.GhostRect()
+	t.ghostFill, t.ghostStroke = ghost.FillColor, ghost.StrokeColor
+	ghost.FillColor = theme.SelectionHalo
+	ghost.StrokeColor = theme.SelectionBlue
+	ghost.Resize(fyne.NewSize(0, 0))
+	ghost.Show()
+}
 
-		scale := c.GetScale()
-		if scale <= 0 {
-			scale = 1.0
+func (t *SelectTool) OnDragEnd(c Canvas) {
+	switch {
+	case t.resizeHandle != cards.HandleNone:
+		if card := c.GetSelectedCard(); card != nil {
+			c.UpdateCardBounds(card)
+			c.CommitCardResized(card, t.dragStartPos, t.dragStartSize)
+		}
+	case t.isMoving:
+		c.CommitSelectionMoved(t.applied)
+	case t.isMarquee:
+		ghost := c.GhostRect()
+		ghost.Hide()
+		ghost.FillColor, ghost.StrokeColor = t.ghostFill, t.ghostStroke
+
+		lo, hi := orderedCorners(t.dragStartPos, t.dragStartPos.Add(t.rawDelta))
+		inside := objectsInside(c, lo, hi)
+		if c.ShiftHeld() {
+			c.AddToSelection(inside...)
+		} else {
+			c.SetSelection(inside...)
 		}
+	}
+	c.Refresh()
+	*t = SelectTool{}
+}
 
-		// Convert screen delta to world delta
-		dx := e.Dragged.DX / scale
-		dy := e.Dragged.DY / scale
+// resizeCard moves the edges of the grabbed handle along with the drag.
+func (t *SelectTool) resizeCard(c Canvas) {
+	card := c.GetSelectedCard()
+	if card == nil {
+		return
+	}
+	card.WorldPos, card.WorldSize = resizeBounds(c, t.dragStartPos, t.dragStartSize, t.resizeHandle, t.rawDelta)
+	c.UpdateCardBounds(card)
+}
 
-		t.rawPos.X += dx
-		t.rawPos.Y += dy
+// moveSelection drags the selection along. A selection holding cards moves
+// in whole grid cells, so the cards stay on the grid.
+func (t *SelectTool) moveSelection(c Canvas) {
+	delta := t.rawDelta
+	for _, obj := range c.Selection() {
+		if _, ok := obj.(*cards.MosuWidget); ok {
+			delta = fyne.NewPos(snapNearest(c, delta.X), snapNearest(c, delta.Y))
+			break
+		}
+	}
+	if step := delta.Subtract(t.applied); !step.IsZero() {
+		c.MoveSelection(step)
+		t.applied = delta
+	}
+}
 
-		card.WorldPos.X = c.Snap(t.rawPos.X)
-		card.WorldPos.Y = c.Snap(t.rawPos.Y)
+// updateMarquee stretches the ghost rect from the drag start to the pointer.
+func (t *SelectTool) updateMarquee(c Canvas) {
+	lo, hi := orderedCorners(t.dragStartPos, t.dragStartPos.Add(t.rawDelta))
+	scale := c.GetScale()
+	c.GhostRect().Move(c.WorldToScreen(lo))
+	c.GhostRect().Resize(fyne.NewSize((hi.X-lo.X)*scale, (hi.Y-lo.Y)*scale))
+}
 
-		c.Refresh()
-		return
+// objectAt returns the card or stroke under a screen position.
+// Cards are drawn above strokes, so they win.
+func objectAt(c Canvas, screenPos fyne.Position) fyne.CanvasObject {
+	if card := c.CardAt(screenPos); card != nil {
+		return card
+	}
+	if stroke := c.StrokeAt(screenPos, pickTolerance); stroke != nil {
+		return stroke
 	}
+	return nil
+}
 
-	// 4. Pan Logic (Fallback if no card hit)
-	current := c.GetOffset()
-	c.SetOffset(fyne.NewPos(
-		current.X+e.Dragged.DX,
-		current.Y+e.Dragged.DY,
-	))
-	c.Refresh()
+// objectsInside returns the cards and strokes lying entirely within the
+// world rectangle lo-hi.
+func objectsInside(c Canvas, lo, hi fyne.Position) []fyne.CanvasObject {
+	inside := []fyne.CanvasObject{}
+	for _, obj := range c.ObjectsInRect(lo, hi) {
+		switch o := obj.(type) {
+		case *cards.MosuWidget:
+			end := o.WorldPos.Add(fyne.NewPos(o.WorldSize.Width, o.WorldSize.Height))
+			if within(o.WorldPos, lo, hi) && within(end, lo, hi) {
+				inside = append(inside, o)
+			}
+		case *strokes.Stroke:
+			all := len(o.Points) > 0
+			for _, p := range o.Points {
+				if !within(p, lo, hi) {
+					all = false
+					break
+				}
+			}
+			if all {
+				inside = append(inside, o)
+			}
+		}
+	}
+	return inside
 }
 
-func (t *SelectTool) OnDragEnd(c Canvas) {
-	if t.isMovingCard && c.GetSelectedCard() != nil {
-		card := c.GetSelectedCard()
-		card.WorldPos.X = c.Snap(card.WorldPos.X)
-		card.WorldPos.Y = c.Snap(card.WorldPos.Y)
-		c.CommitCardMoved(card, t.dragStartPos)
-		c.Refresh()
+func within(p, lo, hi fyne.Position) bool {
+	return p.X >= lo.X && p.X <= hi.X && p.Y >= lo.Y && p.Y <= hi.Y
+}
+
+func orderedCorners(a, b fyne.Position) (fyne.Position, fyne.Position) {
+	return fyne.NewPos(fyne.Min(a.X, b.X), fyne.Min(a.Y, b.Y)),
+		fyne.NewPos(fyne.Max(a.X, b.X), fyne.Max(a.Y, b.Y))
+}
+
+// handleAt returns the resize handle of card under screenPos, if any.
+func handleAt(c Canvas, card *cards.MosuWidget, screenPos fyne.Position) cards.ResizeHandle {
+	scale := c.GetScale()
+	origin := c.WorldToScreen(card.WorldPos)
+	size := fyne.NewSize(card.WorldSize.Width*scale, card.WorldSize.Height*scale)
+	return cards.HandleAt(size, screenPos.Subtract(origin), handleTolerance)
+}
+
+// resizeBounds moves the edges named by h by delta in world units. Moved edges
+// snap to the nearest grid line and opposite edges stay minCardSize apart.
+func resizeBounds(c Canvas, pos fyne.Position, size fyne.Size, h cards.ResizeHandle, delta fyne.Position) (fyne.Position, fyne.Size) {
+	left, top := pos.X, pos.Y
+	right, bottom := pos.X+size.Width, pos.Y+size.Height
+
+	if h&cards.HandleLeft != 0 {
+		left = fyne.Min(snapNearest(c, left+delta.X), right-minCardSize)
+	}
+	if h&cards.HandleRight != 0 {
+		right = fyne.Max(snapNearest(c, right+delta.X), left+minCardSize)
+	}
+	if h&cards.HandleTop != 0 {
+		top = fyne.Min(snapNearest(c, top+delta.Y), bottom-minCardSize)
 	}
-	t.isMovingCard = false
-	t.dragStartPos = fyne.Position{}
+	if h&cards.HandleBottom != 0 {
+		bottom = fyne.Max(snapNearest(c, bottom+delta.Y), top+minCardSize)
+	}
+	return fyne.NewPos(left, top), fyne.NewSize(right-left, bottom-top)
+}
+
+// snapNearest snaps v to whichever grid line is closer.
+func snapNearest(c Canvas, v float32) float32 {
+	down, up := c.Snap(v), c.SnapUp(v)
+	if v-down <= up-v {
+		return down
+	}
+	return up
 }
 
 type CardTool struct {
@@ -269,8 +437,7 @@ func (t *CardTool) OnDragEnd(c Canvas) {
 			return
 		}
 
-		cardID := fmt.Sprintf("card_%d", len(c.ContentContainer().Objects))
-		newCard := cards.NewMosuWidget(cardID, theme.CardBg, 0) // colorIndex 0 = default card color
+		newCard := cards.NewMosuWidget(c.GenerateCardID(), theme.CardColor(0), 0) // colorIndex 0 = default card color
 		newCard.SetOnDirty(c.MarkDirty)
 
 		newCard.WorldPos = fyne.NewPos(c.Snap(worldPos.X), c.Snap(worldPos.Y))
@@ -290,7 +457,6 @@ type DrawTool struct {
 	lastDrawPos     fyne.Position
 	isDrawing       bool
 	currentStrokeID int
-	strokeLines     []*canvas.Line
 }
 
 func (t *DrawTool) Name() string           { return "Draw Tool" }
@@ -304,7 +470,6 @@ func (t *DrawTool) OnDragged(c Canvas, e *fyne.DragEvent) {
 		// Generate stroke ID immediately for real-time drawing
 		t.currentStrokeID = c.GenerateStrokeID()
 		t.lastDrawPos = e.Position
-		t.strokeLines = []*canvas.Line{}
 		return
 	}
 
@@ -327,49 +492,10 @@ func (t *DrawTool) OnDragged(c Canvas, e *fyne.DragEvent) {
 
 func (t *DrawTool) OnDragEnd(c Canvas) {
 	if t.isDrawing {
-		// Collect world coordinates from all line segments drawn
-		points := []fyne.Position{}
-		objects := c.ContentContainer().Objects
-
-		for _, obj := range objects {
-			if line, ok := obj.(*canvas.Line); ok {
-				if id, exists := c.GetStrokeID(line); exists && id == t.currentStrokeID {
-					p1, p2, ok := c.GetStrokePoints(line)
-					if ok {
-						if len(points) == 0 {
-							points = append(points, p1)
-						}
-						points = append(points, p2)
-					}
-				}
-			}
-		}
-
-		// Only simplify if we have enough points
-		if len(points) > 3 {
-			// Apply Douglas-Peucker simplification
-			simplifiedPoints := c.SimplifyStroke(points, 3.0)
-
-			// Remove original segments
-			toRemove := []fyne.CanvasObject{}
-			for _, obj := range objects {
-				if line, ok := obj.(*canvas.Line); ok {
-					if id, exists := c.GetStrokeID(line); exists && id == t.currentStrokeID {
-						toRemove = append(toRemove, line)
-					}
-				}
-			}
-
-			for _, obj := range toRemove {
-				c.RemoveObject(obj)
-			}
-
-			// Redraw with simplified points using same stroke ID
-			for i := 0; i < len(simplifiedPoints)-1; i++ {
-				p1 := simplifiedPoints[i]
-				p2 := simplifiedPoints[i+1]
-				c.AddStroke(p1, p2, t.currentStrokeID)
-			}
+		// Apply Douglas-Peucker simplification once the stroke is finished
+		if stroke := c.StrokeByID(t.currentStrokeID); stroke != nil && len(stroke.Points) > 3 {
+			stroke.Points = c.SimplifyStroke(stroke.Points, 3.0)
+			c.UpdateStrokeBounds(stroke)
 		}
 
 		segments := c.CollectStrokeDataByID(t.currentStrokeID)
@@ -381,12 +507,15 @@ func (t *DrawTool) OnDragEnd(c Canvas) {
 
 	// Reset state
 	t.isDrawing = false
-	t.strokeLines = nil
 	t.currentStrokeID = 0
 }
 
 // Erase Tool
-type EraseTool struct{}
+// EraseTool removes the cards and strokes it touches. Everything one drag
+// erases is a single undo step.
+type EraseTool struct {
+	dragging bool // a drag is under way, inside a history transaction
+}
 
 func (t *EraseTool) Name() string           { return "Erase Tool" }
 func (t *EraseTool) Cursor() desktop.Cursor { return desktop.HResizeCursor } // Placeholder
@@ -395,9 +524,18 @@ func (t *EraseTool) OnTapped(c Canvas, e *fyne.PointEvent) {
 	t.eraseAt(c, e.Position)
 }
 func (t *EraseTool) OnDragged(c Canvas, e *fyne.DragEvent) {
+	if !t.dragging {
+		t.dragging = true
+		c.BeginTransaction()
+	}
 	t.eraseAt(c, e.Position)
 }
-func (t *EraseTool) OnDragEnd(c Canvas) {}
+func (t *EraseTool) OnDragEnd(c Canvas) {
+	if t.dragging {
+		t.dragging = false
+		c.CommitTransaction()
+	}
+}
 
 func (t *EraseTool) eraseAt(c Canvas, screenPos fyne.Position) {
 	// First try to erase cards
@@ -409,135 +547,25 @@ func (t *EraseTool) eraseAt(c Canvas, screenPos fyne.Position) {
 }
 
 func (t *EraseTool) eraseCardAt(c Canvas, screenPos fyne.Position) bool {
-	objects := c.ContentContainer().Objects
-	for i := len(objects) - 1; i >= 0; i-- {
-		obj := objects[i]
-		if mosuW, ok := obj.(*cards.MosuWidget); ok {
-			sPos := mosuW.Position()
-			sSize := mosuW.Size()
-
-			if screenPos.X >= sPos.X && screenPos.X <= sPos.X+sSize.Width &&
-				screenPos.Y >= sPos.Y && screenPos.Y <= sPos.Y+sSize.Height {
-				cardData := c.CollectCardData(mosuW)
-				c.RemoveObject(obj)
-				c.CommitCardDeleted(cardData)
-				c.Refresh()
-				return true
-			}
-		}
-	}
-	return false
-}
-
-func (t *EraseTool) eraseStrokeAt(c Canvas, screenPos fyne.Position) {
-	objects := c.ContentContainer().Objects
-	for i := len(objects) - 1; i >= 0; i-- {
-		obj := objects[i]
-
-		if line, ok := obj.(*canvas.Line); ok {
-			if pointNearSegment(screenPos, line.Position1, line.Position2, 10.0) {
-				strokeID, hasID := c.GetStrokeID(line)
-
-				if !hasID || !c.ValidateStrokeID(strokeID) {
-					t.removePairedLines(c, objects, line)
-					return
-				}
-
-				segments := c.CollectStrokeDataByID(strokeID)
-				t.removeStrokeByID(c, objects, strokeID, segments)
-				return
-			}
-		}
-	}
-}
-
-func (t *EraseTool) removePairedLines(c Canvas, objects []fyne.CanvasObject, line *canvas.Line) {
-	regularLine, glowLine := t.findPairedLines(c, objects, line)
-	segments := []storage.StrokeData{}
-	if regularLine != nil {
-		if p1, p2, ok := c.GetStrokePoints(regularLine); ok {
-			segments = append(segments, storage.StrokeData{P1X: p1.X, P1Y: p1.Y, P2X: p2.X, P2Y: p2.Y, Width: regularLine.StrokeWidth, StrokeID: 0})
-		}
-	}
-	if regularLine != nil {
-		c.RemoveObject(regularLine)
-	}
-	if glowLine != nil {
-		c.RemoveObject(glowLine)
+	mosuW := c.CardAt(screenPos)
+	if mosuW == nil {
+		return false
 	}
-	c.CommitStrokeDeleted(segments)
+	cardData := c.CollectCardData(mosuW)
+	c.RemoveObject(mosuW)
+	c.CommitCardDeleted(cardData)
 	c.Refresh()
+	return true
 }
 
-func (t *EraseTool) findPairedLines(c Canvas, objects []fyne.CanvasObject, line *canvas.Line) (*canvas.Line, *canvas.Line) {
-	var regularLine, glowLine *canvas.Line
-
-	if c.IsGlowLine(line) {
-		glowLine = line
-		regularLine = t.findMatchingLine(objects, line, false, c)
-	} else {
-		regularLine = line
-		glowLine = t.findMatchingLine(objects, line, true, c)
-	}
-
-	return regularLine, glowLine
-}
-
-func (t *EraseTool) findMatchingLine(objects []fyne.CanvasObject, target *canvas.Line, isGlow bool, c Canvas) *canvas.Line {
-	for _, o := range objects {
-		if l, ok := o.(*canvas.Line); ok && l != target {
-			if isGlow && c.IsGlowLine(l) && l.Position1 == target.Position1 && l.Position2 == target.Position2 {
-				return l
-			}
-			if !isGlow && !c.IsGlowLine(l) && l.Position1 == target.Position1 && l.Position2 == target.Position2 {
-				return l
-			}
-		}
-	}
-	return nil
-}
-
-func (t *EraseTool) removeStrokeByID(c Canvas, objects []fyne.CanvasObject, strokeID int, segments []storage.StrokeData) {
-	toRemove := []fyne.CanvasObject{}
-	for _, o := range objects {
-		if l, ok := o.(*canvas.Line); ok {
-			if id, exists := c.GetStrokeID(l); exists && id == strokeID {
-				toRemove = append(toRemove, l)
-			}
-		}
-	}
-
-	for _, o := range toRemove {
-		c.RemoveObject(o)
+func (t *EraseTool) eraseStrokeAt(c Canvas, screenPos fyne.Position) {
+	stroke := c.StrokeAt(screenPos, 10.0)
+	if stroke == nil {
+		return
 	}
 
+	segments := c.CollectStrokeDataByID(stroke.ID)
+	c.RemoveObject(stroke)
 	c.CommitStrokeDeleted(segments)
 	c.Refresh()
 }
-
-func pointNearSegment(p, a, b fyne.Position, threshold float32) bool {
-	x0, y0 := float64(p.X), float64(p.Y)
-	x1, y1 := float64(a.X), float64(a.Y)
-	x2, y2 := float64(b.X), float64(b.Y)
-
-	dx := x2 - x1
-	dy := y2 - y1
-	lengthSq := dx*dx + dy*dy
-
-	var dist float64
-	if lengthSq < 0.0001 {
-		dist = math.Sqrt((x0-x1)*(x0-x1) + (y0-y1)*(y0-y1))
-	} else {
-		t := ((x0-x1)*dx + (y0-y1)*dy) / lengthSq
-		if t < 0 {
-			t = 0
-		} else if t > 1 {
-			t = 1
-		}
-
-		projX := x1 + t*dx
-		projY := y1 + t*dy
-		dist = math.Sqrt((x0-projX)*(x0-projX) + (y0-projY)*(y0-projY))
-	}
-	return dist <= float64(threshold)
-}
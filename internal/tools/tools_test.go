@@ -0,0 +1,312 @@
+package tools_test
+
+import (
+	"image/color"
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/F4tal1t/Mosugo/internal/cards"
+	"github.com/F4tal1t/Mosugo/internal/strokes"
+	"github.com/F4tal1t/Mosugo/internal/testutil"
+	"github.com/F4tal1t/Mosugo/internal/tools"
+)
+
+// onEmptySpace stubs hit testing so that nothing lies under the pointer
+func onEmptySpace(c *testutil.MockCanvas) {
+	c.On("GetSelectedCard").Return((*cards.MosuWidget)(nil)).Maybe()
+	c.On("CardAt", mock.Anything).Return((*cards.MosuWidget)(nil)).Maybe()
+	c.On("StrokeAt", mock.Anything, mock.Anything).Return((*strokes.Stroke)(nil)).Maybe()
+}
+
+// marqueeFixture returns the objects a marquee over world 10-210 by 10-160
+// finds in its bounds, and those of them wholly inside it
+func marqueeFixture() (candidates, inside []fyne.CanvasObject) {
+	inCard := newTestCard("card_in", 30, 30, 60, 60)
+	partCard := newTestCard("card_part", 180, 100, 60, 60)
+	inStroke := newTestStroke(1, fyne.NewPos(50, 100), fyne.NewPos(200, 150))
+	partStroke := newTestStroke(2, fyne.NewPos(100, 100), fyne.NewPos(250, 100))
+	return []fyne.CanvasObject{inCard, partCard, inStroke, partStroke}, []fyne.CanvasObject{inCard, inStroke}
+}
+
+// TestSelectMarqueeSelectsInside tests that a drag on empty space selects the objects wholly inside it
+func TestSelectMarqueeSelectsInside(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to fyne.Position
+		shift    bool
+		method   string
+	}{
+		{"replaces the selection", fyne.NewPos(10, 10), fyne.NewPos(210, 160), false, "SetSelection"},
+		{"works dragged backwards", fyne.NewPos(210, 160), fyne.NewPos(10, 10), false, "SetSelection"},
+		{"shift adds to the selection", fyne.NewPos(10, 10), fyne.NewPos(210, 160), true, "AddToSelection"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := testutil.NewMockCanvas(t, 1)
+			onEmptySpace(c)
+			candidates, inside := marqueeFixture()
+			c.On("ObjectsInRect", fyne.NewPos(10, 10), fyne.NewPos(210, 160)).Return(candidates).Once()
+			c.On("ShiftHeld").Return(tt.shift)
+			c.On(tt.method, inside).Once()
+
+			ghost := canvas.NewRectangle(color.Black)
+			ghost.Hide()
+			c.On("GhostRect").Return(ghost)
+
+			dragThrough(&tools.SelectTool{}, c, tt.from, tt.to)
+			c.AssertNotCalled(t, "SetOffset", mock.Anything)
+		})
+	}
+}
+
+// TestSelectMarqueeShowsGhost tests that the ghost rect follows a marquee and is restored after it
+func TestSelectMarqueeShowsGhost(t *testing.T) {
+	c := testutil.NewMockCanvas(t, 2)
+	onEmptySpace(c)
+	c.On("ObjectsInRect", mock.Anything, mock.Anything).Return([]fyne.CanvasObject{})
+	c.On("ShiftHeld").Return(false)
+	c.On("SetSelection", []fyne.CanvasObject{}).Once()
+
+	fill, stroke := color.RGBA{R: 1, A: 255}, color.RGBA{G: 1, A: 255}
+	ghost := canvas.NewRectangle(fill)
+	ghost.StrokeColor = stroke
+	ghost.Hide()
+	c.On("GhostRect").Return(ghost)
+
+	tool := &tools.SelectTool{}
+	tool.OnDragged(c, &fyne.DragEvent{PointEvent: fyne.PointEvent{Position: fyne.NewPos(120, 80)}, Dragged: fyne.NewDelta(100, 60)})
+	assert.True(t, ghost.Visible(), "The marquee is shown while dragging")
+	assert.Equal(t, fyne.NewPos(20, 20), ghost.Position())
+	assert.Equal(t, fyne.NewSize(100, 60), ghost.Size())
+	assert.NotEqual(t, fill, ghost.FillColor, "The marquee is styled apart from the card ghost")
+
+	tool.OnDragged(c, &fyne.DragEvent{PointEvent: fyne.PointEvent{Position: fyne.NewPos(0, 40)}, Dragged: fyne.NewDelta(-120, -40)})
+	assert.Equal(t, fyne.NewPos(0, 20), ghost.Position(), "The marquee flips when dragged past its start")
+	assert.Equal(t, fyne.NewSize(20, 20), ghost.Size())
+
+	tool.OnDragEnd(c)
+	assert.False(t, ghost.Visible())
+	assert.Equal(t, fill, ghost.FillColor)
+	assert.Equal(t, stroke, ghost.StrokeColor)
+}
+
+// TestSelectTap tests what a click selects, with and without shift
+func TestSelectTap(t *testing.T) {
+	card := newTestCard("card_a", 0, 0, 60, 60)
+	stroke := newTestStroke(1, fyne.NewPos(100, 100), fyne.NewPos(150, 100))
+
+	tests := []struct {
+		name      string
+		shift     bool
+		card      *cards.MosuWidget
+		stroke    *strokes.Stroke
+		method    string
+		arguments []interface{}
+	}{
+		{"a card replaces the selection", false, card, nil, "SetSelection", []interface{}{[]fyne.CanvasObject{card}}},
+		{"a stroke replaces the selection", false, nil, stroke, "SetSelection", []interface{}{[]fyne.CanvasObject{stroke}}},
+		{"empty space deselects", false, nil, nil, "SetSelection", []interface{}{[]fyne.CanvasObject(nil)}},
+		{"shift toggles a card", true, card, nil, "ToggleSelected", []interface{}{card}},
+		{"shift toggles a stroke", true, nil, stroke, "ToggleSelected", []interface{}{stroke}},
+		{"shift on empty space keeps the selection", true, nil, nil, "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := testutil.NewMockCanvas(t, 1)
+			c.On("CardAt", mock.Anything).Return(tt.card)
+			c.On("StrokeAt", mock.Anything, mock.Anything).Return(tt.stroke).Maybe()
+			c.On("ShiftHeld").Return(tt.shift)
+			if tt.method != "" {
+				c.On(tt.method, tt.arguments...).Once()
+			}
+
+			(&tools.SelectTool{}).OnTapped(c, &fyne.PointEvent{Position: fyne.NewPos(10, 10)})
+			if tt.method == "" {
+				c.AssertNotCalled(t, "SetSelection", mock.Anything)
+				c.AssertNotCalled(t, "ToggleSelected", mock.Anything)
+			}
+		})
+	}
+}
+
+// TestSelectDragMovesSelection tests that dragging an object selects it if needed and moves the selection
+func TestSelectDragMovesSelection(t *testing.T) {
+	card := newTestCard("card_a", 30, 30, 60, 60)
+	stroke := newTestStroke(1, fyne.NewPos(200, 200), fyne.NewPos(250, 200))
+	// The drag travels 10,5 then 20,20 then 46,30 in all
+	path := []fyne.Position{{X: 40, Y: 40}, {X: 50, Y: 45}, {X: 60, Y: 60}, {X: 86, Y: 70}}
+
+	tests := []struct {
+		name      string
+		grab      fyne.CanvasObject
+		selected  bool
+		shift     bool
+		selection []fyne.CanvasObject
+		selects   string
+		steps     []fyne.Position
+	}{
+		{
+			name: "cards move in whole grid cells", grab: card,
+			selection: []fyne.CanvasObject{card}, selects: "SetSelection",
+			steps: []fyne.Position{{X: 30, Y: 30}, {X: 30, Y: 0}},
+		},
+		{
+			name: "shift adds the grabbed card", grab: card, shift: true,
+			selection: []fyne.CanvasObject{stroke, card}, selects: "AddToSelection",
+			steps: []fyne.Position{{X: 30, Y: 30}, {X: 30, Y: 0}},
+		},
+		{
+			name: "a selected card keeps the selection", grab: card, selected: true,
+			selection: []fyne.CanvasObject{card, stroke},
+			steps:     []fyne.Position{{X: 30, Y: 30}, {X: 30, Y: 0}},
+		},
+		{
+			name: "strokes alone move freely", grab: stroke,
+			selection: []fyne.CanvasObject{stroke}, selects: "SetSelection",
+			steps: []fyne.Position{{X: 10, Y: 5}, {X: 10, Y: 15}, {X: 26, Y: 10}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := testutil.NewMockCanvas(t, 1)
+			c.On("GetSelectedCard").Return((*cards.MosuWidget)(nil))
+			grabbedCard, _ := tt.grab.(*cards.MosuWidget)
+			c.On("CardAt", path[0]).Return(grabbedCard)
+			c.On("StrokeAt", path[0], mock.Anything).Return(stroke).Maybe()
+			c.On("IsSelected", tt.grab).Return(tt.selected)
+			c.On("ShiftHeld").Return(tt.shift).Maybe()
+			if tt.selects != "" {
+				c.On(tt.selects, []fyne.CanvasObject{tt.grab}).Once()
+			}
+			c.On("Selection").Return(tt.selection)
+
+			var total fyne.Position
+			for _, step := range tt.steps {
+				c.On("MoveSelection", step).Once()
+				total = total.Add(step)
+			}
+			c.On("CommitSelectionMoved", total).Once()
+
+			dragThrough(&tools.SelectTool{}, c, path...)
+			c.AssertNotCalled(t, "SetOffset", mock.Anything)
+		})
+	}
+}
+
+// TestSelectResizeHandles tests that dragging a handle moves its edges onto the grid
+func TestSelectResizeHandles(t *testing.T) {
+	// The card spans world 30-150 by 30-120
+	tests := []struct {
+		name     string
+		scale    float32
+		from, to fyne.Position // screen
+		wantPos  fyne.Position
+		wantSize fyne.Size
+	}{
+		{
+			name: "right edge snaps down", from: fyne.NewPos(150, 75), to: fyne.NewPos(190, 75),
+			wantPos: fyne.NewPos(30, 30), wantSize: fyne.NewSize(150, 90),
+		},
+		{
+			name: "right edge snaps up", from: fyne.NewPos(150, 75), to: fyne.NewPos(200, 75),
+			wantPos: fyne.NewPos(30, 30), wantSize: fyne.NewSize(180, 90),
+		},
+		{
+			name: "left edge", from: fyne.NewPos(30, 75), to: fyne.NewPos(10, 75),
+			wantPos: fyne.NewPos(0, 30), wantSize: fyne.NewSize(150, 90),
+		},
+		{
+			name: "top edge stops at the minimum size", from: fyne.NewPos(90, 30), to: fyne.NewPos(90, 80),
+			wantPos: fyne.NewPos(30, 60), wantSize: fyne.NewSize(120, 60),
+		},
+		{
+			name: "bottom edge stops at the minimum size", from: fyne.NewPos(90, 120), to: fyne.NewPos(90, 20),
+			wantPos: fyne.NewPos(30, 30), wantSize: fyne.NewSize(120, 60),
+		},
+		{
+			name: "left edge stops at the minimum size", from: fyne.NewPos(30, 75), to: fyne.NewPos(200, 75),
+			wantPos: fyne.NewPos(90, 30), wantSize: fyne.NewSize(60, 90),
+		},
+		{
+			name: "corner snaps both edges", from: fyne.NewPos(150, 120), to: fyne.NewPos(164, 136),
+			wantPos: fyne.NewPos(30, 30), wantSize: fyne.NewSize(120, 120),
+		},
+		{
+			name: "a handle is grabbed within the tolerance", from: fyne.NewPos(156, 80), to: fyne.NewPos(196, 80),
+			wantPos: fyne.NewPos(30, 30), wantSize: fyne.NewSize(150, 90),
+		},
+		{
+			name: "handles are hit in screen pixels when zoomed", scale: 2,
+			from: fyne.NewPos(306, 150), to: fyne.NewPos(386, 150),
+			wantPos: fyne.NewPos(30, 30), wantSize: fyne.NewSize(150, 90),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scale := tt.scale
+			if scale == 0 {
+				scale = 1
+			}
+			c := testutil.NewMockCanvas(t, scale)
+			card := newTestCard("card_a", 30, 30, 120, 90)
+			c.On("GetSelectedCard").Return(card)
+			c.On("UpdateCardBounds", card)
+			c.On("CommitCardResized", card, fyne.NewPos(30, 30), fyne.NewSize(120, 90)).Once()
+
+			// Drag in two steps, so the edges follow the whole distance
+			mid := fyne.NewPos((tt.from.X+tt.to.X)/2, (tt.from.Y+tt.to.Y)/2)
+			dragThrough(&tools.SelectTool{}, c, tt.from, mid, tt.to)
+			assert.Equal(t, tt.wantPos, card.WorldPos)
+			assert.Equal(t, tt.wantSize, card.WorldSize)
+		})
+	}
+}
+
+// TestSelectResizeMissesHandle tests that a drag starting away from every handle does not resize
+func TestSelectResizeMissesHandle(t *testing.T) {
+	tests := []struct {
+		name   string
+		scale  float32
+		from   fyne.Position // screen
+		onCard bool
+	}{
+		{name: "inside the card moves it", scale: 1, from: fyne.NewPos(90, 75), onCard: true},
+		{name: "beyond the tolerance draws a marquee", scale: 1, from: fyne.NewPos(160, 75)},
+		{name: "the tolerance does not grow when zoomed", scale: 2, from: fyne.NewPos(316, 150)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := testutil.NewMockCanvas(t, tt.scale)
+			card := newTestCard("card_a", 30, 30, 120, 90)
+			c.On("GetSelectedCard").Return(card)
+			c.On("StrokeAt", mock.Anything, mock.Anything).Return((*strokes.Stroke)(nil)).Maybe()
+			if tt.onCard {
+				c.On("CardAt", tt.from).Return(card)
+				c.On("IsSelected", card).Return(true)
+				c.On("Selection").Return([]fyne.CanvasObject{card})
+				c.On("MoveSelection", fyne.NewPos(30, 0)).Once()
+				c.On("CommitSelectionMoved", fyne.NewPos(30, 0)).Once()
+			} else {
+				c.On("CardAt", tt.from).Return((*cards.MosuWidget)(nil))
+				c.On("GhostRect").Return(canvas.NewRectangle(color.Black))
+				c.On("ObjectsInRect", mock.Anything, mock.Anything).Return([]fyne.CanvasObject{})
+				c.On("ShiftHeld").Return(false)
+				c.On("SetSelection", []fyne.CanvasObject{}).Once()
+			}
+
+			dragThrough(&tools.SelectTool{}, c, tt.from, tt.from.Add(fyne.NewPos(40*tt.scale, 0)))
+			c.AssertNotCalled(t, "CommitCardResized", mock.Anything, mock.Anything, mock.Anything)
+			assert.Equal(t, fyne.NewPos(30, 30), card.WorldPos)
+			assert.Equal(t, fyne.NewSize(120, 90), card.WorldSize)
+		})
+	}
+}
@@ -88,6 +88,7 @@ func newCompactGrid(cols int, verticalSpacing float32, objects ...fyne.CanvasObj
 type CalendarContent struct {
 	widget.BaseWidget
 
+	store          storage.Store
 	currentDate    time.Time
 	onDateSelected func(time.Time)
 
@@ -96,8 +97,11 @@ type CalendarContent struct {
 	renderer  *calendarRenderer
 }
 
-func NewCalendarContent(currentDate time.Time, onDateSelected func(time.Time)) *CalendarContent {
+// NewCalendarContent creates a month view of currentDate that marks the days
+// saved in store.
+func NewCalendarContent(store storage.Store, currentDate time.Time, onDateSelected func(time.Time)) *CalendarContent {
 	c := &CalendarContent{
+		store:          store,
 		currentDate:    currentDate,
 		onDateSelected: onDateSelected,
 	}
@@ -127,7 +131,7 @@ func (c *CalendarContent) formatDate(date time.Time) string {
 
 func (c *CalendarContent) createMonthView(date time.Time) *fyne.Container {
 	// Get saved dates
-	savedDates, err := storage.ListSavedDates()
+	savedDates, err := c.store.ListSavedDates()
 	if err != nil {
 		savedDates = []time.Time{}
 	}
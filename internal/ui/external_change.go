@@ -0,0 +1,73 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/F4tal1t/Mosugo/internal/storage"
+)
+
+// ExternalChoice is what to do with a day that changed on disk while it has
+// unsaved changes on the canvas.
+type ExternalChoice int
+
+const (
+	// KeepMine saves the canvas over the other version
+	KeepMine ExternalChoice = iota
+	// LoadTheirs drops the unsaved changes and loads the other version
+	LoadTheirs
+	// MergeBoth combines the changes made on the canvas and in the other version
+	MergeBoth
+)
+
+// ShowExternalChangeDialog asks what to do about the workspace of date
+// having changed outside Mosugo while the canvas has unsaved changes, and
+// calls onChoice with the answer.
+func ShowExternalChangeDialog(parent fyne.Window, date time.Time, onChoice func(ExternalChoice)) {
+	message := widget.NewLabel(fmt.Sprintf(
+		"The workspace for %s was changed outside Mosugo, and you have unsaved changes.",
+		date.Format("2006-01-02")))
+	message.Wrapping = fyne.TextWrapWord
+
+	d := dialog.NewCustomWithoutButtons("Workspace changed on disk", message, parent)
+	choose := func(choice ExternalChoice) func() {
+		return func() {
+			d.Hide()
+			onChoice(choice)
+		}
+	}
+
+	keep := widget.NewButton("Keep mine", choose(KeepMine))
+	load := widget.NewButton("Load theirs", choose(LoadTheirs))
+	merge := widget.NewButton("Merge", choose(MergeBoth))
+	merge.Importance = widget.HighImportance
+	d.SetButtons([]fyne.CanvasObject{keep, load, merge})
+
+	d.Resize(fyne.NewSize(420, 160))
+	d.Show()
+}
+
+// ShowMergeConflictsDialog lists the changes a merge could not combine, so
+// the user knows where to look.
+func ShowMergeConflictsDialog(parent fyne.Window, conflicts []storage.MergeConflict) {
+	lines := []string{"Some changes were made in both versions. Yours were kept, and edited text from the other version was added as a card beside yours:", ""}
+	for _, conflict := range conflicts {
+		if conflict.CardID != "" {
+			lines = append(lines, fmt.Sprintf("Card %s: %s", conflict.CardID, conflict.Reason))
+		} else {
+			lines = append(lines, fmt.Sprintf("Stroke %d: %s", conflict.StrokeID, conflict.Reason))
+		}
+	}
+	message := widget.NewLabel(strings.Join(lines, "\n"))
+	message.Wrapping = fyne.TextWrapWord
+
+	d := dialog.NewCustom("Merge conflicts", "OK", container.NewVScroll(message), parent)
+	d.Resize(fyne.NewSize(420, 300))
+	d.Show()
+}
@@ -0,0 +1,150 @@
+package ui
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/widget"
+
+	mosuCanvas "github.com/F4tal1t/Mosugo/internal/canvas"
+	"github.com/F4tal1t/Mosugo/internal/theme"
+)
+
+// historyPanelSize is the smallest size the history panel takes on screen
+var historyPanelSize = fyne.NewSize(260, 320)
+
+// HistoryPanel is the timeline of the day's history: every step with its
+// time and what it did. Dragging the slider, or tapping a step, undoes or
+// redoes to that point as a preview; "Branch here" drops the steps after it,
+// so the day carries on from there.
+type HistoryPanel struct {
+	widget.BaseWidget
+
+	canvas *mosuCanvas.MosugoCanvas
+	items  []mosuCanvas.HistoryItem
+	pos    int // steps applied; row pos of the list is the current state
+
+	slider  *widget.Slider
+	list    *widget.List
+	status  *widget.Label
+	branch  *widget.Button
+	syncing bool // updating the widgets from the canvas, not the user
+}
+
+// NewHistoryPanel creates a timeline for c, which it keeps up to date.
+func NewHistoryPanel(c *mosuCanvas.MosugoCanvas) *HistoryPanel {
+	p := &HistoryPanel{canvas: c}
+	p.ExtendBaseWidget(p)
+
+	p.status = widget.NewLabel("")
+	p.slider = widget.NewSlider(0, 1)
+	p.slider.Step = 1
+	p.slider.OnChanged = func(v float64) {
+		p.travel(int(v))
+	}
+	p.branch = widget.NewButton("Branch here", func() {
+		p.canvas.BranchAt(p.pos)
+	})
+
+	// Row 0 is the day as it was opened, row i the state after step i
+	p.list = widget.NewList(
+		func() int { return len(p.items) + 1 },
+		func() fyne.CanvasObject {
+			at := widget.NewLabel("00:00:00")
+			at.TextStyle = fyne.TextStyle{Monospace: true}
+			return container.NewHBox(at, widget.NewLabel(""))
+		},
+		p.updateRow,
+	)
+	p.list.OnSelected = func(id widget.ListItemID) {
+		p.travel(id)
+	}
+
+	c.SetOnHistoryChanged(p.Sync)
+	p.Sync()
+	return p
+}
+
+func (p *HistoryPanel) updateRow(id widget.ListItemID, obj fyne.CanvasObject) {
+	row := obj.(*fyne.Container)
+	at := row.Objects[0].(*widget.Label)
+	desc := row.Objects[1].(*widget.Label)
+
+	if id == 0 {
+		at.SetText("--:--:--")
+		desc.SetText("Start of day")
+	} else {
+		item := p.items[id-1]
+		if item.At.IsZero() {
+			at.SetText("--:--:--")
+		} else {
+			at.SetText(item.At.Format("15:04:05"))
+		}
+		desc.SetText(item.Description)
+	}
+
+	// Steps past the current point are only there to redo
+	importance := widget.MediumImportance
+	if id > p.pos {
+		importance = widget.LowImportance
+	}
+	at.Importance = importance
+	desc.Importance = importance
+	at.Refresh()
+	desc.Refresh()
+}
+
+func (p *HistoryPanel) travel(pos int) {
+	if p.syncing || pos == p.pos {
+		return
+	}
+	if !p.canvas.TravelTo(pos) {
+		// Out of range or mid-gesture: put the widgets back
+		p.Sync()
+	}
+}
+
+// Sync reloads the timeline from the canvas.
+func (p *HistoryPanel) Sync() {
+	p.items, p.pos = p.canvas.Timeline()
+
+	p.syncing = true
+	defer func() { p.syncing = false }()
+
+	if len(p.items) == 0 {
+		p.slider.Max = 1
+		p.slider.Disable()
+	} else {
+		p.slider.Max = float64(len(p.items))
+		p.slider.Enable()
+	}
+	p.slider.SetValue(float64(p.pos))
+	p.status.SetText(fmt.Sprintf("Step %d of %d", p.pos, len(p.items)))
+	if p.pos < len(p.items) {
+		p.branch.Enable()
+	} else {
+		p.branch.Disable()
+	}
+	p.list.Refresh()
+	p.list.Select(p.pos)
+}
+
+func (p *HistoryPanel) CreateRenderer() fyne.WidgetRenderer {
+	bg := canvas.NewRectangle(theme.GridBg)
+	bg.StrokeColor = theme.GridLine
+	bg.StrokeWidth = 1
+	bg.CornerRadius = 8
+	bg.SetMinSize(historyPanelSize)
+
+	title := widget.NewLabel("History")
+	title.TextStyle = fyne.TextStyle{Bold: true}
+
+	top := container.NewHBox(title, layout.NewSpacer(), p.status)
+	bottom := container.NewBorder(nil, nil, nil, p.branch, p.slider)
+	content := container.NewBorder(top, bottom, nil, nil, p.list)
+
+	return widget.NewSimpleRenderer(container.NewStack(bg, container.NewPadded(content)))
+}
@@ -0,0 +1,42 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/F4tal1t/Mosugo/internal/storage"
+)
+
+// ShowRecoveryWarning tells the user that the workspace file of date was
+// damaged, what was recovered, and where the damaged file was kept.
+func ShowRecoveryWarning(parent fyne.Window, date time.Time, recovery storage.Recovery) {
+	lines := []string{fmt.Sprintf("The workspace file for %s is damaged.", date.Format("2006-01-02"))}
+
+	if recovery.SalvagedCards > 0 || recovery.SalvagedStrokes > 0 {
+		lines = append(lines, fmt.Sprintf("%d cards and %d strokes could still be read from it.",
+			recovery.SalvagedCards, recovery.SalvagedStrokes))
+	}
+	if recovery.RestoredFrom != "" {
+		lines = append(lines, fmt.Sprintf("The rest was restored from the backup %s, so something deleted since then may be back.",
+			filepath.Base(recovery.RestoredFrom)))
+	} else if recovery.SalvagedCards == 0 && recovery.SalvagedStrokes == 0 {
+		lines = append(lines, "Nothing could be recovered from it, and there is no backup.")
+	}
+	if recovery.QuarantinedTo != "" {
+		lines = append(lines, fmt.Sprintf("The damaged file was kept as %s.", filepath.Base(recovery.QuarantinedTo)))
+	}
+	lines = append(lines, "", fmt.Sprintf("Details: %v", recovery.Err))
+
+	message := widget.NewLabel(strings.Join(lines, "\n"))
+	message.Wrapping = fyne.TextWrapWord
+
+	d := dialog.NewCustom("Damaged workspace", "OK", message, parent)
+	d.Resize(fyne.NewSize(440, 240))
+	d.Show()
+}